@@ -0,0 +1,563 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/controlplane"
+	gfy "stillgrove.com/gofeedyourself/pkg/feedservice"
+	config "stillgrove.com/gofeedyourself/pkg/feedservice/config"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
+	"stillgrove.com/gofeedyourself/pkg/secrets"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func runCmd(configPath string, args []string) error {
+	var (
+		backend     string
+		production  bool
+		forceDelete bool
+		purgeImages bool
+		profile     bool
+		feeds       feedList
+	)
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.StringVar(&backend, "backend", "woocommerce", "woocommerce, vsf-dump, csv, or catalog")
+	fs.BoolVar(&production, "production", false, "apply the update, otherwise the requests are only written to the logs folder")
+	fs.BoolVar(&forceDelete, "force-delete", false, "apply all deletions, even if they exceed the deletion guard")
+	fs.BoolVar(&purgeImages, "purge-images", false, "remove the image assets from FTP before uploading")
+	fs.Var(&feeds, "feed", "only run the feeds whose names start with this, repeat or separate by comma")
+	fs.BoolVar(&profile, "profile", false, "write cpu and memory profiles to the logs folder")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(configPath, backend)
+	if err != nil {
+		return err
+	}
+	if profile {
+		stop, err := startProfile(helpers.FindFolderDir("gofeedyourself") + "/logs")
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	p, err := gfy.New(cfg, backend, production)
+	if err != nil {
+		return err
+	}
+	if forceDelete {
+		p.OverrideDeletionGuard()
+	}
+	p.SetFeeds(feeds)
+
+	err = p.RunLocked(production, purgeImages)
+	if err != nil {
+		return err
+	}
+
+	mode := "dev"
+	if production {
+		mode = "production"
+	}
+	pushMetrics(cfg.GetPushGateway(), "feedservice_"+mode)
+
+	return nil
+}
+
+func dryRunCmd(configPath string, args []string) error {
+	var feeds feedList
+	fs := flag.NewFlagSet("dry-run", flag.ExitOnError)
+	fs.Var(&feeds, "feed", "only run the feeds whose names start with this, repeat or separate by comma")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(configPath, "woocommerce")
+	if err != nil {
+		return err
+	}
+	p, err := gfy.New(cfg, "woocommerce", true)
+	if err != nil {
+		return err
+	}
+	p.SetDryRun(true)
+	p.SetFeeds(feeds)
+
+	err = p.RunLocked(false, false)
+	if err != nil {
+		return err
+	}
+
+	pushMetrics(cfg.GetPushGateway(), "feedservice_dry_run")
+
+	return nil
+}
+
+func serveCmd(configPath string, args []string) error {
+	cfg, err := loadConfig(configPath, "woocommerce")
+	if err != nil {
+		return err
+	}
+	p, err := gfy.New(cfg, "woocommerce", true)
+	if err != nil {
+		return err
+	}
+	err = p.ReloadMappings()
+	if err != nil {
+		log.WithField("Error", err).Warnln("Not ready")
+	}
+
+	addr, token, err := cfg.GetControlPlane()
+	if err != nil {
+		return err
+	}
+	var api *controlplane.Server
+	if addr != "" {
+		api, err = controlplane.New(addr, token, p)
+		if err != nil {
+			return err
+		}
+		api.Start()
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	err = p.Serve(stop)
+	if err != nil {
+		return err
+	}
+
+	if api != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		api.Shutdown(ctx)
+	}
+
+	return nil
+}
+
+func resumeCmd(configPath string, args []string) error {
+	cfg, err := loadConfig(configPath, "woocommerce")
+	if err != nil {
+		return err
+	}
+	p, err := gfy.New(cfg, "woocommerce", true)
+	if err != nil {
+		return err
+	}
+	p.Resume()
+
+	return nil
+}
+
+func purgeCmd(configPath string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: purge products|images")
+	}
+
+	cfg, err := loadConfig(configPath, "woocommerce")
+	if err != nil {
+		return err
+	}
+	p, err := gfy.New(cfg, "woocommerce", true)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "products":
+		p.PurgeProducts()
+		return nil
+	case "images":
+		return p.PurgeImages()
+	default:
+		return fmt.Errorf("Unknown purge target %q, permitted options: products and images", args[0])
+	}
+}
+
+func validateCmd(configPath string, args []string) error {
+	var (
+		backend string
+		show    bool
+	)
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	fs.StringVar(&backend, "backend", "woocommerce", "woocommerce, vsf-dump, csv, or catalog")
+	fs.BoolVar(&show, "show", false, "print every setting and where it came from, secrets are masked")
+	fs.Parse(args)
+
+	cfg, err := config.Load(configPath, backend)
+	if show {
+		for _, line := range cfg.Describe() {
+			fmt.Println(line)
+		}
+	}
+	if verr, ok := err.(config.ValidationError); ok {
+		fmt.Printf("Config %s is invalid for %s:\n", configPath, backend)
+		for i := range verr.Problems {
+			fmt.Printf(" - %s\n", verr.Problems[i])
+		}
+		return fmt.Errorf("Config %s has %d problems", configPath, len(verr.Problems))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Config %s is valid for %s with feeds %s\n", configPath, backend, strings.Join(cfg.GetFeeds(), ", "))
+
+	return nil
+}
+
+func mappingsCmd(configPath string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Usage: mappings pull|report")
+	}
+
+	var out string
+	fs := flag.NewFlagSet("mappings "+args[0], flag.ExitOnError)
+	fs.StringVar(&out, "out", helpers.FindFolderDir("gofeedyourself")+"/dump/mappings", "folder for the JSON files")
+	fs.Parse(args[1:])
+
+	cfg, err := loadConfig(configPath, "vsf-dump")
+	if err != nil {
+		return err
+	}
+	p, err := gfy.New(cfg, "vsf-dump", false)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "pull":
+		err = p.ExportMappings(out)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote mappings to %s\n", out)
+	case "report":
+		stats, err := p.MappingReport()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "MAPPING\tTERMS\tTARGETS")
+		for i := range stats {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", stats[i].Name, stats[i].Keys, stats[i].Values)
+		}
+		w.Flush()
+	default:
+		return fmt.Errorf("Unknown mappings command %q, permitted options: pull and report", args[0])
+	}
+
+	return nil
+}
+
+func cacheCmd(configPath string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Usage: cache list|clear [name ...]")
+	}
+
+	switch args[0] {
+	case "list":
+		caches, err := gfy.Caches()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CACHE\tSIZE (MB)\tMODIFIED")
+		for i := range caches {
+			fmt.Fprintf(
+				w, "%s\t%.1f\t%s\n",
+				caches[i].Name,
+				float64(caches[i].Size)/(1<<20),
+				caches[i].Modified.Format("2006-01-02 15:04"),
+			)
+		}
+		w.Flush()
+		return nil
+	case "clear":
+		return gfy.ClearCaches(args[1:])
+	default:
+		return fmt.Errorf("Unknown cache command %q, permitted options: list and clear", args[0])
+	}
+}
+
+func rankCmd(configPath string, args []string) error {
+	if len(args) == 0 || args[0] != "explain" {
+		return fmt.Errorf("Usage: rank explain [-feed name] [-top n] [-production] [query]")
+	}
+
+	var (
+		feeds      feedList
+		top        int
+		production bool
+	)
+	fs := flag.NewFlagSet("rank explain", flag.ExitOnError)
+	fs.Var(&feeds, "feed", "only load the feeds whose names start with this, repeat or separate by comma")
+	fs.IntVar(&top, "top", 10, "number of products to explain, 0 for all")
+	fs.BoolVar(&production, "production", false, "load the full feeds instead of samples")
+	fs.Parse(args[1:])
+
+	cfg, err := loadConfig(configPath, "vsf-dump")
+	if err != nil {
+		return err
+	}
+	p, err := gfy.New(cfg, "vsf-dump", production)
+	if err != nil {
+		return err
+	}
+	p.SetFeeds(feeds)
+
+	explanations, err := p.ExplainRanking(strings.Join(fs.Args(), " "), top)
+	if err != nil {
+		return err
+	}
+	if len(explanations) == 0 {
+		return fmt.Errorf("No product matches %q", strings.Join(fs.Args(), " "))
+	}
+	for i := range explanations {
+		fmt.Println(explanations[i])
+	}
+
+	return nil
+}
+
+func feedsCmd(configPath string, args []string) error {
+	if len(args) != 1 || args[0] != "list" {
+		return fmt.Errorf("Usage: feeds list")
+	}
+
+	cfg, err := loadConfig(configPath, "vsf-dump")
+	if err != nil {
+		return err
+	}
+	p, err := gfy.New(cfg, "vsf-dump", false)
+	if err != nil {
+		return err
+	}
+
+	feeds, err := p.Feeds()
+	if err != nil {
+		return err
+	}
+	for i := range feeds {
+		fmt.Println(feeds[i].GetName())
+	}
+
+	_, website, err := cfg.GetTD()
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(website.Feeds))
+	for id := range website.Feeds {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "\nTRADEDOUBLER %s\tID\tSAMPLE SIZE\n", website.Name)
+	for _, id := range ids {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", website.Feeds[id].Name, id, website.Feeds[id].SampleSize)
+	}
+	w.Flush()
+	if len(ids) == 0 {
+		fmt.Println("all feeds except the excluded ones")
+	}
+
+	return nil
+}
+
+func catalogCmd(configPath string, args []string) error {
+	if len(args) == 0 || args[0] != "serve" {
+		return fmt.Errorf("Usage: catalog serve [-addr addr]")
+	}
+
+	var addr string
+	fs := flag.NewFlagSet("catalog serve", flag.ExitOnError)
+	fs.StringVar(&addr, "addr", ":8081", "listen address")
+	fs.Parse(args[1:])
+
+	dir := gfy.CatalogDir()
+	server := &http.Server{
+		Addr:    addr,
+		Handler: http.StripPrefix("/catalog/", http.FileServer(http.Dir(dir))),
+	}
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+		<-stop
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	log.WithFields(
+		log.Fields{
+			"Addr":   addr,
+			"Folder": dir,
+		},
+	).Infoln("Serving catalog under /catalog/")
+	err := server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func healthCmd(configPath string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Usage: health show|accept <feed>")
+	}
+
+	switch args[0] {
+	case "show":
+		records, err := gfy.HealthRecords()
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(records))
+		for name := range records {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "FEED\tPRODUCTS\tIN STOCK\tMEDIAN PRICE\tCATEGORIES\tMAPPED\tSTATE")
+		for _, name := range names {
+			r := records[name]
+			state := "ok"
+			if r.Quarantined {
+				state = "quarantined"
+			} else if len(r.Anomalies) > 0 {
+				state = "flagged"
+			}
+			fmt.Fprintf(
+				w, "%s\t%d (%d)\t%.2f (%.2f)\t%.2f %s (%.2f %s)\t%d (%d)\t%.2f (%.2f)\t%s\n",
+				name,
+				r.Latest.Products, r.Baseline.Products,
+				r.Latest.InStockShare, r.Baseline.InStockShare,
+				r.Latest.MedianPrice, r.Latest.Currency, r.Baseline.MedianPrice, r.Baseline.Currency,
+				r.Latest.Categories, r.Baseline.Categories,
+				r.Latest.MappingHitRate, r.Baseline.MappingHitRate,
+				state,
+			)
+		}
+		w.Flush()
+		fmt.Println("\nLatest download (baseline)")
+		return nil
+	case "accept":
+		if len(args) != 2 {
+			return fmt.Errorf("Usage: health accept <feed>")
+		}
+		err := gfy.AcceptFeedHealth(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Accepted the latest profile of %s as its baseline\n", args[1])
+		return nil
+	default:
+		return fmt.Errorf("Unknown health command %q, permitted options: show and accept", args[0])
+	}
+}
+
+func secretsCmd(configPath string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Usage: secrets keygen|seal")
+	}
+
+	switch args[0] {
+	case "keygen":
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	case "seal":
+		var in, out string
+		fs := flag.NewFlagSet("secrets seal", flag.ExitOnError)
+		fs.StringVar(&in, "in", "private-env.list", "env file with NAME=value lines")
+		fs.StringVar(&out, "out", "secrets.enc", "encrypted file for SECRETS_FILE")
+		fs.Parse(args[1:])
+
+		b, err := ioutil.ReadFile(in)
+		if err != nil {
+			return err
+		}
+		values := make(map[string]string)
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("Expected NAME=value in %s, not %q", in, kv[0])
+			}
+			values[strings.TrimSpace(kv[0])] = kv[1]
+		}
+
+		sealed, err := secrets.Seal(values, os.Getenv("SECRETS_KEY"))
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(out, sealed, 0600)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Sealed %d secrets to %s\n", len(values), out)
+		return nil
+	default:
+		return fmt.Errorf("Unknown secrets command %q, permitted options: keygen and seal", args[0])
+	}
+}
+
+// startProfile writes a cpu profile until stop is called, which also writes the heap profile
+func startProfile(dir string) (stop func(), err error) {
+	cpu, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		return nil, fmt.Errorf("Create cpu profile - %v", err)
+	}
+	err = pprof.StartCPUProfile(cpu)
+	if err != nil {
+		cpu.Close()
+		return nil, fmt.Errorf("Start cpu profile - %v", err)
+	}
+
+	return func() {
+		pprof.StopCPUProfile()
+		cpu.Close()
+
+		mem, err := os.Create(filepath.Join(dir, "mem.pprof"))
+		if err != nil {
+			log.WithField("Error", err).Warnln("Failed to create memory profile")
+			return
+		}
+		defer mem.Close()
+		err = pprof.WriteHeapProfile(mem)
+		if err != nil {
+			log.WithField("Error", err).Warnln("Failed to write memory profile")
+		}
+	}, nil
+}
+
+// pushMetrics sends the metrics of a one-off run to the push gateway, if one is configured
+func pushMetrics(gateway, job string) {
+	if gateway == "" {
+		return
+	}
+	err := metrics.Default.Push(gateway, job)
+	if err != nil {
+		log.WithField("Error", err).Warnln("Failed to push metrics")
+	}
+}
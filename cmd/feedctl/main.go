@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	config "stillgrove.com/gofeedyourself/pkg/feedservice/config"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ConfigUsage = "path of the yaml config (default config/config.se.dev.yaml in the repository)"
+	HostUsage   = "override host from config, e.g. to localhost:8080 for development"
+	Usage       = `Usage: feedctl [-config path] [-host host] <command> [arguments]
+
+Commands:
+  run [-backend woocommerce|vsf-dump|csv|catalog] [-production] [-feed name] [-force-delete] [-purge-images] [-profile]
+  dry-run [-feed name]          load the production feeds and write the requests to the logs folder
+  serve                         run on the configured schedule with the control plane
+  resume                        replay the unfinished requests of the last interrupted sync
+  purge products|images         delete all products (and images) from WooCommerce, or only the images
+  validate-config [-backend b] [-show]  check the config and environment variables
+  mappings pull [-out dir]      write the mapping tables from Google Sheets as JSON files
+  mappings report               show the size of the mapping tables
+  cache list                    list the on-disk caches
+  cache clear [name ...]        remove the named caches, or all of them
+  feeds list                    list the configured feeds
+  rank explain [-feed name] [-top n] [-production] [query]  break down the ranking scores of the best or matching products
+  catalog serve [-addr addr]    serve the files of the catalog backend under /catalog/
+  health show                   compare the latest download of every feed with its baseline
+  health accept <feed>          make the latest profile of a feed its baseline, e.g. after a currency switch
+  secrets keygen                print a new key for SECRETS_KEY
+  secrets seal [-in f] [-out f] encrypt an env file with SECRETS_KEY for SECRETS_FILE
+`
+)
+
+var (
+	configFlag string
+	// HostFlag allows to ovveride the domain of the WooCommerce Database to be updated
+	HostFlag string
+	// BuildTime will be populated by the linker to tell builds appart after they were shipped
+	BuildTime string
+)
+
+type command func(configPath string, args []string) error
+
+var commands = map[string]command{
+	"run":             runCmd,
+	"dry-run":         dryRunCmd,
+	"serve":           serveCmd,
+	"resume":          resumeCmd,
+	"purge":           purgeCmd,
+	"validate-config": validateCmd,
+	"mappings":        mappingsCmd,
+	"cache":           cacheCmd,
+	"feeds":           feedsCmd,
+	"rank":            rankCmd,
+	"secrets":         secretsCmd,
+	"health":          healthCmd,
+	"catalog":         catalogCmd,
+}
+
+func init() {
+	flag.StringVar(&configFlag, "config", "", ConfigUsage)
+	flag.StringVar(&HostFlag, "host", "", HostUsage)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), Usage)
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	log.SetFormatter(&log.TextFormatter{
+		DisableColors: false,
+		FullTimestamp: true,
+	})
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name, args := flag.Arg(0), flag.Args()[1:]
+	if name == "help" {
+		flag.Usage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(flag.CommandLine.Output(), "Unknown command %q\n\n", name)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if configFlag == "" {
+		configFlag = helpers.FindFolderDir("gofeedyourself") + "/config/config.se.dev.yaml"
+	}
+
+	log.WithFields(
+		log.Fields{
+			"Image Built on": BuildTime,
+			"Started at":     time.Now().UTC(),
+			"Command":        strings.Join(flag.Args(), " "),
+			"Config":         configFlag,
+		},
+	).Println("Application Started")
+
+	err := cmd(configFlag, args)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// loadConfig reads and validates the config for the backend, see validate-config for the details
+func loadConfig(path, backend string) (cfg *config.File, err error) {
+	cfg, err = config.Load(path, backend)
+	if err != nil {
+		return cfg, err
+	}
+
+	if len(HostFlag) > 0 {
+		if helpers.IsOnline(HostFlag) {
+			log.WithField(HostFlag, "GET successful").Println("Custom Host flag set")
+			cfg.SetHost(HostFlag)
+			return cfg, nil
+		}
+		log.WithField(HostFlag, "Couldn't GET").Println("Custom Host flag rejected")
+	}
+
+	return cfg, nil
+}
+
+// feedList collects repeated or comma separated -feed flags
+type feedList []string
+
+func (f *feedList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *feedList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			*f = append(*f, name)
+		}
+	}
+	return nil
+}
@@ -13,4 +13,4 @@ sudo nohup docker run \
     -v "$(pwd)"/cache:/cache \
     -v "$(pwd)"/logs:/logs \
     {{repo}}.dkr.ecr.eu-central-1.amazonaws.com/{{reponame}} \
-    --mode=production env &
\ No newline at end of file
+    -config ./config/config.se.prod.yaml run -production &
\ No newline at end of file
@@ -0,0 +1,101 @@
+// +build unit
+// +build !integration
+
+package adtraction
+
+import (
+	"strings"
+	"testing"
+
+	adc "stillgrove.com/gofeedyourself/pkg/adtraction/client"
+	"stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<productFeed>
+  <product>
+    <SKU>A1</SKU>
+    <Name>Brand Summer Dress</Name>
+    <Description>A navy summer dress</Description>
+    <Category>Dam &gt; Klänningar</Category>
+    <Price>499,00</Price>
+    <OriginalPrice>799.00</OriginalPrice>
+    <Shipping>49</Shipping>
+    <Currency>SEK</Currency>
+    <Instock>yes</Instock>
+    <ProductUrl>https://shop.se/a1</ProductUrl>
+    <ImageUrl>https://shop.se/a1.jpg</ImageUrl>
+    <TrackingUrl>https://track.adtraction.com/t/t?a=1&amp;url=https://shop.se/a1</TrackingUrl>
+    <Brand>Brand</Brand>
+    <Ean>7350000000017</Ean>
+    <Extras>
+      <Extra><Name>COLOR</Name><Value>Navy</Value></Extra>
+      <Extra><Name>SIZE</Name><Value>S, M</Value></Extra>
+      <Extra><Name>GENDER</Name><Value>women</Value></Extra>
+    </Extras>
+  </product>
+  <product>
+    <SKU>A2</SKU>
+    <Name>Shirt without price</Name>
+    <Category>Herr</Category>
+    <Currency>SEK</Currency>
+    <ImageUrl>https://shop.se/a2.jpg</ImageUrl>
+    <TrackingUrl>https://track.adtraction.com/t/t?a=2</TrackingUrl>
+  </product>
+</productFeed>`
+
+func TestProduct(t *testing.T) {
+	var products []adc.Product
+	err := adc.ReadProducts(strings.NewReader(testFeed), func(p adc.Product) bool {
+		products = append(products, p)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 || products[0].Extra("color") != "Navy" {
+		t.Fatalf("Expected two products with extras - %+v", products)
+	}
+	if products[1].Validate() == nil {
+		t.Fatalf("Products without a price must not validate")
+	}
+
+	for _, prices := range [][2]string{{"1 299,00", "1 599,00"}, {"1,299.00", "1,599.00"}, {"1299", "1.599"}} {
+		product := adc.Product{Price: prices[0], OriginalPrice: prices[1]}
+		price, regular, err := product.GetPrices()
+		if err != nil || price != 1299 || regular != 1599 {
+			t.Fatalf("Wrong prices for %v - %v and %v (%v)", prices, price, regular, err)
+		}
+	}
+
+	blue, dress := "blue", "dresses"
+	mapping := &feed.Mapping{
+		ColorMap:   map[string][]*string{"navy": {&blue}},
+		CatNameMap: map[string][]*string{"klänningar": {&dress}},
+	}
+	products[0].ProgramName = "Shop"
+	p, err := (&Product{&products[0], mapping, "sv_se"}).ToFeedProduct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := p.Retailers[0]
+	if p.SKU != "7350000000017" || p.Gender != "women" || !p.Active || p.GetKey() == 0 {
+		t.Fatalf("Wrong product - %+v", p)
+	}
+	if r.Name != "Shop" || r.Price != "499.00" || r.HighestPrice != 799 || len(r.Sizes) != 2 || r.ShippingCost != "49" {
+		t.Fatalf("Wrong retailer - %+v", r)
+	}
+	if !collection.StringInList("blue", p.ColorGroups) {
+		t.Fatalf("Expected the mapped color - %v", p.ColorGroups)
+	}
+	for gender, expected := range map[string]string{"men": "men", "Male": "men", "unisex": "unisex"} {
+		other := products[0]
+		other.Extras = []adc.Extra{{Name: "COLOR", Value: "Navy"}, {Name: "GENDER", Value: gender}}
+		other.Category = "Kläder"
+		p, err = (&Product{&other, mapping, "sv_se"}).ToFeedProduct()
+		if err != nil || p.Gender != expected {
+			t.Fatalf("Gender %s - expected %s, got %q (%v)", gender, expected, p.Gender, err)
+		}
+	}
+}
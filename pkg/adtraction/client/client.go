@@ -0,0 +1,73 @@
+package adtractionclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/network"
+)
+
+const (
+	// the product feeds of the big retailers take a while to download
+	requestTimeout = 30 * time.Minute
+)
+
+// Client talks to the Adtraction partner API of one channel (our website) in one market
+type Client struct {
+	token     string
+	channelID uint64
+	locale    *feed.Locale
+	http      *http.Client
+}
+
+// New returns a Client for the market of the locale
+func New(locale *feed.Locale, token string, channelID uint64) (c *Client, err error) {
+	var exists bool
+	for i := range Markets {
+		if Markets[i] == strings.ToUpper(locale.TwoLetterCode) {
+			exists = true
+		}
+	}
+	if !exists {
+		return c, fmt.Errorf("Market not mapped - %s", locale.TwoLetterCode)
+	}
+	if token == "" || channelID == 0 {
+		return c, fmt.Errorf("Token and channel id are required")
+	}
+
+	return &Client{
+		token:     token,
+		channelID: channelID,
+		locale:    locale,
+		http: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}, nil
+}
+
+// Market returns the two letter country code the client requests programs for
+func (c *Client) Market() string {
+	return strings.ToUpper(c.locale.TwoLetterCode)
+}
+
+// post sends the payload to the endpoint and decodes the response into out
+func (c *Client) post(endpoint string, payload, out interface{}) error {
+	r, err := network.NewPost(BaseURL+endpoint, payload, map[string]string{"X-Token": c.token})
+	if err != nil {
+		return err
+	}
+	r.Client = c.http
+	raw, err := network.Retry(r, RequestRetries, time.Second)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(raw, out)
+	if err != nil {
+		return fmt.Errorf("Unmarshal %s - %v", endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package adtractionclient
+
+const (
+	BaseURL        = "https://api.adtraction.com/v2"
+	RequestRetries = 2
+	DateFormat     = "2006-01-02T15:04:05-0700"
+)
+
+var (
+	// Markets lists the countries Adtraction programs are requested for
+	Markets = []string{
+		"SE",
+		"NO",
+		"DK",
+		"FI",
+	}
+)
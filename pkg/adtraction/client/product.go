@@ -0,0 +1,180 @@
+package adtractionclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strings"
+
+	"stillgrove.com/gofeedyourself/pkg/filefeed"
+)
+
+// Extra is a free attribute of a product, e.g. COLOR, SIZE or GENDER
+type Extra struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+// Product is a product element of an Adtraction product feed
+type Product struct {
+	SKU                       string  `xml:"SKU"`
+	Name                      string  `xml:"Name"`
+	Description               string  `xml:"Description"`
+	Category                  string  `xml:"Category"`
+	Price                     string  `xml:"Price"`
+	OriginalPrice             string  `xml:"OriginalPrice"`
+	Shipping                  string  `xml:"Shipping"`
+	Currency                  string  `xml:"Currency"`
+	Instock                   string  `xml:"Instock"`
+	ProductURL                string  `xml:"ProductUrl"`
+	ImageURL                  string  `xml:"ImageUrl"`
+	TrackingURL               string  `xml:"TrackingUrl"`
+	Brand                     string  `xml:"Brand"`
+	EAN                       string  `xml:"Ean"`
+	ManufacturerArticleNumber string  `xml:"ManufacturerArticleNumber"`
+	Extras                    []Extra `xml:"Extras>Extra"`
+
+	// set from the program the feed belongs to
+	ProgramID   uint64 `xml:"-"`
+	ProgramName string `xml:"-"`
+	FeedID      uint64 `xml:"-"`
+	Logo        string `xml:"-"`
+}
+
+// Extra returns the value of the first extra with one of the names, case insensitive
+func (p *Product) Extra(names ...string) string {
+	for _, name := range names {
+		for i := range p.Extras {
+			if strings.EqualFold(p.Extras[i].Name, name) && p.Extras[i].Value != "" {
+				return p.Extras[i].Value
+			}
+		}
+	}
+	return ""
+}
+
+// Key identifies the product of a program
+func (p *Product) Key() (hash uint64, err error) {
+	s := p.SKU + p.ProgramName + p.Name
+	if p.SKU == "" || s == "" {
+		return hash, fmt.Errorf("Couldn't create key")
+	}
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64(), nil
+}
+
+// GetPrices returns the current and the regular price, the regular one is the current one if not discounted
+func (p *Product) GetPrices() (price, regular float64, err error) {
+	price, err = filefeed.ParsePrice(p.Price)
+	if err != nil || price == 0 {
+		return price, regular, fmt.Errorf("Invalid price %q", p.Price)
+	}
+	regular, err = filefeed.ParsePrice(p.OriginalPrice)
+	if err != nil || regular < price {
+		regular = price
+	}
+	return price, regular, nil
+}
+
+// Validate checks the fields the conversion relies on
+func (p *Product) Validate() error {
+	var strFields = map[string]string{
+		"SKU":         p.SKU,
+		"Name":        p.Name,
+		"TrackingUrl": p.TrackingURL,
+		"ImageUrl":    p.ImageURL,
+		"Currency":    p.Currency,
+	}
+	for k, v := range strFields {
+		if v == "" {
+			return fmt.Errorf("Validation - Adtraction Field missing - %s", k)
+		}
+	}
+
+	if p.Category == "" && p.Extra("CATEGORY") == "" {
+		return fmt.Errorf("Validation - No category found - %s", p.Name)
+	}
+
+	_, _, err := p.GetPrices()
+	if err != nil {
+		return fmt.Errorf("Validation - %v - %s", err, p.Name)
+	}
+
+	return nil
+}
+
+// GetProducts downloads the product feeds of the programs, at most maxCount products if maxCount > 0
+func (c *Client) GetProducts(programs []Program, maxCount int) (products []Product, err error) {
+	for i := range programs {
+		for _, f := range programs[i].Feeds {
+			if maxCount > 0 && len(products) >= maxCount {
+				return products, nil
+			}
+			limit := 0
+			if maxCount > 0 {
+				limit = maxCount - len(products)
+			}
+			feedProducts, err := c.GetFeedProducts(f.URL, limit)
+			if err != nil {
+				return products, fmt.Errorf("Download feed %d of %s - %v", f.ID, programs[i].Name, err)
+			}
+			for j := range feedProducts {
+				feedProducts[j].ProgramID = programs[i].ID
+				feedProducts[j].ProgramName = programs[i].Name
+				feedProducts[j].FeedID = f.ID
+				feedProducts[j].Logo = programs[i].Logo
+			}
+			products = append(products, feedProducts...)
+		}
+	}
+	return products, nil
+}
+
+// GetFeedProducts downloads one product feed, at most limit products if limit > 0
+func (c *Client) GetFeedProducts(url string, limit int) (products []Product, err error) {
+	resp, err := c.http.Get(url)
+	if err != nil {
+		return products, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return products, fmt.Errorf("Download failed with status %s", resp.Status)
+	}
+
+	err = ReadProducts(resp.Body, func(p Product) bool {
+		products = append(products, p)
+		return limit <= 0 || len(products) < limit
+	})
+	return products, err
+}
+
+// ReadProducts calls fn for every product element of a feed until fn returns false
+func ReadProducts(r io.Reader, fn func(Product) bool) error {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Read xml - %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "product" {
+			continue
+		}
+		var p Product
+		err = decoder.DecodeElement(&p, &start)
+		if err != nil {
+			return fmt.Errorf("Read product - %v", err)
+		}
+		if !fn(p) {
+			return nil
+		}
+	}
+}
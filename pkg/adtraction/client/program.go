@@ -0,0 +1,65 @@
+package adtractionclient
+
+import (
+	"fmt"
+)
+
+// approved is the approval status of programs we may promote
+const approved = 1
+
+// ProductFeed is a product feed of a program
+type ProductFeed struct {
+	ID               uint64 `json:"feedId"`
+	Name             string `json:"name"`
+	URL              string `json:"feedUrl"`
+	LastUpdated      string `json:"lastUpdated"`
+	NumberOfProducts int    `json:"numberOfProducts"`
+}
+
+// Compensation is the commission of a program for one type of transaction
+type Compensation struct {
+	ID    uint64  `json:"id"`
+	Name  string  `json:"name"`
+	Type  string  `json:"type"` // e.g. percent or fixed
+	Value float64 `json:"value"`
+}
+
+// Program is an advertiser we are approved for, see the partner programs endpoint
+type Program struct {
+	ID             uint64         `json:"programId"`
+	Name           string         `json:"programName"`
+	URL            string         `json:"programURL"`
+	Currency       string         `json:"currency"`
+	Market         string         `json:"market"`
+	Category       string         `json:"categoryName"`
+	Logo           string         `json:"logoURL"`
+	TrackingURL    string         `json:"trackingURL"`
+	ApprovalStatus int            `json:"approvalStatus"`
+	Feeds          []ProductFeed  `json:"feeds"`
+	Compensations  []Compensation `json:"compensations"`
+}
+
+// GetPrograms returns the programs of the market our channel is approved for
+func (c *Client) GetPrograms() (programs []Program, err error) {
+	var all []Program
+	err = c.post(
+		"/partner/programs/",
+		map[string]interface{}{
+			"market":         c.Market(),
+			"channelId":      c.channelID,
+			"approvalStatus": approved,
+		},
+		&all,
+	)
+	if err != nil {
+		return programs, fmt.Errorf("Get programs - %v", err)
+	}
+
+	for i := range all {
+		if all[i].ApprovalStatus != approved {
+			continue
+		}
+		programs = append(programs, all[i])
+	}
+	return programs, nil
+}
@@ -0,0 +1,46 @@
+package adtractionclient
+
+import (
+	"fmt"
+	"time"
+)
+
+// Transaction is a sale or lead of a program, see the partner transactions endpoint
+type Transaction struct {
+	ID                uint64  `json:"transactionId"`
+	ProgramID         uint64  `json:"programId"`
+	ProgramName       string  `json:"programName"`
+	ChannelID         uint64  `json:"channelId"`
+	Type              int     `json:"transactionType"`   // 1 sale, 2 lead
+	Status            int     `json:"transactionStatus"` // 1 approved, 2 pending, 5 rejected
+	OrderValue        float64 `json:"orderValue"`
+	Commission        float64 `json:"commission"`
+	Currency          string  `json:"currency"`
+	ClickDate         string  `json:"clickDate"`
+	TransactionDate   string  `json:"transactionDate"`
+	EPI               string  `json:"epi"` // our click reference
+	ProductIdentifier string  `json:"sku"`
+}
+
+// GetTransactions returns the transactions of our channel between start and end
+func (c *Client) GetTransactions(start, end time.Time) (transactions []Transaction, err error) {
+	err = c.post(
+		"/partner/transactions/",
+		map[string]interface{}{
+			"fromDate":  start.Format(DateFormat),
+			"toDate":    end.Format(DateFormat),
+			"channelId": c.channelID,
+		},
+		&transactions,
+	)
+	if err != nil {
+		return transactions, fmt.Errorf("Get transactions - %v", err)
+	}
+	return transactions, nil
+}
+
+// GetRecentTransactions returns the transactions of the last seven days
+func (c *Client) GetRecentTransactions() (transactions []Transaction, err error) {
+	end := time.Now()
+	return c.GetTransactions(end.AddDate(0, 0, -7), end)
+}
@@ -0,0 +1,159 @@
+package adtraction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	adc "stillgrove.com/gofeedyourself/pkg/adtraction/client"
+	"stillgrove.com/gofeedyourself/pkg/cache"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/network"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SampleSize describes the limit of products to download when not in production mode
+	SampleSize = 5000
+	// ProductionLimit is the hard limit of products in production mode, 0 means no limit
+	ProductionLimit = 0
+	// CacheTTL is how long the converted products of a program are reused in production mode
+	CacheTTL = 4 * time.Hour
+	// FallbackFeedID is the feed id of products whose program has none, the other networks use their own
+	FallbackFeedID = 998
+)
+
+// Feed reads the product feeds of the Adtraction programs our channel is approved for
+type Feed struct {
+	Client *adc.Client
+	m      *feed.Mapping
+	locale *feed.Locale
+}
+
+// NewAdtraction returns the feed of the channel in the market of the locale
+func NewAdtraction(locale *feed.Locale, token string, channelID uint64, mapping *feed.Mapping) (f *Feed, err error) {
+	c, err := adc.New(locale, token, channelID)
+	if err != nil {
+		return f, fmt.Errorf("Initialize Adtraction client - %v", err)
+	}
+	return &Feed{
+		Client: c,
+		m:      mapping,
+		locale: locale,
+	}, nil
+}
+
+// GetName identifies the feed source
+func (f Feed) GetName() string {
+	return fmt.Sprintf("Adtraction - %s", f.locale.TwoLetterCode)
+}
+
+// GetLocale implements the feed interface
+func (f Feed) GetLocale() *feed.Locale {
+	return f.locale
+}
+
+// Get implements the feed interface, in production mode the products of each program are cached.
+// A program that fails to load fails the feed
+func (f Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
+	programs, err := f.Client.GetPrograms()
+	if err != nil {
+		return outProducts, fmt.Errorf("Loading Adtraction Programs - %v", err)
+	}
+	if len(programs) == 0 {
+		return outProducts, fmt.Errorf("No approved programs in %s", f.Client.Market())
+	}
+
+	if !productionFlag {
+		log.Infoln("Adtraction: Dev mode: skipping cache, downloading feeds")
+		products, err := f.Client.GetProducts(programs, SampleSize)
+		if err != nil {
+			return outProducts, fmt.Errorf("Loading Adtraction Products - %v", err)
+		}
+		outProducts = f.convert(products)
+	} else {
+		c, err := network.OpenCache(f.GetName(), CacheTTL)
+		if err != nil {
+			return outProducts, err
+		}
+		defer c.Close()
+
+		for i := range programs {
+			// without one of the programs its products would be phased out of the shop
+			products, err := f.getProgram(c, programs[i])
+			if err != nil {
+				return nil, fmt.Errorf("Program %s - %v", programs[i].Name, err)
+			}
+			outProducts = append(outProducts, products...)
+			if ProductionLimit > 0 && len(outProducts) >= ProductionLimit {
+				outProducts = outProducts[:ProductionLimit]
+				break
+			}
+		}
+	}
+
+	if len(outProducts) == 0 {
+		return outProducts, fmt.Errorf("No valid products in the feed")
+	}
+
+	return outProducts, nil
+}
+
+// getProgram returns the cached products of the program or downloads and caches them
+func (f Feed) getProgram(c cache.Cache, program adc.Program) (outProducts []feed.Product, err error) {
+	key := strconv.FormatUint(program.ID, 10)
+	raw, err := c.Load(key)
+	if err == nil && len(raw) > 0 {
+		var cached []feed.Product
+		err = json.Unmarshal(raw, &cached)
+		if err == nil {
+			for j := range cached {
+				err = cached[j].Validate()
+				if err != nil {
+					log.WithField("Error", err).Debugln("Adtraction: Inconsistent product in cache")
+					continue
+				}
+				outProducts = append(outProducts, cached[j])
+			}
+		}
+		if len(outProducts) > 0 {
+			return outProducts, nil
+		}
+	}
+
+	products, err := f.Client.GetProducts([]adc.Program{program}, ProductionLimit)
+	if err != nil {
+		return outProducts, fmt.Errorf("Loading Adtraction Products - %v", err)
+	}
+	outProducts = f.convert(products)
+
+	payload, err := json.Marshal(outProducts)
+	if err != nil {
+		return outProducts, fmt.Errorf("Failed to store products in cache - %v", err)
+	}
+	err = c.Store(map[string][]byte{key: payload})
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Program": program.Name,
+				"Error":   err,
+			},
+		).Warnln("Adtraction: Failed to write to cache")
+	}
+	return outProducts, nil
+}
+
+// convert returns the active products that could be mapped, the others are dropped
+func (f Feed) convert(products []adc.Product) []feed.Product {
+	converters := make([]network.Product, len(products))
+	for i := range products {
+		converters[i] = &Product{
+			&products[i],
+			f.m,
+			f.locale.Locale,
+		}
+	}
+	return network.Convert("Adtraction", converters)
+}
@@ -0,0 +1,152 @@
+package adtraction
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	adc "stillgrove.com/gofeedyourself/pkg/adtraction/client"
+	"stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// Product converts Adtraction products like awin.Product converts Awin products
+type Product struct {
+	*adc.Product
+	mapping *feed.Mapping
+	locale  string
+}
+
+// ToFeedProduct returns pointer to a converted FeedProduct
+func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
+	err = p.Validate()
+	if err != nil {
+		return productOut, err
+	}
+
+	feedID := int32(p.FeedID)
+	if feedID == 0 {
+		feedID = FallbackFeedID
+	}
+
+	color := p.Extra("COLOR", "COLOUR", "FARG", "FÄRG")
+	productOut = &feed.Product{
+		Name:        p.Name,
+		SKU:         p.SKU,
+		Color:       collection.CollateStrings(color, "multi"),
+		Description: p.Description,
+		ImageURL:    p.ImageURL,
+		Brand:       p.Brand,
+		Material:    p.Extra("MATERIAL"),
+		Language:    p.locale,
+		Retailers: []feed.Retailer{
+			feed.Retailer{
+				Link:         p.TrackingURL,
+				Name:         p.ProgramName,
+				Logo:         p.Logo,
+				Price:        p.Price,
+				Currency:     p.Currency,
+				Availability: feed.MapAvailability(strings.ToLower(p.Instock)),
+				FeedID:       feedID,
+				ShippingCost: p.Shipping,
+				IsCrawler:    false,
+				Sizes:        feed.SplitSizes(p.Extra("SIZE", "STORLEK")),
+			},
+		},
+	}
+
+	productOut.FromFeeds = []int32{feedID}
+
+	// ----------------------
+	// Price logic ----------
+	// ----------------------
+
+	price, regular, err := p.GetPrices()
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to parse prices - %v", err)
+	}
+	productOut.LowestPrice, productOut.HighestPrice = float32(price), float32(regular)
+	productOut.Retailers[0].Price = strconv.FormatFloat(price, 'f', 2, 32)
+	productOut.Retailers[0].HighestPrice = float32(regular)
+	err = productOut.CalculateDiscounts(10)
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to parse prices - %v", err)
+	}
+
+	// ----------------------
+	// Color logic ----------
+	// ----------------------
+
+	colorCandidates := []string{
+		color,
+		productOut.Color,
+		strings.Replace(p.Name, p.Brand, "", 1),
+	}
+	productOut.ColorGroups = feed.MapColors(
+		p.mapping,
+		colorCandidates...,
+	)
+	if len(productOut.ColorGroups) == 0 {
+		return productOut, fmt.Errorf("Failed to parse color - %v", colorCandidates)
+	}
+
+	// ----------------------
+	// Gender logic ---------
+	// ----------------------
+
+	category := collection.CollateStrings(p.Category, p.Extra("CATEGORY"))
+	productOut.Gender = feed.MapGender(
+		p.Extra("GENDER", "KON", "KÖN"),
+		category,
+		p.Name,
+	)
+	if productOut.Gender == "" {
+		return productOut, fmt.Errorf("Failed to parse gender - %v", category)
+	}
+
+	// ----------------------
+	// Category logic -------
+	// ----------------------
+
+	productOut.ProviderCategories, err = feed.MapCategories(
+		p.mapping,
+		"adtraction",
+		productOut.Gender,
+		category,
+	)
+
+	productOut.OriginalCategories = []string{category}
+
+	if category == "" && err != nil {
+		return productOut, fmt.Errorf("Failed to parse categories - %v", err)
+	}
+
+	// ----------------------
+	// Offer logic ----------
+	// ----------------------
+
+	productOut.RetailerMap = make(map[uint64]struct{}, 1)
+	productOut.RetailerMap[collection.HashKey(collection.CollateStrings(p.ProductURL, p.TrackingURL))] = struct{}{}
+
+	productOut.Active = productOut.Retailers[0].Availability == "instock"
+
+	// ----------------------
+	// SKU logic ------------
+	// ----------------------
+
+	productOut.SKU = collection.CollateStrings(
+		p.EAN,
+		p.SKU,
+		p.ManufacturerArticleNumber,
+	)
+	if productOut.SKU == "" {
+		return productOut, fmt.Errorf("No identifier found - %s", p.Name)
+	}
+
+	err = productOut.SetKey()
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to set key - %v", err)
+	}
+
+	return productOut, nil
+}
@@ -92,6 +92,7 @@ func TestAwinFeed(t *testing.T) {
 		loc,
 		os.Getenv("AWIN_TOKEN"),
 		os.Getenv("AWIN_FEED_TOKEN"),
+		7,
 		m,
 	)
 	if err != nil {
@@ -26,7 +26,7 @@ func GetAccounts(apiToken string) (acc AccountResponse, err error) {
 		apiToken,
 	)
 
-	b, err := accountsReq.Send()
+	b, err := send(accountsReq)
 	if err != nil {
 		return acc, fmt.Errorf("Get accounts - %v", err)
 	}
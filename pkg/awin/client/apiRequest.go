@@ -9,9 +9,10 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
 	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/network"
 )
 
 type ApiRequest struct {
@@ -103,11 +104,9 @@ func (r ApiRequest) Send() (rawResponse []byte, err error) {
 	}
 	//defer resp.Body.Close()
 
-	// If request limit exceeded: recursive retry, 1 minute delay
-	if resp.StatusCode == 429 {
-		log.Println("Request limit exceeded, recursive retry")
-		time.Sleep(time.Minute)
-		return r.Send()
+	// the queue or send waits and retries
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return rawResponse, network.ErrRateLimited
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"runtime"
 	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/network"
 )
 
 const (
@@ -21,8 +21,10 @@ const (
 type Client struct {
 	apiToken      string
 	productsToken string
-	queue         *Queue
+	queue         *network.Queue
 	locale        *feed.Locale
+	filter        ProgrammeFilter
+	exclusions    []Exclusion // advertisers left out by the last GetProducts
 }
 
 func New(locale *feed.Locale, apiToken, productsToken string) (c *Client, err error) {
@@ -41,7 +43,7 @@ func New(locale *feed.Locale, apiToken, productsToken string) (c *Client, err er
 	return &Client{
 		apiToken:      apiToken,
 		productsToken: productsToken,
-		queue:         NewQueue(),
+		queue:         network.NewQueue("Execute Queue", ConcurrentRequests, RequestRetries),
 		locale:        locale,
 	}, nil
 }
@@ -51,21 +53,34 @@ func (c *Client) AddApiRequest(method, endpoint string, params *url.Values, payl
 	if err != nil {
 		return fmt.Errorf("Failed to add request to queue - %v", err)
 	}
-	err = c.queue.Add(r)
-	if err != nil {
-		return fmt.Errorf("Failed to add request to queue - %v", err)
-	}
+	c.queue.Add(r)
 	return nil
 }
 
+// send sends a single API request with the retries of the queue
+func send(r network.Request) ([]byte, error) {
+	return network.Retry(r, RequestRetries, time.Second)
+}
+
+// SetFilter sets the rules the programmes must pass for their products to be included
+func (c *Client) SetFilter(filter ProgrammeFilter) {
+	c.filter = filter
+}
+
+// Exclusions returns the advertisers whose products the last GetProducts left out
+func (c *Client) Exclusions() []Exclusion {
+	return c.exclusions
+}
+
 func (c *Client) GetProgrammes() (programmes []Programme, err error) {
 	programmes, err = GetProgrammes(c.locale.TwoLetterCode, c.apiToken)
 	return programmes, err
 }
 
-func (c *Client) GetTransactions() (transactions []Transaction, err error) {
+// GetTransactions returns the transactions of the joined programmes over the last days
+func (c *Client) GetTransactions(days int) (transactions []Transaction, err error) {
 	end := time.Now()
-	start := end.AddDate(0, 0, -7)
+	start := end.AddDate(0, 0, -days)
 	transactions, err = GetTransactions(c.locale.TwoLetterCode, start, end, c.apiToken)
 	if err != nil {
 		return transactions, err
@@ -91,22 +106,23 @@ func (c *Client) GetFeeds() (list []Feed, err error) {
 	return list, nil
 }
 
-func (c Client) GetProducts(maxCount int) (products []Product, err error) {
+func (c *Client) GetProducts(maxCount int) (products []Product, err error) {
 	var (
 		activeProgrammes []Programme
-		progIDs          map[uint64]struct{}
-		exists, matched  bool
+		suspended        []ProgrammeInfo
+		matched          bool
 		inList, outList  []Feed
+		selected         []Feed
 		results          [][]byte
 		product          []Product
+		exists           bool
 
-		key       uint64
-		uniques   map[uint64]struct{}
-		maxMemory uint64
-		mem       runtime.MemStats
+		key     uint64
+		uniques map[uint64]struct{}
 	)
 
-	memLog("Starting to gather Awin Products", mem, &maxMemory)
+	network.MemLog("Awin", "Starting to gather Awin Products")
+	c.exclusions = nil
 
 	activeProgrammes, err = GetProgrammes(c.locale.TwoLetterCode, c.apiToken)
 	if err != nil {
@@ -117,12 +133,10 @@ func (c Client) GetProducts(maxCount int) (products []Product, err error) {
 		return products, nil
 	}
 
-	progIDs = make(map[uint64]struct{})
-	for i := range activeProgrammes {
-		_, exists = progIDs[activeProgrammes[i].ProgrammeInfo.ID]
-		if !exists {
-			progIDs[activeProgrammes[i].ProgrammeInfo.ID] = struct{}{}
-		}
+	// suspended programmes are left out as not joined otherwise
+	suspended, err = GetSuspendedProgrammes(c.locale.TwoLetterCode, c.apiToken)
+	if err != nil {
+		log.WithField("Error", err).Warnln("Awin: Couldn't get the suspended programmes")
 	}
 
 	inList, err = c.GetFeeds()
@@ -130,7 +144,18 @@ func (c Client) GetProducts(maxCount int) (products []Product, err error) {
 		return products, fmt.Errorf("Get list - %v", err)
 	}
 
-	matched, err = c.enqueue(inList, progIDs, maxCount)
+	selected, activeProgrammes, c.exclusions = c.filter.Select(inList, activeProgrammes, suspended)
+	for _, e := range c.exclusions {
+		log.WithFields(
+			log.Fields{
+				"Advertiser": e.AdvertiserName,
+				"ID":         e.AdvertiserID,
+				"Reason":     e.Reason,
+			},
+		).Infoln("Awin: Excluded advertiser")
+	}
+
+	matched, err = c.enqueue(selected, maxCount)
 	if err != nil {
 		return products, fmt.Errorf("Enqueue requests - %v", err)
 	}
@@ -159,27 +184,25 @@ func (c Client) GetProducts(maxCount int) (products []Product, err error) {
 	}
 
 	for i := range outList {
-		err = c.queue.Add(
+		c.queue.Add(
 			NewProductRequest(
 				outList[i].URL,
 				c.productsToken,
 				maxCount,
 			),
 		)
-		if err != nil {
-			return products, fmt.Errorf("Failed to add request to queue - %v", err)
-		}
 	}
 
-	memLog("Downloading Awin Products", mem, &maxMemory)
-	results, err = c.Execute(false)
+	network.MemLog("Awin", "Downloading Awin Products")
+	// without one of the feeds its products would be deleted from the shop
+	results, err = c.Execute(true)
 	if err != nil {
 		return products, fmt.Errorf("Query feeds - %v", err)
 	}
 
 	uniques = make(map[uint64]struct{})
 	for i := range results {
-		memLog(fmt.Sprintf("Processing Feed %d", i), mem, &maxMemory)
+		network.MemLog("Awin", fmt.Sprintf("Processing Feed %d", i))
 		if results[i] == nil {
 			continue
 		}
@@ -226,31 +249,23 @@ func (c Client) GetProducts(maxCount int) (products []Product, err error) {
 			}
 		}
 	}
-	memLog("All feeds processed", mem, &maxMemory)
+	network.MemLog("Awin", "All feeds processed")
 
 	return products, nil
 }
 
-func (c *Client) enqueue(in []Feed, activeProgrammes map[uint64]struct{}, maxRows int) (matched bool, err error) {
+func (c *Client) enqueue(in []Feed, maxRows int) (matched bool, err error) {
 	for i := range in {
 		if in[i].PrimaryRegion != c.locale.TwoLetterCode {
 			continue
 		}
-		for key := range activeProgrammes {
-			if in[i].AdvertiserID == key {
-				r := NewProductRequest(
-					in[i].URL,
-					c.productsToken,
-					maxRows,
-				)
-				err = c.queue.Add(r)
-				if err != nil {
-					return matched, err
-				}
-
-				matched = true
-			}
-		}
+		r := NewProductRequest(
+			in[i].URL,
+			c.productsToken,
+			maxRows,
+		)
+		c.queue.Add(r)
+		matched = true
 	}
 
 	return matched, nil
@@ -48,7 +48,7 @@ func GetCommissionGroups(publisherID, advertiserID uint64, apiToken string) (cg
 	ctx := context.Background()
 	ctx, _ = context.WithTimeout(ctx, 100*time.Millisecond)
 
-	b, err := r.Send()
+	b, err := send(r)
 	if err != nil {
 		return cg, err
 	}
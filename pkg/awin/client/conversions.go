@@ -0,0 +1,102 @@
+package awinclient
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxLookbackDays is the longest date range the transaction endpoint accepts
+	MaxLookbackDays = 31
+)
+
+// Conversion sums the transactions of a product
+type Conversion struct {
+	Leads       int32
+	Conversions int32
+	Commission  float32
+	Events      []Event // the transactions with a date
+}
+
+// Event is a transaction of a Conversion
+type Event struct {
+	Time       time.Time
+	Lead       bool
+	Commission float32
+}
+
+// ConversionKey joins transactions and products by advertiser and the advertiser's product id
+func ConversionKey(advertiserID, productID string) string {
+	return strings.TrimSpace(advertiserID) + "-" + strings.ToLower(strings.TrimSpace(productID))
+}
+
+// Conversions sums the transactions by advertiser and basket product, declined transactions are left out.
+// Transactions of a lead commission group count as leads, the others as conversions
+func Conversions(transactions []Transaction) map[string]Conversion {
+	conversions := make(map[string]Conversion)
+	for _, t := range transactions {
+		if strings.EqualFold(t.CommissionStatus, "declined") {
+			continue
+		}
+		lead := t.SaleAmount.Amount == "" || t.SaleAmount.Amount == "0"
+		for _, part := range t.TransactionParts {
+			if strings.Contains(strings.ToLower(part.CommissionGroupCode+part.CommissionGroupName), "lead") {
+				lead = true
+			}
+		}
+
+		date, dated := parseDate(t.TransactionDate)
+		advertiser := fmt.Sprintf("%d", t.AdvertiserID)
+		for _, b := range t.BasketProducts {
+			for _, id := range uniqueIDs(b.ID, b.SkuCode) {
+				key := ConversionKey(advertiser, id)
+				c := conversions[key]
+				if lead {
+					c.Leads++
+				} else {
+					c.Conversions++
+				}
+				c.Commission += float32(b.Commission)
+				if dated {
+					c.Events = append(c.Events, Event{Time: date, Lead: lead, Commission: float32(b.Commission)})
+				}
+				conversions[key] = c
+			}
+		}
+	}
+	return conversions
+}
+
+// GetConversions returns the conversions of the products over the last days
+func (c *Client) GetConversions(days int) (conversions map[string]Conversion, err error) {
+	if days < 1 || days > MaxLookbackDays {
+		return conversions, fmt.Errorf("Lookback must be between 1 and %d days, not %d", MaxLookbackDays, days)
+	}
+	transactions, err := c.GetTransactions(days)
+	if err != nil {
+		return conversions, fmt.Errorf("Get transactions - %v", err)
+	}
+	return Conversions(transactions), nil
+}
+
+// parseDate reads the transaction dates, with or without a time zone
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, DateFormat} {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func uniqueIDs(ids ...string) (out []string) {
+	for _, id := range ids {
+		if id == "" || (len(out) > 0 && out[0] == id) {
+			continue
+		}
+		out = append(out, id)
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+// +build unit
+// +build !integration
+
+package awinclient
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConversions(t *testing.T) {
+	sale := func(status, code, amount string, products ...BasketProduct) Transaction {
+		return Transaction{
+			AdvertiserID:     42,
+			TransactionDate:  "2019-05-01T10:00:00.000",
+			CommissionStatus: status,
+			SaleAmount:       Amount{Amount: amount},
+			TransactionParts: []TransactionPart{{CommissionGroupCode: code}},
+			BasketProducts:   products,
+		}
+	}
+	dress := BasketProduct{ID: "D1", SkuCode: "sku-1", Commission: 40}
+	shirt := BasketProduct{ID: "S1", Commission: 20}
+
+	conversions := Conversions([]Transaction{
+		sale("approved", "DEFAULT", "499", dress, shirt),
+		sale("pending", "DEFAULT", "499", dress),
+		sale("pending", "LEAD", "0", dress),
+		sale("declined", "DEFAULT", "499", dress),
+	})
+
+	c := conversions[ConversionKey("42", "d1")]
+	if c.Conversions != 2 || c.Leads != 1 || c.Commission != 120 {
+		t.Fatalf("Expected the dress to sum the transactions that were not declined - %+v", c)
+	}
+	if len(c.Events) != 3 || !c.Events[0].Time.Equal(time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)) || !c.Events[2].Lead {
+		t.Fatalf("Expected the dated transactions as events - %+v", c.Events)
+	}
+	if !reflect.DeepEqual(conversions[ConversionKey("42", "SKU-1")], c) {
+		t.Fatalf("Expected the sku code to join as well - %+v", conversions)
+	}
+	if c := conversions[ConversionKey("42", "S1")]; c.Conversions != 1 || c.Commission != 20 {
+		t.Fatalf("Wrong shirt conversions - %+v", c)
+	}
+	if _, exists := conversions[ConversionKey("7", "S1")]; exists {
+		t.Fatalf("Products of other advertisers must not join")
+	}
+}
@@ -0,0 +1,136 @@
+package awinclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProgrammeFilter holds the rules a joined programme must pass for its products to be included, zero values disable a rule
+type ProgrammeFilter struct {
+	MinApprovalShare  float64  `yaml:"min_approval_share"`        // share of the transactions approved, 0.8 for 80%
+	MinEPC            float64  `yaml:"min_epc"`                   // earnings per click
+	MaxValidationDays int      `yaml:"max_validation_days"`       // days until the transactions are approved or declined
+	MinPercentage     float64  `yaml:"min_commission_percentage"` // of the best percentage commission group, as Awin states it
+	MinAmount         float64  `yaml:"min_commission_amount"`     // of the best fixed commission group
+	ExcludeGroups     []string `yaml:"exclude_commission_groups"` // codes or names of commission groups that don't count, e.g. LEAD
+}
+
+// Exclusion is an advertiser whose products are left out
+type Exclusion struct {
+	AdvertiserID   uint64
+	AdvertiserName string
+	Reason         string
+}
+
+func (e Exclusion) String() string {
+	return fmt.Sprintf("%s (%d): %s", e.AdvertiserName, e.AdvertiserID, e.Reason)
+}
+
+// Select returns the feeds of the joined programmes that pass the filter and the programmes without the excluded commission groups.
+// The advertisers of the other feeds are excluded with the reason, the ones of the suspended programmes first
+func (f ProgrammeFilter) Select(feeds []Feed, joined []Programme, suspended []ProgrammeInfo) (selected []Feed, programmes []Programme, excluded []Exclusion) {
+	reasons := make(map[uint64]string)
+	for _, info := range suspended {
+		reasons[info.ID] = "programme suspended"
+	}
+
+	active := make(map[uint64]struct{})
+	for _, p := range joined {
+		id := p.ProgrammeInfo.ID
+		if _, exists := reasons[id]; exists {
+			continue
+		}
+		p.CommissionGroups = f.groups(p.CommissionGroups)
+		if reason := f.check(p); reason != "" {
+			reasons[id] = reason
+			continue
+		}
+		active[id] = struct{}{}
+		programmes = append(programmes, p)
+	}
+
+	reported := make(map[uint64]struct{})
+	for _, feed := range feeds {
+		reason, exists := reasons[feed.AdvertiserID]
+		if _, ok := active[feed.AdvertiserID]; !exists && !ok {
+			reason = "programme not joined"
+		}
+		if reason == "" && !isActiveMembership(feed.MembershipStatus) {
+			reason = fmt.Sprintf("feed membership is %s", strings.ToLower(feed.MembershipStatus))
+		}
+		if reason == "" {
+			selected = append(selected, feed)
+			continue
+		}
+		if _, exists := reported[feed.AdvertiserID]; exists {
+			continue
+		}
+		reported[feed.AdvertiserID] = struct{}{}
+		excluded = append(excluded, Exclusion{
+			AdvertiserID:   feed.AdvertiserID,
+			AdvertiserName: feed.AdvertiserName,
+			Reason:         reason,
+		})
+	}
+	return selected, programmes, excluded
+}
+
+// check returns why the programme fails the filter, empty if it passes
+func (f ProgrammeFilter) check(p Programme) string {
+	// Awin states the approval as percentage, even below 1%
+	approval := p.KPI.ApprovalPercentage / 100.0
+	switch {
+	case f.MinApprovalShare > 0 && approval < f.MinApprovalShare:
+		return fmt.Sprintf("approval of %.0f%% is below %.0f%%", approval*100, f.MinApprovalShare*100)
+	case f.MinEPC > 0 && p.KPI.EPC < f.MinEPC:
+		return fmt.Sprintf("EPC of %.2f is below %.2f", p.KPI.EPC, f.MinEPC)
+	case f.MaxValidationDays > 0 && p.KPI.ValidationDays > f.MaxValidationDays:
+		return fmt.Sprintf("validation takes %d days, more than %d", p.KPI.ValidationDays, f.MaxValidationDays)
+	case len(f.ExcludeGroups) > 0 && len(p.CommissionGroups) == 0:
+		return "only excluded commission groups"
+	}
+
+	if f.MinPercentage == 0 && f.MinAmount == 0 {
+		return ""
+	}
+	for _, g := range p.CommissionGroups {
+		if (f.MinPercentage > 0 && g.Type == "percentage" && g.Percentage >= f.MinPercentage) ||
+			(f.MinAmount > 0 && g.Type == "fix" && g.Amount >= f.MinAmount) {
+			return ""
+		}
+	}
+	var minimums []string
+	if f.MinPercentage > 0 {
+		minimums = append(minimums, fmt.Sprintf("%g%%", f.MinPercentage))
+	}
+	if f.MinAmount > 0 {
+		minimums = append(minimums, fmt.Sprintf("%g", f.MinAmount))
+	}
+	return fmt.Sprintf("no commission group reaches %s", strings.Join(minimums, " or "))
+}
+
+// groups drops the excluded commission groups
+func (f ProgrammeFilter) groups(in []CommissionGroup) (out []CommissionGroup) {
+	for _, g := range in {
+		var excluded bool
+		for _, name := range f.ExcludeGroups {
+			if strings.EqualFold(g.GroupCode, name) || strings.EqualFold(g.GroupName, name) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// isActiveMembership is true for joined feeds, the feed list leaves the status empty for some of them
+func isActiveMembership(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "", "active", "joined":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// +build unit
+// +build !integration
+
+package awinclient
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProgrammeFilter(t *testing.T) {
+	programme := func(id uint64, approval, epc float64, groups ...CommissionGroup) Programme {
+		return Programme{
+			ProgrammeInfo:    ProgrammeInfo{ID: id},
+			KPI:              KPI{ApprovalPercentage: approval, EPC: epc, ValidationDays: 30},
+			CommissionGroups: groups,
+		}
+	}
+	sale := CommissionGroup{GroupCode: "DEFAULT", Type: "percentage", Percentage: 10}
+	lead := CommissionGroup{GroupCode: "LEAD", Type: "fix", Amount: 50}
+
+	joined := []Programme{
+		programme(1, 90, 1.5, sale, lead),
+		programme(2, 40, 1.5, sale),
+		programme(3, 95, 0.1, sale),
+		programme(4, 95, 2, lead),
+		programme(5, 95, 2, sale),
+		programme(7, 0.8, 2, sale),
+	}
+	feeds := []Feed{
+		{AdvertiserID: 1, AdvertiserName: "Shop", FeedID: 10},
+		{AdvertiserID: 1, AdvertiserName: "Shop", FeedID: 11},
+		{AdvertiserID: 2, AdvertiserName: "Unreliable"},
+		{AdvertiserID: 3, AdvertiserName: "Cheap"},
+		{AdvertiserID: 4, AdvertiserName: "Leads"},
+		{AdvertiserID: 5, AdvertiserName: "Suspended"},
+		{AdvertiserID: 6, AdvertiserName: "Left"},
+		{AdvertiserID: 7, AdvertiserName: "Rarely approved"},
+		{AdvertiserID: 1, AdvertiserName: "Shop", FeedID: 12, MembershipStatus: "Inactive"},
+	}
+	filter := ProgrammeFilter{
+		MinApprovalShare: 0.8,
+		MinEPC:           0.5,
+		ExcludeGroups:    []string{"lead"},
+	}
+
+	selected, programmes, excluded := filter.Select(feeds, joined, []ProgrammeInfo{{ID: 5}})
+	if len(selected) != 2 || selected[0].FeedID != 10 || selected[1].FeedID != 11 {
+		t.Fatalf("Expected the active feeds of the shop - %+v", selected)
+	}
+	if len(programmes) != 1 || len(programmes[0].CommissionGroups) != 1 || programmes[0].CommissionGroups[0].GroupCode != "DEFAULT" {
+		t.Fatalf("Expected the shop without the lead commission - %+v", programmes)
+	}
+
+	reasons := make(map[uint64]string)
+	for _, e := range excluded {
+		reasons[e.AdvertiserID] = e.Reason
+	}
+	expected := map[uint64]string{
+		1: "feed membership is inactive",
+		2: "approval of 40% is below 80%",
+		3: "EPC of 0.10 is below 0.50",
+		4: "only excluded commission groups",
+		5: "programme suspended",
+		6: "programme not joined",
+		7: "approval of 1% is below 80%",
+	}
+	if len(excluded) != len(expected) {
+		t.Fatalf("Expected one exclusion per advertiser - %v", excluded)
+	}
+	for id, reason := range expected {
+		if reasons[id] != reason {
+			t.Fatalf("Wrong reason for %d - %q instead of %q", id, reasons[id], reason)
+		}
+	}
+
+	filter = ProgrammeFilter{MinPercentage: 12, MinAmount: 40}
+	_, programmes, _ = filter.Select(feeds, joined, nil)
+	if len(programmes) != 2 || programmes[0].ProgrammeInfo.ID != 1 || programmes[1].ProgrammeInfo.ID != 4 {
+		t.Fatalf("Expected the programmes with a fixed commission of 50 - %+v", programmes)
+	}
+}
+
+func TestProgrammeDecode(t *testing.T) {
+	raw := []byte(`{
+		"programmeInfo": {
+			"id": 1001,
+			"name": "Shop",
+			"currencyCode": "SEK",
+			"primaryRegion": {"name": "Sweden", "region": "SE"},
+			"validDomains": [{"domain": "shop.se"}]
+		},
+		"kpi": {"approvalPercentage": 92.5, "epc": 1.2, "validationDays": 30}
+	}`)
+	var p Programme
+	err := json.Unmarshal(raw, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ProgrammeInfo.ID != 1001 || p.ProgrammeInfo.PrimaryRegion.Name != "Sweden" ||
+		p.ProgrammeInfo.PrimaryRegion.Region != "SE" || len(p.ProgrammeInfo.ValidDomains) != 1 ||
+		p.KPI.ApprovalPercentage != 92.5 {
+		t.Fatalf("Wrong programme - %+v", p)
+	}
+}
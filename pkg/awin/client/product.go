@@ -74,6 +74,7 @@ type Product struct {
 	DisplayPrice    string `json:"display_price"`
 
 	SizeStockAmount string `json:"size_stock_amount,omitempty"`
+	LastUpdated     string `json:"last_updated"`
 
 	Custom3 string `json:"custom_3,omitempty"`
 	Custom4 string `json:"custom_4,omitempty"`
@@ -84,6 +85,7 @@ type Product struct {
 	Custom9 string `json:"custom_9,omitempty,omitempty"`
 
 	ExpectedValue float64 `json:"expected_value,omitempty"`
+	EPC           float64 `json:"epc,omitempty"` // of the programme
 }
 
 func (p *Product) Key() (hash uint64, err error) {
@@ -121,6 +123,7 @@ func (p *Product) handleProgramme(prog *Programme) error {
 		approval, price, value float64
 	)
 	approval = prog.KPI.ApprovalPercentage
+	p.EPC = prog.KPI.EPC
 	price, _, _ = p.GetPrices()
 	for i := range prog.CommissionGroups {
 		if prog.CommissionGroups[i].Type == "percentage" {
@@ -9,13 +9,10 @@ import (
 	"io"
 	"net/http"
 	"runtime"
-	"time"
 
-	log "github.com/sirupsen/logrus"
+	"stillgrove.com/gofeedyourself/pkg/network"
 )
 
-const toMeg uint64 = 1048576
-
 type ProductRequest struct {
 	url     string
 	token   string
@@ -64,13 +61,11 @@ func (r ProductRequest) Send() (b []byte, err error) {
 	}
 	defer resp.Body.Close()
 
-	memLog("Starting to download Awin File", mem, &maxMemory)
+	network.MemLog("Awin", "Starting to download Awin File")
 
-	// If request limit exceeded: recursive retry
-	if resp.StatusCode == 429 {
-		log.Println("Request limit exceeded, recursive retry")
-		time.Sleep(time.Minute)
-		return r.Send()
+	// the queue waits and retries
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return b, network.ErrRateLimited
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -104,7 +99,7 @@ func (r ProductRequest) Send() (b []byte, err error) {
 		}
 
 		if i%10000 == 0 {
-			memLog(fmt.Sprintf("Downloaded %d rows", i), mem, &maxMemory)
+			network.MemLog("Awin", fmt.Sprintf("Downloaded %d rows", i))
 		}
 
 		row = make(Row, len(header))
@@ -130,7 +125,7 @@ func (r ProductRequest) Send() (b []byte, err error) {
 	}
 	rows = nil
 
-	memLog("Finished collecting", mem, &maxMemory)
+	network.MemLog("Awin", "Finished collecting")
 
 	return b, nil
 }
@@ -10,8 +10,8 @@ type Domain struct {
 	Domain string `json:"domain"`
 }
 type Region struct {
-	Name   string `json:"name`
-	Region string `json:"region`
+	Name   string `json:"name"`
+	Region string `json:"region"`
 }
 
 type ProgrammeInfo struct {
@@ -81,26 +81,9 @@ func GetProgrammes(countryCode string, apiToken string) (outProgs []Programme, e
 }
 
 func GetPublisherProgrammes(pubID uint64, apiToken, countryCode string) (progs []Programme, err error) {
-	programmesReq, err := NewApiRequest(
-		"GET",
-		fmt.Sprintf("publishers/%d/programmes", pubID),
-		nil,
-		&url.Values{
-			"relationship": []string{"joined"},
-			"countryCode":  []string{countryCode},
-		},
-		apiToken,
-	)
-
-	b, err := programmesReq.Send()
+	list, err := listProgrammes(pubID, apiToken, countryCode, "joined")
 	if err != nil {
-		return progs, fmt.Errorf("Get programmes - %v", err)
-	}
-
-	var list []ProgrammeInfo
-	err = json.Unmarshal(b, &list)
-	if err != nil {
-		return progs, fmt.Errorf("Unmarshal programmes - %v", err)
+		return progs, err
 	}
 
 	progs = make([]Programme, len(list))
@@ -114,7 +97,7 @@ func GetPublisherProgrammes(pubID uint64, apiToken, countryCode string) (progs [
 			},
 			apiToken,
 		)
-		b, err := programmesReq.Send()
+		b, err := send(programmesReq)
 		if err != nil {
 			return progs, fmt.Errorf("Get programmes - %v", err)
 		}
@@ -130,3 +113,44 @@ func GetPublisherProgrammes(pubID uint64, apiToken, countryCode string) (progs [
 
 	return progs, nil
 }
+
+// GetSuspendedProgrammes returns the programmes that suspended our accounts
+func GetSuspendedProgrammes(countryCode string, apiToken string) (list []ProgrammeInfo, err error) {
+	acc, err := GetAccounts(apiToken)
+	if err != nil {
+		return list, fmt.Errorf("Get accounts - %v", err)
+	}
+	for i := range acc.Accounts {
+		suspended, err := listProgrammes(acc.Accounts[i].AccountID, apiToken, countryCode, "suspended")
+		if err != nil {
+			return list, err
+		}
+		list = append(list, suspended...)
+	}
+	return list, nil
+}
+
+// listProgrammes returns the programmes of the relationship: joined, pending, suspended, rejected or notjoined
+func listProgrammes(pubID uint64, apiToken, countryCode, relationship string) (list []ProgrammeInfo, err error) {
+	programmesReq, err := NewApiRequest(
+		"GET",
+		fmt.Sprintf("publishers/%d/programmes", pubID),
+		nil,
+		&url.Values{
+			"relationship": []string{relationship},
+			"countryCode":  []string{countryCode},
+		},
+		apiToken,
+	)
+
+	b, err := send(programmesReq)
+	if err != nil {
+		return list, fmt.Errorf("Get programmes - %v", err)
+	}
+
+	err = json.Unmarshal(b, &list)
+	if err != nil {
+		return list, fmt.Errorf("Unmarshal programmes - %v", err)
+	}
+	return list, nil
+}
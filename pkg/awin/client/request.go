@@ -1,6 +0,0 @@
-package awinclient
-
-type Request interface {
-	URL() string
-	Send() (result []byte, err error)
-}
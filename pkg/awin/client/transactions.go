@@ -22,6 +22,17 @@ type Param struct {
 	Value string `json:"value"`
 }
 
+// BasketProduct is a product of the order, the id is the one of the advertiser's feed
+type BasketProduct struct {
+	ID          string  `json:"id"`
+	ProductName string  `json:"productName"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Quantity    int     `json:"quantity"`
+	SkuCode     string  `json:"skuCode"`
+	Commission  float64 `json:"commission"`
+	Category    string  `json:"category"`
+}
+
 type TransactionPart struct {
 	CommissionGroupId   uint64  `json:"commissionGroupId"`
 	Amount              float64 `json:"amount"`
@@ -62,6 +73,7 @@ type Transaction struct {
 	OrderRef                     string            `json:"orderRef"`
 	CustomParameters             []Param           `json:"customParameters"`
 	TransactionParts             []TransactionPart `json:"transactionParts"`
+	BasketProducts               []BasketProduct   `json:"basketProducts"`
 	PaidToPublisher              bool              `json:"paidToPublisher"`
 	PaymentID                    int64             `json:"paymentId"`
 	TransactionQueryID           int64             `json:"transactionQueryId"`
@@ -104,6 +116,7 @@ func GetTransactions(countryCode string, startDate, endDate time.Time, apiToken
 		u.Add("timezone", "UTC")
 		u.Add("endDate", end)
 		u.Add("startDate", start)
+		u.Add("showBasketProducts", "true")
 		for j := range progs {
 			u.Add("advertiserId", fmt.Sprintf("%d", progs[j].ProgrammeInfo.ID))
 		}
@@ -119,7 +132,7 @@ func GetTransactions(countryCode string, startDate, endDate time.Time, apiToken
 			return transactions, fmt.Errorf("Build transaction request - %v", err)
 		}
 
-		resp, err := programmesReq.Send()
+		resp, err := send(programmesReq)
 		if err != nil {
 			return transactions, fmt.Errorf("Query trasnactions - %v", err)
 		}
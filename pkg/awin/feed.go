@@ -1,16 +1,11 @@
 package awin
 
 import (
-	"encoding/json"
 	"fmt"
-	"math/rand"
-	"os"
-	"time"
 
 	ac "stillgrove.com/gofeedyourself/pkg/awin/client"
-	"stillgrove.com/gofeedyourself/pkg/cache"
 	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
-	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+	"stillgrove.com/gofeedyourself/pkg/network"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -24,12 +19,14 @@ const (
 )
 
 type Feed struct {
-	Client *ac.Client
-	m      *feed.Mapping
-	locale *feed.Locale
+	Client   *ac.Client
+	m        *feed.Mapping
+	locale   *feed.Locale
+	lookback int // days of transactions joined to the products, 0 disables it
 }
 
-func NewAwin(locale *feed.Locale, apiToken, productToken string, mapping *feed.Mapping) (f *Feed, err error) {
+// NewAwin returns the Awin feed, the transactions of the last lookbackDays rank its products
+func NewAwin(locale *feed.Locale, apiToken, productToken string, lookbackDays int, mapping *feed.Mapping) (f *Feed, err error) {
 	c, err := ac.New(
 		locale,
 		apiToken,
@@ -39,9 +36,10 @@ func NewAwin(locale *feed.Locale, apiToken, productToken string, mapping *feed.M
 		return f, fmt.Errorf("Locale not recognized - %v", err)
 	}
 	return &Feed{
-		Client: c,
-		m:      mapping,
-		locale: locale,
+		Client:   c,
+		m:        mapping,
+		locale:   locale,
+		lookback: lookbackDays,
 	}, nil
 }
 
@@ -54,12 +52,25 @@ func (f Feed) GetLocale() *feed.Locale {
 	return f.locale
 }
 
+// Exclusions lists the advertisers whose products the last Get left out, with the reason
+func (f Feed) Exclusions() (out []string) {
+	for _, e := range f.Client.Exclusions() {
+		out = append(out, e.String())
+	}
+	return out
+}
+
 /*
 // Cache is still not working, somehow (synchronous access complaints, deadlocks)
 
 func (f Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
 
-	/*outProducts, err = loadFromCache(f.GetName())
+	/*c, err := network.OpenCache(f.GetName(), 4*time.Hour)
+	if err != nil {
+		return outProducts, fmt.Errorf("Preparing Awin Cache - %v", err)
+	}
+	defer c.Close()
+	outProducts, err = network.LoadProducts(c, "Awin")
 	if err != nil {
 		return outProducts, fmt.Errorf("Retrieving Products from Awin Cache - %v", err)
 	}
@@ -71,7 +82,7 @@ func (f Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
 			return outProducts, fmt.Errorf("Downloading Awin products - %v", err)
 		}
 
-		/*err = writeToCache(f.GetName(), outProducts)
+		/*err = network.StoreProducts(c, outProducts)
 		log.WithFields(
 			log.Fields{
 				"Feed":  "Awin",
@@ -110,36 +121,29 @@ func (f Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
 		return outProducts, fmt.Errorf("No products returned")
 	}
 
-	temp := new(Product)
+	var conversions map[string]ac.Conversion
+	if f.lookback > 0 {
+		conversions, err = f.Client.GetConversions(f.lookback)
+	}
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Lookback": f.lookback,
+				"Error":    err,
+			},
+		).Warnln("Awin: Ranking without transactions")
+	}
+
+	converters := make([]network.Product, len(products))
 	for i := range products {
-		temp = &Product{
+		converters[i] = &Product{
 			&products[i],
 			f.m,
 			f.locale.Locale,
-		}
-		p, err := temp.ToFeedProduct()
-		if err != nil {
-			log.WithFields(
-				log.Fields{
-					"Error":  err,
-					"Source": "awin",
-				},
-			).Debugln("Dropping Product")
-			continue
-		}
-		if p.GetKey() == 0 {
-			return outProducts, fmt.Errorf("Failed to prepare product - %s - %s", products[i].ProductName, p.SKU)
-		}
-		if !p.Active {
-			continue
-		}
-
-		outProducts = append(outProducts, *p)
-
-		if i != 0 && (i%2000 == 0 || i == len(products)-1) {
-			log.WithField("Received", fmt.Sprintf("%d / %d", i, len(products))).Infoln("Download Awin Products")
+			conversions,
 		}
 	}
+	outProducts = network.Convert("Awin", converters)
 
 	if len(outProducts) == 0 {
 		return outProducts, fmt.Errorf("No valid products in the feed")
@@ -147,69 +151,3 @@ func (f Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
 
 	return outProducts, nil
 }
-
-func newCache(name string) (c cache.Cache, err error) {
-	path := helpers.FindFolderDir("gofeedyourself") + "/cache/"
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		os.Mkdir(path, os.ModePerm)
-	}
-	cache, err := cache.NewBadgerCache(path+name, 4*time.Hour)
-	if err != nil {
-		return c, fmt.Errorf("Initialize Cache -%v", err)
-	}
-
-	return cache, nil
-}
-
-func writeToCache(name string, feedProducts []feed.Product) (err error) {
-	cache, err := newCache(name)
-	if err != nil {
-		return fmt.Errorf("Failed to initiate cache - %v", err)
-	}
-	payload, err := json.Marshal(feedProducts)
-	if err != nil {
-		return fmt.Errorf("Failed to store products in cache - %v", err)
-	}
-	err = cache.Store(
-		map[string][]byte{
-			fmt.Sprintf("%d", rand.Int63()): payload,
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("Failed to store product in cache - %v", err)
-	}
-
-	return nil
-}
-
-func loadFromCache(name string) (outProducts []feed.Product, err error) {
-	cache, err := newCache(name)
-	if err != nil {
-		return outProducts, fmt.Errorf("Preparing Awin Cache - %v", err)
-	}
-	defer cache.Close()
-	res, err := cache.LoadAll()
-	if err != nil {
-		return outProducts, fmt.Errorf("Load cached products - %v", err)
-	}
-
-	var prod []feed.Product
-	for k := range res {
-		json.Unmarshal(res[k], &prod)
-
-		for j := range prod {
-			if prod[j].GetKey() == 0 {
-				return outProducts, fmt.Errorf("Awin: Empty product in cache")
-			}
-			err = prod[j].Validate()
-			if err != nil {
-				log.WithField("Error", err).Debugln("Awin: Inconsistent product in cache")
-				continue
-			}
-			outProducts = append(outProducts, prod[j])
-		}
-		prod = nil
-	}
-
-	return outProducts, nil
-}
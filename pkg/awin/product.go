@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	ac "stillgrove.com/gofeedyourself/pkg/awin/client"
 	"stillgrove.com/gofeedyourself/pkg/collection"
 	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
 )
 
+const (
+	// LastUpdatedFormat is the layout of the last_updated column of the product feeds
+	LastUpdatedFormat = "2006-01-02 15:04:05"
+)
+
 var (
 	// Locales maps tradedoubler language names to WP locales
 	Locales = map[string]string{
@@ -17,25 +23,13 @@ var (
 		"en":    "sv_se", // FOR TESTING ONLY !
 		"sv_se": "sv_se",
 	}
-	FemaleTerms = [...]string{
-		"women",
-		"woman",
-		"female",
-	}
-	MaleTerms = [...]string{
-		"men",
-		"man",
-		"male",
-	}
-	UnisexTerms = [...]string{
-		"unisex",
-	}
 )
 
 type Product struct {
 	*ac.Product
-	mapping *feed.Mapping
-	locale  string
+	mapping     *feed.Mapping
+	locale      string
+	conversions map[string]ac.Conversion // by ac.ConversionKey
 }
 
 // ToFeedProduct returns pointer to a converted FeedProduct
@@ -60,12 +54,18 @@ func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
 					p.BasePriceText,
 				),
 				Currency:     p.Currency,
-				Availability: handleAvailability(p.InStock, p.StockQuantity, p.StockStatus),
+				Availability: feed.MapAvailability(p.InStock, p.StockQuantity, p.StockStatus),
+				DeliveryTime: p.DeliveryTime,
+				ShippingCost: p.DeliveryCost,
 				IsCrawler:    false,
-				Sizes:        handleSizes(p.Size),
+				Sizes:        feed.SplitSizes(p.Size),
 			},
 		},
 		ExpectedValue: float32(p.ExpectedValue),
+		EPC:           float32(p.EPC),
+	}
+	if updated, err := time.Parse(LastUpdatedFormat, p.LastUpdated); err == nil {
+		productOut.Updated = int32(updated.Unix())
 	}
 
 	feedID, _ := strconv.Atoi(p.DataFeedID)
@@ -73,6 +73,7 @@ func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
 		feedID = 999
 	}
 	productOut.FromFeeds = []int32{int32(feedID)}
+	productOut.Retailers[0].FeedID = int32(feedID)
 
 	// ----------------------
 	// Price logic ----------
@@ -97,7 +98,7 @@ func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
 		productOut.Color,
 		strings.Replace(p.ProductName, p.BrandName, "", 1),
 	}
-	productOut.ColorGroups = handleColors(
+	productOut.ColorGroups = feed.MapColors(
 		p.mapping,
 		colorCandidates...,
 	)
@@ -109,7 +110,7 @@ func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
 	// Gender logic ---------
 	// ----------------------
 
-	productOut.Gender = handleGender(
+	productOut.Gender = feed.MapGender(
 		p.MerchantCategory,
 		p.CategoryName,
 		p.Custom1,
@@ -123,8 +124,9 @@ func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
 	// Category logic -------
 	// ----------------------
 
-	productOut.ProviderCategories, err = handleCategories(
+	productOut.ProviderCategories, err = feed.MapCategories(
 		p.mapping,
+		"awin",
 		p.MerchantCategory,
 		p.CategoryName,
 		p.Custom1,
@@ -170,159 +172,29 @@ func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
 		return productOut, fmt.Errorf("Failed to set key - %v", err)
 	}
 
-	return productOut, nil
-}
-
-func handleSizes(str ...string) (out []string) {
-	var (
-		tmp []string
-	)
-	for i := range str {
-		tmp = strings.Split(str[i], ",")
-		for j := range tmp {
-			out = append(
-				out,
-				strings.TrimSpace(tmp[j]),
-			)
-		}
-	}
-	return out
-}
-
-func handleAvailability(str ...string) string {
-	for i := range str {
-		switch str[i] {
-		case "1", "instock", "in_stock", "in stock", "true", "yes":
-			return "instock"
-		default:
-			return "out of stock"
-		}
-	}
-	return "out of stock"
-}
-
-func handleColors(mapping *feed.Mapping, str ...string) (colors []string) {
-	var (
-		substr []string
-		terms  []string
-	)
-
-	for s := range str {
-		substr = collection.SplitList(str[s])
-		for i := range substr {
-			terms = append(terms, collection.Sanitize(substr[i]))
-		}
-
-		if strings.ToLower(str[s]) == "no color" {
-			colors = append(colors, "multi")
-		}
-	}
-
-	for i := range terms {
-		replacements, matched := collection.StrictFindReplace2(terms[i], mapping.ColorMap)
-		if !matched {
-			colors = append(
-				colors,
-				terms[i],
-			)
-		}
-		for j := range replacements {
-			colors = append(
-				colors,
-				replacements[j],
-			)
-		}
-	}
-
-	return collection.UniqueNames(colors)
-}
-
-func handleGender(str ...string) string {
-	var (
-		substr []string
-	)
-	for s := range str {
-		substr = collection.SplitList(str[s])
-		for i := range substr {
-			for j := range FemaleTerms {
-				if strings.ContainsAny(strings.ToLower(substr[i]), FemaleTerms[j]) {
-					return "women"
-				}
-			}
-			for j := range MaleTerms {
-				if strings.ContainsAny(strings.ToLower(substr[i]), MaleTerms[j]) {
-					return "men"
-				}
-			}
-			for j := range UnisexTerms {
-				if strings.ContainsAny(strings.ToLower(substr[i]), UnisexTerms[j]) {
-					return "unisex"
-				}
-			}
-		}
-	}
-
-	return ""
-}
-
-func handleCategories(mapping *feed.Mapping, str ...string) (categories []feed.ProviderCategory, err error) {
-	var (
-		substr []string
-		terms  []string
-		gender rune
-		exists bool
-	)
-
-	uniques := make(map[string]struct{})
-	for s := range str {
-		substr = collection.SplitList(str[s])
-		for i := range substr {
-			term := strings.ToLower(substr[i])
-			_, exists = uniques[term]
-			if exists {
-				continue
-			}
-			terms = append(terms, term)
-			uniques[term] = struct{}{}
-		}
-	}
-
-	switch handleGender(terms...) {
-	case "women":
-		gender = 'w'
-		break
-	case "unisex":
-		gender = 'u'
-		break
-	case "men":
-		gender = 'm'
-		break
-	default:
-		return categories, fmt.Errorf("Couldn't parse gender - %v", terms)
-	}
+	// ----------------------
+	// Ranking logic --------
+	// ----------------------
 
-	for i := range terms {
-		replacements, matched := collection.StrictFindReplace2(terms[i], mapping.CatNameMap)
-		if !matched {
+	for _, id := range []string{p.MerchantProductID, p.AWProductID} {
+		c, exists := p.conversions[ac.ConversionKey(p.MerchantID, id)]
+		if id == "" || !exists {
 			continue
 		}
-		for j := range replacements {
-			categories = append(
-				categories,
-				feed.ProviderCategory{
-					ProviderName: "awin",
-					Name:         strings.TrimSpace(replacements[j]),
-					Gender:       gender,
-				},
-			)
+		productOut.Leads7d = c.Leads
+		productOut.Conversions7d = c.Conversions
+		productOut.Commision7d = c.Commission
+		for _, e := range c.Events {
+			productOut.Transactions = append(productOut.Transactions, feed.Transaction{
+				Time:       int32(e.Time.Unix()),
+				Lead:       e.Lead,
+				Commission: e.Commission,
+			})
 		}
+		break
 	}
 
-	if len(categories) == 0 {
-		return categories, fmt.Errorf("No categories found for - %v", terms)
-	}
-
-	return categories, nil
+	return productOut, nil
 }
 
 func handleLanguage(str ...string) string {
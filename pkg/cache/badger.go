@@ -1,16 +1,19 @@
 package cache
 
 import (
+	"path/filepath"
 	"time"
 
 	badger "github.com/dgraph-io/badger"
 	log "github.com/sirupsen/logrus"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
 	zip "stillgrove.com/gofeedyourself/pkg/zip"
 )
 
 type BadgerCache struct {
-	db  *badger.DB
-	ttl time.Duration
+	db   *badger.DB
+	ttl  time.Duration
+	name string
 }
 
 // NewBadgerCache returns a Cache, takes path to cache file on disk (creates file if neccessary)
@@ -26,8 +29,9 @@ func NewBadgerCache(file string, ttl time.Duration) (c Cache, err error) {
 		return c, err
 	}
 	return BadgerCache{
-		db:  db,
-		ttl: ttl,
+		db:   db,
+		ttl:  ttl,
+		name: filepath.Base(file),
 	}, nil
 }
 
@@ -54,6 +58,8 @@ func (b BadgerCache) Load(key string) (payload []byte, err error) {
 		return nil
 	})
 
+	metrics.CacheLookups.Inc(b.name, metrics.Result(err == nil))
+
 	payload, err = zip.Unzip(zipped)
 	if err != nil {
 		return nil, err
@@ -110,6 +116,7 @@ func (b BadgerCache) LoadAll() (outputs map[string][]byte, err error) {
 		}
 		return nil
 	})
+	metrics.CacheLookups.Inc(b.name, metrics.Result(err == nil && len(outputs) > 0))
 
 	return outputs, err
 }
@@ -0,0 +1,123 @@
+// +build unit
+// +build !integration
+
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+func testProduct() feed.Product {
+	retailer := func(name, price, availability string, sizes ...string) feed.Retailer {
+		return feed.Retailer{
+			Name:         name,
+			Link:         "https://" + name + ".se/dress?ref=aff",
+			Price:        price,
+			Currency:     "SEK",
+			Availability: availability,
+			Sizes:        sizes,
+		}
+	}
+	shop := retailer("shop", "399", "instock", "S", "M")
+	shop.HighestPrice = 499
+	p := feed.Product{
+		Name:        "Dress",
+		SKU:         "D1",
+		Color:       "blue",
+		Description: "A \"blue\"\tdress",
+		ImageURL:    "https://img.se/d1.jpg",
+		Brand:       "Brand",
+		Gender:      "women",
+		Language:    "sv_se",
+		Retailers: []feed.Retailer{
+			retailer("cheap", "199", "out of stock", "S"),
+			shop,
+			retailer("expensive", "599", "instock", "L"),
+		},
+		ProviderCategories: []feed.ProviderCategory{
+			{Name: "dresses", Gender: 'w'},
+		},
+		RetailerMap: make(map[uint64]struct{}),
+	}
+	return p
+}
+
+func TestCatalog(t *testing.T) {
+	soldOut := testProduct()
+	soldOut.Retailers = soldOut.Retailers[:1]
+	_, err := newItems(&soldOut)
+	if err == nil {
+		t.Fatalf("Products without a retailer in stock must be skipped")
+	}
+
+	pm, err := feed.PMFromSlice([]feed.Product{testProduct()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, skipped := NewItems(pm)
+	if skipped != 0 || len(items) != 2 {
+		t.Fatalf("Expected the product in two sizes - %d skipped, %+v", skipped, items)
+	}
+	item := items[0]
+	if item.Link != "https://shop.se/dress?ref=aff" || item.SalePrice != 399 || item.Price != 499 ||
+		item.GroupID == "" || item.ID != item.GroupID+"-S" || item.Gender != "female" {
+		t.Fatalf("Expected the cheapest retailer in stock and its own prices - %+v", item)
+	}
+
+	c := Channel{
+		Title:    "Catalog",
+		Link:     "https://www.test.com",
+		Tracking: "utm_source={channel}&utm_medium=shopping",
+	}
+
+	var buf bytes.Buffer
+	err = Write(&buf, FormatGoogleXML, items, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Items []struct {
+			ID    string `xml:"id"`
+			Link  string `xml:"link"`
+			Price string `xml:"price"`
+		} `xml:"channel>item"`
+	}
+	err = xml.Unmarshal(buf.Bytes(), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Items) != 2 || doc.Items[0].Price != "499.00 SEK" ||
+		doc.Items[0].Link != "https://shop.se/dress?ref=aff&utm_medium=shopping&utm_source=google" {
+		t.Fatalf("Wrong google feed - %+v", doc.Items)
+	}
+
+	buf.Reset()
+	err = Write(&buf, FormatGoogleTSV, items, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "\tA \"blue\" dress\t") ||
+		len(strings.Split(lines[1], "\t")) != len(strings.Split(lines[0], "\t")) {
+		t.Fatalf("Wrong google tsv - %q", buf.String())
+	}
+
+	buf.Reset()
+	err = Write(&buf, FormatFacebookCSV, items, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "id" || !strings.Contains(rows[1][8], "utm_source=facebook") {
+		t.Fatalf("Wrong facebook catalog - %v", rows)
+	}
+}
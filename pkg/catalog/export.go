@@ -0,0 +1,272 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Formats of the catalog files
+const (
+	FormatGoogleXML   = "google-xml"
+	FormatGoogleTSV   = "google-tsv"
+	FormatFacebookCSV = "facebook-csv"
+)
+
+// Formats lists the known formats
+var Formats = []string{
+	FormatGoogleXML,
+	FormatGoogleTSV,
+	FormatFacebookCSV,
+}
+
+// fileNames of the formats in the export directory
+var fileNames = map[string]string{
+	FormatGoogleXML:   "google.xml",
+	FormatGoogleTSV:   "google.tsv",
+	FormatFacebookCSV: "facebook.csv",
+}
+
+// Channel describes the catalog in the Google XML and is used for the tracking links
+type Channel struct {
+	Title       string
+	Link        string
+	Description string
+	Tracking    string // query parameters added to the links, e.g. utm_source={channel}&utm_medium=shopping
+}
+
+// Export writes the items in the formats to dir and returns the paths of the files
+func Export(dir string, items []Item, formats []string, c Channel) (files []string, err error) {
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return files, fmt.Errorf("Create catalog folder - %v", err)
+	}
+
+	for _, format := range formats {
+		name, ok := fileNames[format]
+		if !ok {
+			return files, fmt.Errorf("Unknown catalog format %s", format)
+		}
+		path := filepath.Join(dir, name)
+		err = writeFile(path, func(w io.Writer) error {
+			return Write(w, format, items, c)
+		})
+		if err != nil {
+			return files, fmt.Errorf("Write %s - %v", name, err)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// writeFile replaces path only once the whole catalog is written, so it is never served half done
+func writeFile(path string, write func(io.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Write writes the items in one of the formats
+func Write(w io.Writer, format string, items []Item, c Channel) error {
+	switch format {
+	case FormatGoogleXML:
+		return writeGoogleXML(w, items, c)
+	case FormatGoogleTSV:
+		return writeTSV(w, googleColumns, items, c.Tracking, "google")
+	case FormatFacebookCSV:
+		return writeCSV(w, facebookColumns, items, c.Tracking, "facebook")
+	}
+	return fmt.Errorf("Unknown catalog format %s", format)
+}
+
+type column struct {
+	name  string
+	value func(Item) string
+}
+
+var googleColumns = []column{
+	{"id", func(i Item) string { return i.ID }},
+	{"item_group_id", func(i Item) string { return i.GroupID }},
+	{"title", func(i Item) string { return i.Title }},
+	{"description", func(i Item) string { return i.Description }},
+	{"link", func(i Item) string { return i.Link }},
+	{"image_link", func(i Item) string { return i.ImageLink }},
+	{"availability", func(i Item) string { return i.Availability }},
+	{"price", func(i Item) string { return formatPrice(i.Price, i.Currency) }},
+	{"sale_price", func(i Item) string { return formatPrice(i.SalePrice, i.Currency) }},
+	{"brand", func(i Item) string { return i.Brand }},
+	{"condition", func(i Item) string { return "new" }},
+	{"identifier_exists", func(i Item) string { return "no" }},
+	{"color", func(i Item) string { return i.Color }},
+	{"size", func(i Item) string { return i.Size }},
+	{"gender", func(i Item) string { return i.Gender }},
+	{"age_group", func(i Item) string { return "adult" }},
+	{"product_type", func(i Item) string { return i.ProductType }},
+}
+
+var facebookColumns = []column{
+	{"id", func(i Item) string { return i.ID }},
+	{"item_group_id", func(i Item) string { return i.GroupID }},
+	{"title", func(i Item) string { return i.Title }},
+	{"description", func(i Item) string { return i.Description }},
+	{"availability", func(i Item) string { return i.Availability }},
+	{"condition", func(i Item) string { return "new" }},
+	{"price", func(i Item) string { return formatPrice(i.Price, i.Currency) }},
+	{"sale_price", func(i Item) string { return formatPrice(i.SalePrice, i.Currency) }},
+	{"link", func(i Item) string { return i.Link }},
+	{"image_link", func(i Item) string { return i.ImageLink }},
+	{"brand", func(i Item) string { return i.Brand }},
+	{"color", func(i Item) string { return i.Color }},
+	{"size", func(i Item) string { return i.Size }},
+	{"gender", func(i Item) string { return i.Gender }},
+	{"age_group", func(i Item) string { return "adult" }},
+	{"product_type", func(i Item) string { return i.ProductType }},
+}
+
+// eachRow calls write with the header and a row per item, tabs and line breaks are dropped from the values
+func eachRow(columns []column, items []Item, tracking, channel string, write func(row []string) error) error {
+	clean := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+	row := make([]string, len(columns))
+	for i := range columns {
+		row[i] = columns[i].name
+	}
+	err := write(row)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		item.Link = trackedLink(item.Link, tracking, channel)
+		for i := range columns {
+			row[i] = clean.Replace(columns[i].value(item))
+		}
+		err = write(row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes the items as comma separated values, quoted where needed
+func writeCSV(w io.Writer, columns []column, items []Item, tracking, channel string) error {
+	writer := csv.NewWriter(w)
+	err := eachRow(columns, items, tracking, channel, writer.Write)
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeTSV writes the items as tab separated lines, Google doesn't accept quoted values
+func writeTSV(w io.Writer, columns []column, items []Item, tracking, channel string) error {
+	buf := bufio.NewWriter(w)
+	err := eachRow(columns, items, tracking, channel, func(row []string) error {
+		_, err := buf.WriteString(strings.Join(row, "\t") + "\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// googleRSS is the RSS 2.0 document of a Google Merchant Center feed
+type googleRSS struct {
+	XMLName xml.Name      `xml:"rss"`
+	Version string        `xml:"version,attr"`
+	NS      string        `xml:"xmlns:g,attr"`
+	Channel googleChannel `xml:"channel"`
+}
+
+type googleChannel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	Items       []googleItem `xml:"item"`
+}
+
+type googleItem struct {
+	ID               string `xml:"g:id"`
+	ItemGroupID      string `xml:"g:item_group_id,omitempty"`
+	Title            string `xml:"title"`
+	Description      string `xml:"description"`
+	Link             string `xml:"link"`
+	ImageLink        string `xml:"g:image_link"`
+	Availability     string `xml:"g:availability"`
+	Price            string `xml:"g:price"`
+	SalePrice        string `xml:"g:sale_price,omitempty"`
+	Brand            string `xml:"g:brand,omitempty"`
+	Condition        string `xml:"g:condition"`
+	IdentifierExists string `xml:"g:identifier_exists"`
+	Color            string `xml:"g:color,omitempty"`
+	Size             string `xml:"g:size,omitempty"`
+	Gender           string `xml:"g:gender"`
+	AgeGroup         string `xml:"g:age_group"`
+	ProductType      string `xml:"g:product_type,omitempty"`
+}
+
+func writeGoogleXML(w io.Writer, items []Item, c Channel) error {
+	doc := googleRSS{
+		Version: "2.0",
+		NS:      "http://base.google.com/ns/1.0",
+		Channel: googleChannel{
+			Title:       c.Title,
+			Link:        c.Link,
+			Description: c.Description,
+			Items:       make([]googleItem, len(items)),
+		},
+	}
+	for i, item := range items {
+		doc.Channel.Items[i] = googleItem{
+			ID:               item.ID,
+			ItemGroupID:      item.GroupID,
+			Title:            item.Title,
+			Description:      item.Description,
+			Link:             trackedLink(item.Link, c.Tracking, "google"),
+			ImageLink:        item.ImageLink,
+			Availability:     item.Availability,
+			Price:            formatPrice(item.Price, item.Currency),
+			SalePrice:        formatPrice(item.SalePrice, item.Currency),
+			Brand:            item.Brand,
+			Condition:        "new",
+			IdentifierExists: "no",
+			Color:            item.Color,
+			Size:             item.Size,
+			Gender:           item.Gender,
+			AgeGroup:         "adult",
+			ProductType:      item.ProductType,
+		}
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/storefront"
+)
+
+// Item is one offer of the catalog, products with several sizes become one item per size sharing the GroupID
+type Item struct {
+	ID           string
+	GroupID      string
+	Title        string
+	Description  string
+	Link         string
+	ImageLink    string
+	Brand        string
+	Color        string
+	Size         string
+	Gender       string // female, male, or unisex
+	ProductType  string // categories, e.g. dresses, party dresses
+	Price        float64
+	SalePrice    float64 // 0 if not discounted
+	Currency     string
+	Availability string
+}
+
+// NewItems returns the items of the products that have a retailer in stock, skipped counts the other ones
+func NewItems(pm *feed.ProductMap) (items []Item, skipped int) {
+	products, _, _, _ := pm.Get()
+
+	keys := make([]uint64, 0, len(products))
+	for key := range products {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, key := range keys {
+		productItems, err := newItems(products[key])
+		if err != nil {
+			skipped++
+			continue
+		}
+		items = append(items, productItems...)
+	}
+	return items, skipped
+}
+
+func newItems(p *feed.Product) (items []Item, err error) {
+	if p.Description == "" && p.ShortDescription == "" || p.ImageURL == "" || p.Name == "" {
+		return items, fmt.Errorf("Incomplete product - %s", p.Name)
+	}
+
+	// the cheapest retailer among the ones in stock
+	inStock := *p
+	inStock.Retailers = nil
+	for i := range p.Retailers {
+		if p.Retailers[i].Availability == "instock" {
+			inStock.Retailers = append(inStock.Retailers, p.Retailers[i])
+		}
+	}
+	dest, err := storefront.GetDestination(&inStock)
+	if err != nil {
+		return items, err
+	}
+
+	// price and sale price have to match the landing page of the link
+	var linked *feed.Retailer
+	for i := range inStock.Retailers {
+		if inStock.Retailers[i].Link == dest.Link && inStock.Retailers[i].Name == dest.Name {
+			linked = &inStock.Retailers[i]
+			break
+		}
+	}
+	if linked == nil {
+		return items, fmt.Errorf("Linked retailer not found - %s", p.Name)
+	}
+	current, err := strconv.ParseFloat(linked.Price, 64)
+	if err != nil {
+		return items, fmt.Errorf("Price of %s - %v", linked.Name, err)
+	}
+
+	var categories []string
+	for i := range p.ProviderCategories {
+		if p.ProviderCategories[i].Name != "" {
+			categories = append(categories, p.ProviderCategories[i].Name)
+		}
+	}
+
+	item := Item{
+		ID:           strconv.FormatUint(p.Key, 10),
+		Title:        p.Name,
+		Description:  p.Description,
+		Link:         dest.Link,
+		ImageLink:    p.ImageURL,
+		Brand:        p.Brand,
+		Color:        p.Color,
+		Gender:       gender(p.Gender),
+		ProductType:  strings.Join(categories, ", "),
+		Price:        math.Max(current, float64(linked.HighestPrice)),
+		Currency:     linked.Currency,
+		Availability: "in stock",
+	}
+	if item.Description == "" {
+		item.Description = p.ShortDescription
+	}
+	if current < item.Price {
+		item.SalePrice = current
+	}
+
+	if len(dest.Sizes) < 2 {
+		if len(dest.Sizes) == 1 {
+			item.Size = dest.Sizes[0]
+		}
+		return []Item{item}, nil
+	}
+	item.GroupID = item.ID
+	for _, size := range dest.Sizes {
+		variant := item
+		variant.ID = item.ID + "-" + size
+		variant.Size = size
+		items = append(items, variant)
+	}
+	return items, nil
+}
+
+func gender(g string) string {
+	switch g {
+	case "women":
+		return "female"
+	case "men":
+		return "male"
+	}
+	return "unisex"
+}
+
+// trackedLink adds the tracking parameters to the link, {channel} is replaced by google or facebook
+func trackedLink(link, tracking, channel string) string {
+	if tracking == "" {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	params, err := url.ParseQuery(strings.Replace(tracking, "{channel}", channel, -1))
+	if err != nil {
+		return link
+	}
+	query := u.Query()
+	for k := range params {
+		query.Set(k, params.Get(k))
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func formatPrice(price float64, currency string) string {
+	if price == 0 {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", price, currency))
+}
@@ -0,0 +1,131 @@
+package cj
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/network"
+)
+
+const (
+	// Endpoint of the CJ product search, a GraphQL API
+	Endpoint = "https://ads.api.cj.com/query"
+	// PageSize is the largest page the product search returns
+	PageSize = 1000
+	// SampleSize describes the limit of products to download when not in production mode
+	SampleSize = 5000
+	// CacheTTL is how long the converted products are reused in production mode
+	CacheTTL = 4 * time.Hour
+)
+
+// productQuery selects the products of the advertisers we joined, the link codes are the ones of our website
+const productQuery = `{
+  products(companyId: "%s", partnerStatus: JOINED, limit: %d, offset: %d) {
+    totalCount
+    resultList {
+      id
+      advertiserId
+      advertiserName
+      title
+      description
+      brand
+      link
+      imageLink
+      availability
+      color
+      size
+      gender
+      material
+      gtin
+      mpn
+      itemGroupId
+      productType
+      googleProductCategory { id name }
+      price { amount currency }
+      salePrice { amount currency }
+      linkCode(pid: "%s") { clickUrl }
+    }
+  }
+}`
+
+// Adapter implements network.Adapter for the CJ product search
+type Adapter struct {
+	token     string
+	companyID string // CID of our publisher account
+	websiteID string // PID of the website the links are tracked for
+	m         *feed.Mapping
+	locale    *feed.Locale
+}
+
+// NewCJ returns the feed of the products of the joined advertisers
+func NewCJ(locale *feed.Locale, token, companyID, websiteID string, mapping *feed.Mapping) (f *network.Feed, err error) {
+	if token == "" || companyID == "" || websiteID == "" {
+		return f, fmt.Errorf("Token, company id and website id are required")
+	}
+	a := &Adapter{
+		token:     token,
+		companyID: companyID,
+		websiteID: websiteID,
+		m:         mapping,
+		locale:    locale,
+	}
+	return network.NewFeed(a, locale, network.Options{
+		PageSize:   PageSize,
+		SampleSize: SampleSize,
+		CacheTTL:   CacheTTL,
+	}), nil
+}
+
+// Name implements network.Adapter
+func (a *Adapter) Name() string {
+	return "CJ"
+}
+
+// Page implements network.Adapter
+func (a *Adapter) Page(offset, size int) (network.Request, error) {
+	return network.NewPost(
+		Endpoint,
+		map[string]string{
+			"query": fmt.Sprintf(productQuery, a.companyID, size, offset, a.websiteID),
+		},
+		map[string]string{
+			"Authorization": "Bearer " + a.token,
+		},
+	)
+}
+
+// response of the product search
+type response struct {
+	Data struct {
+		Products struct {
+			TotalCount int       `json:"totalCount"`
+			ResultList []Product `json:"resultList"`
+		} `json:"products"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Decode implements network.Adapter
+func (a *Adapter) Decode(raw []byte) (products []network.Product, total int, err error) {
+	var r response
+	err = json.Unmarshal(raw, &r)
+	if err != nil {
+		return products, total, fmt.Errorf("Unmarshal products - %v", err)
+	}
+	if len(r.Errors) > 0 {
+		return products, total, fmt.Errorf("Product search failed - %s", r.Errors[0].Message)
+	}
+
+	list := r.Data.Products.ResultList
+	products = make([]network.Product, len(list))
+	for i := range list {
+		list[i].mapping = a.m
+		list[i].locale = a.locale
+		products[i] = &list[i]
+	}
+	return products, r.Data.Products.TotalCount, nil
+}
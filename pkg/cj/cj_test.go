@@ -0,0 +1,96 @@
+// +build unit
+// +build !integration
+
+package cj
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/network"
+)
+
+const testResponse = `{"data": {"products": {"totalCount": 5, "resultList": [
+  {
+    "id": "P1", "advertiserId": "42", "advertiserName": "Shop", "title": "Brand Summer Dress",
+    "description": "A navy dress", "brand": "Brand", "link": "https://shop.com/p1",
+    "imageLink": "https://shop.com/p1.jpg", "availability": "in stock", "color": "Navy", "size": "S, M",
+    "gender": "female", "productType": ["Dresses"], "googleProductCategory": {"id": "2271", "name": "Apparel > Dresses"},
+    "price": {"amount": "799.00", "currency": "SEK"}, "salePrice": {"amount": "499.00", "currency": "SEK"},
+    "linkCode": {"clickUrl": "https://www.anrdoezrs.net/click-1-2?url=p1"}
+  },
+  {
+    "id": "P3", "advertiserId": "42", "advertiserName": "Shop", "title": "Brand Shirt", "link": "https://shop.com/p3",
+    "imageLink": "https://shop.com/p3.jpg", "availability": "in stock", "color": "Navy", "gender": "male", "productType": ["Shirts"],
+    "price": {"amount": "299.00", "currency": "SEK"}, "linkCode": {"clickUrl": "https://www.anrdoezrs.net/click-1-2?url=p3"}
+  },
+  {
+    "id": "P4", "advertiserId": "42", "advertiserName": "Shop", "title": "Brand Tote", "link": "https://shop.com/p4",
+    "imageLink": "https://shop.com/p4.jpg", "availability": "in stock", "color": "Navy", "gender": "unisex", "productType": ["Bags"],
+    "price": {"amount": "299.00", "currency": "SEK"}, "linkCode": {"clickUrl": "https://www.anrdoezrs.net/click-1-2?url=p4"}
+  },
+  {
+    "id": "P5", "advertiserId": "42", "advertiserName": "Shop", "title": "Brand Untracked Tote", "link": "https://shop.com/p5",
+    "imageLink": "https://shop.com/p5.jpg", "availability": "in stock", "color": "Navy", "gender": "unisex", "productType": ["Bags"],
+    "price": {"amount": "299.00", "currency": "SEK"}
+  },
+  {"id": "P2", "title": "No price", "price": {"amount": "", "currency": "SEK"}}
+]}}}`
+
+func TestAdapter(t *testing.T) {
+	locale, err := feed.NewLocale("SE", "sv", "sv_se")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blue, dress := "blue", "dresses"
+	a := &Adapter{
+		token:     "token",
+		companyID: "1",
+		websiteID: "2",
+		m: &feed.Mapping{
+			ColorMap:   map[string][]*string{"navy": {&blue}},
+			CatNameMap: map[string][]*string{"dresses": {&dress}},
+		},
+		locale: locale,
+	}
+
+	r, err := a.Page(1000, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Query string `json:"query"`
+	}
+	json.Unmarshal(r.(*network.HTTPRequest).Body, &body)
+	if !strings.Contains(body.Query, "offset: 1000") || !strings.Contains(body.Query, `pid: "2"`) {
+		t.Fatalf("Wrong query - %s", body.Query)
+	}
+
+	products, total, err := a.Decode([]byte(testResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted := network.Convert(a.Name(), products)
+	if total != 5 || len(converted) != 3 {
+		t.Fatalf("Expected the products with a price and a click url - %d of %d", len(converted), total)
+	}
+	bySKU := make(map[string]feed.Product)
+	for i := range converted {
+		bySKU[converted[i].SKU] = converted[i]
+	}
+	if bySKU["P3"].Gender != "men" || bySKU["P4"].Gender != "unisex" {
+		t.Fatalf("Wrong genders - %q and %q", bySKU["P3"].Gender, bySKU["P4"].Gender)
+	}
+	p := bySKU["P1"]
+	if p.Gender != "women" || p.Retailers[0].Price != "499.00" || p.HighestPrice != 799 ||
+		!strings.Contains(p.Retailers[0].Link, "anrdoezrs") || len(p.Retailers[0].Sizes) != 2 {
+		t.Fatalf("Wrong product - %+v", p)
+	}
+
+	_, _, err = a.Decode([]byte(`{"errors": [{"message": "Not authorized"}]}`))
+	if err == nil {
+		t.Fatalf("Expected the error of the response")
+	}
+}
@@ -0,0 +1,172 @@
+package cj
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// Amount is a price of the product search
+type Amount struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Product is a product of the CJ product search
+type Product struct {
+	ID                    string   `json:"id"`
+	AdvertiserID          string   `json:"advertiserId"`
+	AdvertiserName        string   `json:"advertiserName"`
+	Title                 string   `json:"title"`
+	Description           string   `json:"description"`
+	Brand                 string   `json:"brand"`
+	Link                  string   `json:"link"`
+	ImageLink             string   `json:"imageLink"`
+	Availability          string   `json:"availability"`
+	Color                 string   `json:"color"`
+	Size                  string   `json:"size"`
+	Gender                string   `json:"gender"`
+	Material              string   `json:"material"`
+	GTIN                  string   `json:"gtin"`
+	MPN                   string   `json:"mpn"`
+	ItemGroupID           string   `json:"itemGroupId"`
+	ProductType           []string `json:"productType"`
+	GoogleProductCategory struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"googleProductCategory"`
+	Price     Amount `json:"price"`
+	SalePrice Amount `json:"salePrice"`
+	LinkCode  struct {
+		ClickURL string `json:"clickUrl"`
+	} `json:"linkCode"`
+
+	mapping *feed.Mapping
+	locale  *feed.Locale
+}
+
+// ToFeedProduct implements network.Product
+func (p *Product) ToFeedProduct() (productOut *feed.Product, err error) {
+	price, err := strconv.ParseFloat(p.Price.Amount, 64)
+	if err != nil || price == 0 {
+		return productOut, fmt.Errorf("Failed to parse price - %s", p.Price.Amount)
+	}
+	salePrice, err := strconv.ParseFloat(p.SalePrice.Amount, 64)
+	if err != nil || salePrice == 0 || salePrice > price {
+		salePrice = price
+	}
+	// the advertiser's own link isn't tracked and earns no commission
+	link := p.LinkCode.ClickURL
+	if link == "" {
+		return productOut, fmt.Errorf("No click url - %s", p.Link)
+	}
+
+	feedID, _ := strconv.Atoi(p.AdvertiserID)
+	if feedID == 0 {
+		feedID = 997
+	}
+
+	productOut = &feed.Product{
+		Name:        p.Title,
+		SKU:         collection.CollateStrings(p.ID, p.GTIN, p.MPN),
+		Color:       collection.CollateStrings(p.Color, "multi"),
+		Description: p.Description,
+		ImageURL:    p.ImageLink,
+		Brand:       p.Brand,
+		Material:    p.Material,
+		Language:    p.locale.Locale,
+		Retailers: []feed.Retailer{
+			feed.Retailer{
+				Link:         link,
+				Name:         p.AdvertiserName,
+				Price:        strconv.FormatFloat(salePrice, 'f', 2, 32),
+				HighestPrice: float32(price),
+				Currency:     p.Price.Currency,
+				Availability: feed.MapAvailability(strings.ToLower(p.Availability)),
+				FeedID:       int32(feedID),
+				IsCrawler:    false,
+				Sizes:        feed.SplitSizes(p.Size),
+			},
+		},
+		LowestPrice:  float32(salePrice),
+		HighestPrice: float32(price),
+	}
+
+	productOut.FromFeeds = []int32{int32(feedID)}
+
+	err = productOut.CalculateDiscounts(10)
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to parse prices - %v", err)
+	}
+
+	// ----------------------
+	// Color logic ----------
+	// ----------------------
+
+	colorCandidates := []string{
+		p.Color,
+		productOut.Color,
+		strings.Replace(p.Title, p.Brand, "", 1),
+	}
+	productOut.ColorGroups = feed.MapColors(
+		p.mapping,
+		colorCandidates...,
+	)
+	if len(productOut.ColorGroups) == 0 {
+		return productOut, fmt.Errorf("Failed to parse color - %v", colorCandidates)
+	}
+
+	// ----------------------
+	// Gender logic ---------
+	// ----------------------
+
+	categories := append([]string{p.GoogleProductCategory.Name}, p.ProductType...)
+	productOut.Gender = feed.MapGender(append([]string{p.Gender}, categories...)...)
+	if productOut.Gender == "" {
+		return productOut, fmt.Errorf("Failed to parse gender - %v", categories)
+	}
+
+	// ----------------------
+	// Category logic -------
+	// ----------------------
+
+	for _, c := range categories {
+		if c != "" {
+			productOut.OriginalCategories = append(productOut.OriginalCategories, c)
+		}
+	}
+	productOut.ProviderCategories, err = feed.MapCategories(
+		p.mapping,
+		"cj",
+		append([]string{productOut.Gender}, categories...)...,
+	)
+	if len(productOut.OriginalCategories) == 0 && err != nil {
+		return productOut, fmt.Errorf("Failed to parse categories - %v", err)
+	}
+
+	// ----------------------
+	// Offer logic ----------
+	// ----------------------
+
+	productOut.RetailerMap = map[uint64]struct{}{
+		collection.HashKey(collection.CollateStrings(p.Link, link)): struct{}{},
+	}
+	productOut.Active = productOut.Retailers[0].Availability == "instock"
+
+	// ----------------------
+	// SKU logic ------------
+	// ----------------------
+
+	if productOut.SKU == "" || link == "" {
+		return productOut, fmt.Errorf("No identifier or link found - %s", p.Title)
+	}
+	err = productOut.SetKey()
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to set key - %v", err)
+	}
+
+	return productOut, nil
+}
@@ -9,6 +9,11 @@ import (
 	"strings"
 )
 
+var (
+	nonLetters        = regexp.MustCompile("[^a-zA-Z]+")
+	nonSlugCharacters = regexp.MustCompile("[^a-z0-9]+")
+)
+
 // UniqueNames returns a slice of unique elements of in
 func UniqueNames(in []string) []string {
 	var out []string
@@ -191,11 +196,37 @@ func Sanitize(s string) (str string) {
 func SanitizeHard(s string) string {
 	s = html.UnescapeString(strings.TrimSpace(s))
 
-	reg, _ := regexp.Compile("[^a-zA-Z]+")
-	s = reg.ReplaceAllString(strings.ToLower(s), " ")
+	s = nonLetters.ReplaceAllString(strings.ToLower(s), " ")
 
 	return strings.TrimSpace(s)
 }
+
+// Slugify turns a term into a lowercase, dash-separated ASCII slug as used by WordPress,
+// e.g. "Jeans & Byxor" -> "jeans-byxor", "Tröjor" -> "trojor"
+func Slugify(s string) string {
+	s = strings.ToLower(html.UnescapeString(strings.TrimSpace(s)))
+
+	var replacer = strings.NewReplacer(
+		"å", "a",
+		"ä", "a",
+		"à", "a",
+		"á", "a",
+		"æ", "ae",
+		"ö", "o",
+		"ø", "o",
+		"ó", "o",
+		"é", "e",
+		"è", "e",
+		"ü", "u",
+		"ß", "ss",
+	)
+	s = replacer.Replace(s)
+
+	s = nonSlugCharacters.ReplaceAllString(s, "-")
+
+	return strings.Trim(s, "-")
+}
+
 func SplitList(s string) (out []string) {
 	split := func(r rune) bool {
 		return r == ',' || r == ':' || r == ';' || r == '.' || r == '/' || r == '#' || r == '>'
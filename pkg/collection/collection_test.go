@@ -125,6 +125,15 @@ func ExampleSanitize() {
 	// a b c d e
 }
 
+func ExampleSlugify() {
+	fmt.Println(Slugify("Jeans & Byxor"))
+	fmt.Println(Slugify(" Tröjor/Västar "))
+
+	// Output:
+	// jeans-byxor
+	// trojor-vastar
+}
+
 func ExampleRemoveFromString() {
 	var selectors = []string{
 		"test",
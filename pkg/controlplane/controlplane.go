@@ -0,0 +1,188 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	gfy "stillgrove.com/gofeedyourself/pkg/feedservice"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
+	"stillgrove.com/gofeedyourself/pkg/scheduler"
+)
+
+// Runner is the part of the FeedService driven by the control plane
+type Runner interface {
+	Trigger(dryRun bool, feeds []string) error
+	Status() gfy.RunStatus
+	Report() gfy.RunReport
+	ReloadMappings() error
+	Ready() error
+}
+
+// Server is the HTTP API to trigger and inspect runs of a long running FeedService
+type Server struct {
+	runner Runner
+	token  string
+	srv    *http.Server
+}
+
+// New returns a Server listening on addr, every endpoint but the probes and /metrics requires the token
+func New(addr, token string, runner Runner) (s *Server, err error) {
+	if token == "" {
+		return s, fmt.Errorf("No token for the control plane")
+	}
+
+	s = &Server{
+		runner: runner,
+		token:  token,
+	}
+	s.srv = &http.Server{
+		Addr:         addr,
+		Handler:      s.Handler(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	return s, nil
+}
+
+// Handler returns the routes of the control plane
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.Handle("/metrics", metrics.Default.Handler())
+	mux.HandleFunc("/runs", s.authorized(http.MethodPost, s.triggerRun))
+	mux.HandleFunc("/status", s.authorized(http.MethodGet, s.status))
+	mux.HandleFunc("/report", s.authorized(http.MethodGet, s.report))
+	mux.HandleFunc("/report/diff", s.authorized(http.MethodGet, s.diff))
+	mux.HandleFunc("/mappings/reload", s.authorized(http.MethodPost, s.reloadMappings))
+	return mux
+}
+
+// Start serves the API in the background
+func (s *Server) Start() {
+	go func() {
+		log.WithField("Address", s.srv.Addr).Infoln("Control plane listening")
+		err := s.srv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.WithField("Error", err).Errorln("Control plane stopped")
+		}
+	}()
+}
+
+// Shutdown stops the server, open requests are finished until ctx expires
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) authorized(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			writeError(rw, http.StatusMethodNotAllowed, fmt.Errorf("Use %s", method))
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(rw, http.StatusUnauthorized, fmt.Errorf("Invalid token"))
+			return
+		}
+		next(rw, r)
+	}
+}
+
+func (s *Server) healthz(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) readyz(rw http.ResponseWriter, r *http.Request) {
+	err := s.runner.Ready()
+	if err != nil {
+		writeError(rw, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// triggerRun takes mode=full|dry-run and optionally feed=<name>, e.g. feed=awin, repeatable
+func (s *Server) triggerRun(rw http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "full":
+	case "dry-run":
+		dryRun = true
+	default:
+		writeError(rw, http.StatusBadRequest, fmt.Errorf("Unknown mode %q, use full or dry-run", mode))
+		return
+	}
+	feeds := r.URL.Query()["feed"]
+
+	err := s.runner.Trigger(dryRun, feeds)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), scheduler.ErrLocked.Error()) {
+			status = http.StatusConflict
+		}
+		writeError(rw, status, err)
+		return
+	}
+
+	log.WithFields(
+		log.Fields{
+			"Dry Run": dryRun,
+			"Feeds":   feeds,
+		},
+	).Infoln("Run triggered")
+
+	writeJSON(rw, http.StatusAccepted, map[string]interface{}{
+		"dry_run": dryRun,
+		"feeds":   feeds,
+	})
+}
+
+func (s *Server) status(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, http.StatusOK, s.runner.Status())
+}
+
+func (s *Server) report(rw http.ResponseWriter, r *http.Request) {
+	report := s.runner.Report()
+	// the diff can be large, it has its own endpoint
+	report.Diff = nil
+	writeJSON(rw, http.StatusOK, report)
+}
+
+func (s *Server) diff(rw http.ResponseWriter, r *http.Request) {
+	diff := s.runner.Report().Diff
+	if diff == nil {
+		writeError(rw, http.StatusNotFound, fmt.Errorf("No diff yet"))
+		return
+	}
+	writeJSON(rw, http.StatusOK, diff)
+}
+
+func (s *Server) reloadMappings(rw http.ResponseWriter, r *http.Request) {
+	err := s.runner.ReloadMappings()
+	if err != nil {
+		writeError(rw, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, map[string]string{"status": "reloaded"})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	err := json.NewEncoder(rw).Encode(v)
+	if err != nil {
+		log.WithField("Error", err).Warnln("Failed to write response")
+	}
+}
+
+func writeError(rw http.ResponseWriter, status int, err error) {
+	writeJSON(rw, status, map[string]string{"error": err.Error()})
+}
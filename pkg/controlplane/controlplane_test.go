@@ -0,0 +1,85 @@
+// +build unit
+// +build !integration
+
+package controlplane
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gfy "stillgrove.com/gofeedyourself/pkg/feedservice"
+	"stillgrove.com/gofeedyourself/pkg/scheduler"
+)
+
+type testRunner struct {
+	running bool
+	dryRun  bool
+	feeds   []string
+}
+
+func (t *testRunner) Trigger(dryRun bool, feeds []string) error {
+	if t.running {
+		return fmt.Errorf("%v - PID 1", scheduler.ErrLocked)
+	}
+	t.running, t.dryRun, t.feeds = true, dryRun, feeds
+	return nil
+}
+
+func (t *testRunner) Status() gfy.RunStatus {
+	return gfy.RunStatus{Running: t.running}
+}
+
+func (t *testRunner) Report() gfy.RunReport {
+	return gfy.RunReport{}
+}
+
+func (t *testRunner) ReloadMappings() error {
+	return nil
+}
+
+func (t *testRunner) Ready() error {
+	return nil
+}
+
+func TestControlPlane(t *testing.T) {
+	runner := new(testRunner)
+	s, err := New(":0", "secret", runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := s.Handler()
+
+	var cases = []struct {
+		method, target, token string
+		status                int
+	}{
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+		{http.MethodGet, "/readyz", "", http.StatusOK},
+		{http.MethodGet, "/status", "", http.StatusUnauthorized},
+		{http.MethodGet, "/status", "wrong", http.StatusUnauthorized},
+		{http.MethodGet, "/runs", "secret", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/runs?mode=partial", "secret", http.StatusBadRequest},
+		{http.MethodPost, "/runs?mode=dry-run&feed=awin", "secret", http.StatusAccepted},
+		{http.MethodPost, "/runs", "secret", http.StatusConflict},
+		{http.MethodGet, "/status", "secret", http.StatusOK},
+		{http.MethodGet, "/report/diff", "secret", http.StatusNotFound},
+		{http.MethodPost, "/mappings/reload", "secret", http.StatusOK},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.target, nil)
+		if c.token != "" {
+			r.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		if rw.Code != c.status {
+			t.Fatalf("%s %s - expected %d, got %d: %s", c.method, c.target, c.status, rw.Code, rw.Body.String())
+		}
+	}
+
+	if !runner.dryRun || len(runner.feeds) != 1 || runner.feeds[0] != "awin" {
+		t.Fatalf("Wrong run triggered - %+v", runner)
+	}
+}
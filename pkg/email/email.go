@@ -43,6 +43,7 @@ func (m *Mail) Send(subject, body string, to []string) error {
 		m.sender.host,
 	)
 
+	m.recipients = to
 	msg := m.compose(subject, body)
 	err := smtp.SendMail(m.server, auth, "no-reply@stillgrove.com", to, msg)
 
@@ -0,0 +1,99 @@
+package feedservice
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+	"stillgrove.com/gofeedyourself/pkg/scheduler"
+)
+
+// CacheInfo describes one of the on-disk caches, e.g. of a feed or the deletion state
+type CacheInfo struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+}
+
+// CacheDir returns the folder holding the badger caches
+func CacheDir() string {
+	return helpers.FindFolderDir("gofeedyourself") + "/cache"
+}
+
+// Caches lists the caches in the cache folder
+func Caches() ([]CacheInfo, error) {
+	entries, err := ioutil.ReadDir(CacheDir())
+	if err != nil {
+		return nil, fmt.Errorf("Read cache folder - %v", err)
+	}
+
+	var caches []CacheInfo
+	for i := range entries {
+		if !entries[i].IsDir() {
+			continue
+		}
+		info := CacheInfo{
+			Name:     entries[i].Name(),
+			Modified: entries[i].ModTime(),
+		}
+		filepath.Walk(
+			filepath.Join(CacheDir(), info.Name),
+			func(path string, f os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				info.Size += f.Size()
+				if f.ModTime().After(info.Modified) {
+					info.Modified = f.ModTime()
+				}
+				return nil
+			},
+		)
+		caches = append(caches, info)
+	}
+
+	return caches, nil
+}
+
+// ClearCaches removes the named caches, all of them if names is empty.
+// It fails while a run holds the lock, the run has the caches open
+func ClearCaches(names []string) error {
+	lock, err := scheduler.AcquireLock(LockFile())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	caches, err := Caches()
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]struct{}, len(caches))
+	for i := range caches {
+		existing[caches[i].Name] = struct{}{}
+	}
+	if len(names) == 0 {
+		for name := range existing {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := existing[name]; !ok {
+			return fmt.Errorf("No cache named %s", name)
+		}
+		err = os.RemoveAll(filepath.Join(CacheDir(), name))
+		if err != nil {
+			return fmt.Errorf("Remove cache %s - %v", name, err)
+		}
+		log.WithField("Cache", name).Infoln("Cleared")
+	}
+
+	return nil
+}
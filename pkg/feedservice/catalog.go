@@ -0,0 +1,95 @@
+package feedservice
+
+import (
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/catalog"
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+	"stillgrove.com/gofeedyourself/pkg/sftp"
+)
+
+// CatalogDir returns the folder the catalog backend writes its files to
+func CatalogDir() string {
+	return helpers.FindFolderDir("gofeedyourself") + "/dump/catalog"
+}
+
+// exportCatalog writes the collated products as shopping catalog and uploads the files unless upload is false
+func (p *FeedService) exportCatalog(q *feed.Queue, feeds []feed.Feed, upload bool) error {
+	var (
+		products *feed.ProductMap
+		err      error
+	)
+	for r := 0; r < Retries; r++ {
+		products, err = q.GetPM(true)
+		if err == nil {
+			break
+		}
+		log.Printf("Loading products - %v", err)
+	}
+	if err != nil {
+		return fmt.Errorf("Load Products - %v", err)
+	}
+
+	np, _, _ := products.Stats()
+	p.tracker.setLoaded(feeds, int(np))
+
+	items, skipped := catalog.NewItems(products)
+	c := p.cfg.GetCatalog()
+	files, err := catalog.Export(
+		CatalogDir(),
+		items,
+		c.Formats,
+		catalog.Channel{
+			Title:       c.Title,
+			Link:        c.Link,
+			Description: c.Description,
+			Tracking:    c.Tracking,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	log.WithFields(
+		log.Fields{
+			"Products": np,
+			"Items":    len(items),
+			"Skipped":  skipped,
+			"Files":    files,
+		},
+	).Infoln("Wrote Catalog")
+
+	if c.UploadDir == "" || !upload {
+		return nil
+	}
+	return p.uploadCatalog(files, c.UploadDir)
+}
+
+func (p *FeedService) uploadCatalog(files []string, dir string) error {
+	host, port, user, password, err := p.cfg.GetFTP()
+	if err != nil {
+		return fmt.Errorf("Upload catalog - %v", err)
+	}
+	sess, err := sftp.NewSession(host, user, password, port)
+	if err != nil {
+		return fmt.Errorf("Upload catalog - %v", err)
+	}
+	defer sess.Close()
+
+	for _, file := range files {
+		err = sess.Upload(file, dir+"/"+filepath.Base(file))
+		if err != nil {
+			return fmt.Errorf("Upload %s - %v", filepath.Base(file), err)
+		}
+	}
+	log.WithFields(
+		log.Fields{
+			"Host":   host,
+			"Folder": dir,
+		},
+	).Infoln("Uploaded Catalog")
+	return nil
+}
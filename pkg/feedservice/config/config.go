@@ -3,44 +3,23 @@ package config
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"strconv"
 	"strings"
 
+	awinclient "stillgrove.com/gofeedyourself/pkg/awin/client"
 	"stillgrove.com/gofeedyourself/pkg/collection"
-
-	"gopkg.in/yaml.v2"
+	"stillgrove.com/gofeedyourself/pkg/filefeed"
 	"stillgrove.com/gofeedyourself/pkg/googlesheets"
+	"stillgrove.com/gofeedyourself/pkg/ranking"
+	"stillgrove.com/gofeedyourself/pkg/shipping"
+	gtd "stillgrove.com/gofeedyourself/pkg/tradedoubler/client"
 )
 
-var (
-	EnvVars = []string{
-		"WOO_KEY",
-		"WOO_SECRET",
-		"DYNAMO_ID",
-		"DYNAMO_SECRET",
-		"FTP_HOST",
-		"FTP_USER",
-		"FTP_PASS",
-		"FTP_PORT",
-		"EMAIL_PW",
-		"AWIN_TOKEN",
-		"AWIN_FEED_TOKEN",
-	}
-)
-
-type tdFeed struct {
-	Name string `yaml:"name"`
-	ID   int    `yaml:"id"`
-}
-
 // TdWebsite contains all the configs from the Tradedoubler Site
-// such as: tokens, feed ids
+// such as: tokens, the selected feeds and the filters of their products
 type TdWebsite struct {
-	Name  string `yaml:"name"`
-	Token string
-	Feeds map[int]tdFeed
+	Name          string `yaml:"name"`
+	Token         string
+	gtd.Selection `yaml:",inline"`
 }
 
 type tdConfig struct {
@@ -48,9 +27,17 @@ type tdConfig struct {
 	Website         TdWebsite `yaml:"website"`
 }
 type wooConfig struct {
-	Domain string `yaml:"domain"`
-	key    string
-	secret string
+	Domain   string         `yaml:"domain"`
+	Deletion deletionConfig `yaml:"deletion"`
+	key      string
+	secret   string
+}
+type deletionConfig struct {
+	MaxDeleteShare  float64 `yaml:"max_delete_share"`
+	MaxDropShare    float64 `yaml:"max_drop_share"`
+	SoftDeleteRuns  int     `yaml:"soft_delete_runs"`
+	HideAfterDays   int     `yaml:"hide_after_days"`
+	DeleteAfterDays int     `yaml:"delete_after_days"`
 }
 type dynamoConfig struct {
 	ID           string
@@ -63,116 +50,120 @@ type gsheetConfig struct {
 	CellRange string `yaml:"range"`
 }
 type ftpConfig struct {
-	host     string
-	username string
+	Host     string `yaml:"host"`
+	User     string `yaml:"user"`
+	Port     int    `yaml:"port"`
 	password string
-	port     int
 }
 type emailConfig struct {
 	Name     string `yaml:"name"`
 	Server   string `yaml:"server"`
 	password string
 }
-type awinConfig struct {
-	apiToken  string
-	feedToken string
+type controlConfig struct {
+	Addr  string `yaml:"addr"`
+	token string
+}
+type metricsConfig struct {
+	PushGateway string `yaml:"pushgateway"`
 }
 
-// File contains all settings for a FeedService instance
-type File struct {
-	Country   string                  `yaml:"country"`
-	Locale    string                  `yaml:"locale"`
-	Language  string                  `yaml:"language"`
-	Time      string                  `yaml:"time"`
-	CleanDays []string                `yaml:"clean_days"`
-	Woo       wooConfig               `yaml:"woocommerce"`
-	TD        tdConfig                `yaml:"tradedoubler"`
-	Dynamo    dynamoConfig            `yaml:"dynamodb"`
-	GSheet    map[string]gsheetConfig `yaml:"gsheet"`
-	email     emailConfig             `yaml:"email"`
-	ftp       ftpConfig
-	Awin      awinConfig
-}
-
-// New returns a pointer to a config object
-func New(filePath string) (cfg *File, err error) {
-	cfg = new(File)
-
-	yamlFile, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return cfg, err
-	}
+// NotifyTarget says where and when run summaries are sent
+type NotifyTarget struct {
+	Recipients []string `yaml:"recipients"` // email only
+	URL        string   `yaml:"url"`        // webhook only, NOTIFY_WEBHOOK_URL overrides it
+	When       []string `yaml:"when"`       // always, failure, errors, drop, deletions
+}
 
-	err = yaml.Unmarshal(yamlFile, &cfg)
-	if err != nil {
-		return cfg, err
-	}
+// Notifications configures the run summaries
+type Notifications struct {
+	Email        NotifyTarget `yaml:"email"`
+	Webhook      NotifyTarget `yaml:"webhook"`
+	MaxDropShare float64      `yaml:"max_drop_share"` // drop of the product count that triggers "drop"
+}
 
-	names := append(EnvVars, "TD_TOKEN_"+cfg.TD.Website.Name)
-	envs, err := getEnvs(names)
-	if err != nil {
-		return cfg, err
-	}
+// Health configures the feed health checks, zero thresholds mean the defaults apply
+type Health struct {
+	Action          string  `yaml:"action"` // flag or quarantine
+	MaxCountDrop    float64 `yaml:"max_count_drop"`
+	MaxInStockShift float64 `yaml:"max_in_stock_shift"`
+	MaxPriceShift   float64 `yaml:"max_price_shift"`
+	MaxCategoryDrop float64 `yaml:"max_category_drop"`
+	MaxMappingDrop  float64 `yaml:"max_mapping_drop"`
+}
 
-	cfg.TD.Website.Token = envs["TD_TOKEN_"+cfg.TD.Website.Name]
-	cfg.Awin.apiToken = envs["AWIN_TOKEN"]
-	cfg.Awin.feedToken = envs["AWIN_FEED_TOKEN"]
+// Catalog configures the product catalog for shopping ads written by the catalog backend
+type Catalog struct {
+	Formats     []string `yaml:"formats"` // google-xml, google-tsv, facebook-csv
+	Title       string   `yaml:"title"`
+	Link        string   `yaml:"link"`
+	Description string   `yaml:"description"`
+	Tracking    string   `yaml:"tracking"`   // query parameters added to the links, {channel} is google or facebook
+	UploadDir   string   `yaml:"upload_dir"` // folder on the ftp host, empty keeps the files local
+}
 
-	cfg.Woo.key = envs["WOO_KEY"]
-	cfg.Woo.secret = envs["WOO_SECRET"]
+// googleConfig holds the Sheets API credentials, config/credentials.json and token.json are used if empty
+type googleConfig struct {
+	credentials string
+	token       string
+}
+type awinConfig struct {
+	apiToken     string
+	feedToken    string
+	LookbackDays int                        `yaml:"lookback_days"` // transactions joined to the products for the ranking
+	Programmes   awinclient.ProgrammeFilter `yaml:"programmes"`    // rules for the programmes whose products are included
+}
 
-	cfg.Dynamo.ID = envs["DYNAMO_ID"]
-	cfg.Dynamo.secret = envs["DYNAMO_SECRET"]
+// adtractionConfig holds the partner API token and the channel (our website) the programs are approved for
+type adtractionConfig struct {
+	token     string
+	ChannelID int `yaml:"channel_id"`
+}
 
-	ftport, _ := strconv.Atoi(envs["FTP_PORT"])
-	cfg.ftp = ftpConfig{
-		host:     envs["FTP_HOST"],
-		username: envs["FTP_USER"],
-		password: envs["FTP_PASS"],
-		port:     ftport,
-	}
+// cjConfig holds the personal access token, the company (CID) and the website (PID) of our CJ account
+type cjConfig struct {
+	token     string
+	CompanyID string `yaml:"company_id"`
+	WebsiteID string `yaml:"website_id"`
+}
 
-	cfg.email.password = envs["EMAIL_PW"]
+// File contains all settings for a FeedService instance
+type File struct {
+	Country    string                  `yaml:"country"`
+	Locale     string                  `yaml:"locale"`
+	Language   string                  `yaml:"language"`
+	Time       string                  `yaml:"time"`
+	CleanDays  []string                `yaml:"clean_days"`
+	Woo        wooConfig               `yaml:"woocommerce"`
+	TD         tdConfig                `yaml:"tradedoubler"`
+	Dynamo     dynamoConfig            `yaml:"dynamodb"`
+	GSheet     map[string]gsheetConfig `yaml:"gsheet"`
+	Email      emailConfig             `yaml:"email"`
+	FTP        ftpConfig               `yaml:"ftp"`
+	Awin       awinConfig
+	Adtraction adtractionConfig  `yaml:"adtraction"`
+	CJ         cjConfig          `yaml:"cj"`
+	Control    controlConfig     `yaml:"control"`
+	Metrics    metricsConfig     `yaml:"metrics"`
+	Notify     Notifications     `yaml:"notifications"`
+	Feeds      []string          `yaml:"feeds"` // enabled feeds, tradedoubler and awin by default
+	Files      []filefeed.Source `yaml:"files"` // generic merchant feeds, enabled by their name in feeds
+	Health     Health            `yaml:"health"`
+	Catalog    Catalog           `yaml:"catalog"`
+	Ranking    ranking.Model     `yaml:"ranking"`  // the default formula if empty
+	Shipping   shipping.Rules    `yaml:"shipping"` // extended by the shipping sheet if it is set up
+	google     googleConfig
+	sources    map[string]string
+}
 
-	return cfg, nil
+// New returns a pointer to a config object for the woocommerce backend
+func New(filePath string) (cfg *File, err error) {
+	return Load(filePath, "woocommerce")
 }
 
 // NewVSF returns a pointer to a config object with fewer environment variables
 func NewVSF(filePath string) (cfg *File, err error) {
-	cfg = new(File)
-
-	yamlFile, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return cfg, err
-	}
-
-	err = yaml.Unmarshal(yamlFile, &cfg)
-	if err != nil {
-		return cfg, err
-	}
-
-	envs, err := getEnvs(
-		[]string{
-			"TD_TOKEN_" + cfg.TD.Website.Name,
-			"DYNAMO_ID",
-			"DYNAMO_SECRET",
-			"AWIN_TOKEN",
-			"AWIN_FEED_TOKEN",
-		},
-	)
-	if err != nil {
-		return cfg, err
-	}
-
-	cfg.TD.Website.Token = envs["TD_TOKEN_"+cfg.TD.Website.Name]
-
-	cfg.Awin.apiToken = envs["AWIN_TOKEN"]
-	cfg.Awin.feedToken = envs["AWIN_FEED_TOKEN"]
-
-	cfg.Dynamo.ID = envs["DYNAMO_ID"]
-	cfg.Dynamo.secret = envs["DYNAMO_SECRET"]
-
-	return cfg, nil
+	return Load(filePath, "vsf-dump")
 }
 
 // SetHost let's you override the host from the config file
@@ -182,7 +173,7 @@ func (cfg *File) SetHost(newHost string) {
 
 // GetEmail returns password for the notification email address
 func (cfg *File) GetEmail() (name string, server string, pass string) {
-	return cfg.email.Name, cfg.email.Server, cfg.email.password
+	return cfg.Email.Name, cfg.Email.Server, cfg.Email.password
 }
 
 // GetLocale returns the locale set in the config file -error if not set
@@ -196,59 +187,36 @@ func (cfg *File) GetLocale() (country, locale, language string, err error) {
 	return cfg.Country, cfg.Locale, cfg.Language, nil
 }
 
-// GetCategoryMaps return the mapping table between different category names and WC ids,
-// plus a list oft the names themselves
-func (cfg *File) GetCategoryMaps() (catMap map[string]map[string][]*int32, CatNameMap map[string][]*string, err error) {
-	catMap = map[string]map[string][]*int32{
-		"m": make(map[string][]*int32),
-		"w": make(map[string][]*int32),
-		"u": make(map[string][]*int32),
-	}
-
+// GetCategoryNames returns the list of category names products are sorted into,
+// the WC ids are synchronized by the woocommerce package itself
+func (cfg *File) GetCategoryNames() (CatNameMap map[string][]*string, err error) {
 	sheet, datarange, err := cfg.GetGSheet("categories")
+	if err != nil {
+		return CatNameMap, err
+	}
 
-	data, err := googlesheets.LoadFromGSheet(sheet, datarange)
+	data, err := googlesheets.LoadWithCredentials(cfg.googleCredentials(), sheet, datarange)
 	if err != nil {
-		return catMap, CatNameMap, err
+		return CatNameMap, err
 	}
 
-	var (
-		name, gender string
-		id64         int64
-	)
+	var exist bool
+	CatNameMap = make(map[string][]*string)
 	for _, row := range data {
-		if len(row) < 3 {
+		if len(row) < 1 {
 			continue
 		}
-		name = strings.ToLower(row[0].(string))
-		id64, err = strconv.ParseInt(row[1].(string), 10, 32)
-		id := int32(id64)
-
-		if err != nil {
-			return catMap, CatNameMap, fmt.Errorf("Unable to parse config file - %v", row)
-		}
-		if row[2] != nil {
-			gender = row[2].(string)
-		} else {
-			gender = "u"
+		name := strings.ToLower(strings.TrimSpace(row[0].(string)))
+		if name == "" {
+			continue
 		}
-
-		catMap[gender][name] = append(catMap[gender][name], &id)
-	}
-
-	var exist bool
-	CatNameMap = make(map[string][]*string)
-	for gender := range catMap {
-		for key := range catMap[gender] {
-			_, exist = CatNameMap[key]
-			if !exist {
-				k := key
-				CatNameMap[k] = []*string{&k}
-			}
+		_, exist = CatNameMap[name]
+		if !exist {
+			CatNameMap[name] = []*string{&name}
 		}
 	}
 
-	return catMap, CatNameMap, nil
+	return CatNameMap, nil
 }
 
 //GetMapping return the mapping table for color simplifications
@@ -258,7 +226,7 @@ func (cfg *File) GetMapping(name string) (mapping map[string][]*string, err erro
 		return mapping, err
 	}
 
-	data, err := googlesheets.LoadFromGSheet(sheet, datarange)
+	data, err := googlesheets.LoadWithCredentials(cfg.googleCredentials(), sheet, datarange)
 	if err != nil {
 		return mapping, err
 	}
@@ -302,11 +270,86 @@ func (cfg *File) GetAwin() (apiToken, feedToken string, err error) {
 	return cfg.Awin.apiToken, cfg.Awin.feedToken, nil
 }
 
+// GetAwinLookback returns the days of Awin transactions that rank the products
+func (cfg *File) GetAwinLookback() int {
+	return cfg.Awin.LookbackDays
+}
+
+// GetAwinFilter returns the rules the Awin programmes must pass for their products to be included
+func (cfg *File) GetAwinFilter() awinclient.ProgrammeFilter {
+	return cfg.Awin.Programmes
+}
+
+// GetAdtraction returns the Adtraction token and channel id
+func (cfg *File) GetAdtraction() (token string, channelID uint64, err error) {
+	if cfg.Adtraction.token == "" || cfg.Adtraction.ChannelID <= 0 {
+		return token, channelID, fmt.Errorf("Couldn't find Adtraction token and channel")
+	}
+	return cfg.Adtraction.token, uint64(cfg.Adtraction.ChannelID), nil
+}
+
+// GetCJ returns the CJ token, company id and website id
+func (cfg *File) GetCJ() (token, companyID, websiteID string, err error) {
+	if cfg.CJ.token == "" || cfg.CJ.CompanyID == "" || cfg.CJ.WebsiteID == "" {
+		return token, companyID, websiteID, fmt.Errorf("Couldn't find CJ token, company and website")
+	}
+	return cfg.CJ.token, cfg.CJ.CompanyID, cfg.CJ.WebsiteID, nil
+}
+
 // GetWoo returns domain, key, secret, and error for a WooCommerce page
 func (cfg *File) GetWoo() (string, string, string, error) {
 	return cfg.Woo.Domain, cfg.Woo.key, cfg.Woo.secret, nil
 }
 
+// GetDeletionGuard returns the limits for product deletions in WooCommerce, zero values mean the defaults apply
+func (cfg *File) GetDeletionGuard() (maxDeleteShare, maxDropShare float64, softDeleteRuns int) {
+	return cfg.Woo.Deletion.MaxDeleteShare, cfg.Woo.Deletion.MaxDropShare, cfg.Woo.Deletion.SoftDeleteRuns
+}
+
+// GetProductLifecycle returns after how many days products missing from the feeds are hidden and deleted, zero values mean the defaults apply
+func (cfg *File) GetProductLifecycle() (hideAfterDays, deleteAfterDays int) {
+	return cfg.Woo.Deletion.HideAfterDays, cfg.Woo.Deletion.DeleteAfterDays
+}
+
+// GetSchedule returns the daily run time (HH:MM) and the weekdays on which image assets are purged
+func (cfg *File) GetSchedule() (runAt string, cleanDays []string, err error) {
+	if cfg.Time == "" {
+		return runAt, cleanDays, fmt.Errorf("No run time configured")
+	}
+	return cfg.Time, cfg.CleanDays, nil
+}
+
+// GetControlPlane returns the listen address and token of the HTTP control plane, an empty address disables it
+func (cfg *File) GetControlPlane() (addr, token string, err error) {
+	if cfg.Control.Addr == "" {
+		return addr, token, nil
+	}
+	if cfg.Control.token == "" {
+		return addr, token, fmt.Errorf("Couldn't find env variable: CONTROL_TOKEN")
+	}
+	return cfg.Control.Addr, cfg.Control.token, nil
+}
+
+// GetPushGateway returns the URL of the Prometheus push gateway for one-off runs, empty if not configured
+func (cfg *File) GetPushGateway() string {
+	return cfg.Metrics.PushGateway
+}
+
+// GetNotifications returns the targets and rules for run summaries
+func (cfg *File) GetNotifications() Notifications {
+	return cfg.Notify
+}
+
+// GetHealth returns the action and thresholds of the feed health checks
+func (cfg *File) GetHealth() Health {
+	return cfg.Health
+}
+
+// GetCatalog returns the formats, description and upload folder of the product catalog
+func (cfg *File) GetCatalog() Catalog {
+	return cfg.Catalog
+}
+
 // GetDynamo returns ID, Secret, Token, ProductTable, and error
 func (cfg *File) GetDynamo() (id, secret, productTable string, err error) {
 	if collection.AnyEmpty(
@@ -332,19 +375,61 @@ func (cfg *File) GetGSheet(name string) (id string, cells string, err error) {
 
 // GetFTP returns host, port, username, password, and error
 func (cfg *File) GetFTP() (string, int, string, string, error) {
-	if cfg.ftp.host == "" {
-		return cfg.ftp.host, cfg.ftp.port, cfg.ftp.username, cfg.ftp.password, errors.New("Couldn't load FTP config")
+	if cfg.FTP.Host == "" {
+		return cfg.FTP.Host, cfg.FTP.Port, cfg.FTP.User, cfg.FTP.password, errors.New("Couldn't load FTP config")
+	}
+	return cfg.FTP.Host, cfg.FTP.Port, cfg.FTP.User, cfg.FTP.password, nil
+}
+
+func (cfg *File) googleCredentials() googlesheets.Credentials {
+	return googlesheets.Credentials{
+		Client: []byte(cfg.google.credentials),
+		Token:  []byte(cfg.google.token),
+	}
+}
+
+// GetFeeds returns the names of the enabled feeds
+func (cfg *File) GetFeeds() []string {
+	return cfg.Feeds
+}
+
+// GetRanking returns the model the products are ranked by
+func (cfg *File) GetRanking() ranking.Model {
+	if cfg.Ranking.IsZero() {
+		return ranking.Default()
+	}
+	return cfg.Ranking
+}
+
+// GetShipping returns the shipping rules of the config followed by the ones of the optional shipping sheet,
+// the rules of the config are returned along with the error if the sheet fails
+func (cfg *File) GetShipping() (rules shipping.Rules, err error) {
+	rules = append(rules, cfg.Shipping...)
+	if _, exists := cfg.GSheet["shipping"]; !exists {
+		return rules, nil
+	}
+
+	sheet, datarange, err := cfg.GetGSheet("shipping")
+	if err != nil {
+		return rules, err
+	}
+	data, err := googlesheets.LoadWithCredentials(cfg.googleCredentials(), sheet, datarange)
+	if err != nil {
+		return rules, fmt.Errorf("Load Shipping Sheet - %v", err)
+	}
+	sheetRules, err := shipping.FromSheet(data)
+	if err != nil {
+		return rules, fmt.Errorf("Shipping Sheet - %v", err)
 	}
-	return cfg.ftp.host, cfg.ftp.port, cfg.ftp.username, cfg.ftp.password, nil
+	return append(rules, sheetRules...), nil
 }
 
-func getEnvs(names []string) (map[string]string, error) {
-	variables := make(map[string]string, len(names))
-	for _, n := range names {
-		variables[n] = os.Getenv(n)
-		if variables[n] == "" {
-			return variables, fmt.Errorf("Couldn't find env variable: %s", n)
+// GetFile returns the generic feed with the name
+func (cfg *File) GetFile(name string) (source filefeed.Source, err error) {
+	for i := range cfg.Files {
+		if cfg.Files[i].Name == name {
+			return cfg.Files[i], nil
 		}
 	}
-	return variables, nil
+	return source, fmt.Errorf("No file feed named %s", name)
 }
@@ -4,6 +4,10 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
@@ -36,7 +40,7 @@ func TestConfig(t *testing.T) {
 		t.Skip("skipping gsheet testing in short mode")
 	}
 
-	_, _, err = cfg.GetCategoryMaps()
+	_, err = cfg.GetCategoryNames()
 	if err != nil {
 		t.Fatalf("Failed to load category config from gsheets")
 	}
@@ -70,3 +74,88 @@ func TestConfig(t *testing.T) {
 		}
 	}
 }
+
+const testYAML = `
+country: "SE"
+locale: "sv_se"
+language: "sv"
+time: "25:00"
+feeds:
+    - awin
+gsheet:
+    categories: {id: "abc", range: "categories!A2:C"}
+    colors: {id: "abc", range: "colors!A2:C"}
+    sizes: {id: "abc", range: "sizes!A2:C"}
+    patterns: {id: "abc", range: "patterns!A2:C"}
+    genders: {id: "abc", range: "genders!A2:C"}
+notifications:
+    webhook:
+        when: [sometimes]
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(path, []byte(testYAML), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string]string{
+		"AWIN_TOKEN":      "api",
+		"AWIN_FEED_TOKEN": "feed",
+		"GFY_COUNTRY":     "DK",
+		"WOO_KEY":         "",
+		"GFY_TIME":        "",
+	} {
+		old, set := os.LookupEnv(name)
+		os.Setenv(name, value)
+		if set {
+			defer os.Setenv(name, old)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+
+	_, err = Load(path, "vsf-dump")
+	verr, ok := err.(ValidationError)
+	if !ok || len(verr.Problems) != 2 {
+		t.Fatalf("Expected a bad time and an unknown condition - %v", err)
+	}
+
+	os.Setenv("GFY_TIME", "03:30")
+	os.Setenv("NOTIFY_WEBHOOK_WHEN", "failure,drop")
+	defer os.Unsetenv("NOTIFY_WEBHOOK_WHEN")
+
+	cfg, err := Load(path, "vsf-dump")
+	if err != nil {
+		t.Fatalf("Load - %v", err)
+	}
+	if cfg.Country != "DK" || cfg.Time != "03:30" || len(cfg.Notify.Webhook.When) != 2 {
+		t.Fatalf("Environment not applied - %+v", cfg)
+	}
+	apiToken, _, err := cfg.GetAwin()
+	if err != nil || apiToken != "api" {
+		t.Fatalf("Wrong Awin token %q - %v", apiToken, err)
+	}
+	described := strings.Join(cfg.Describe(), "\n")
+	for _, line := range []string{
+		"country = DK (env GFY_COUNTRY)",
+		"locale = sv_se (yaml)",
+		"awin.api_token = *** (secret AWIN_TOKEN)",
+		"woocommerce.key =  (unset)",
+	} {
+		if !strings.Contains(described, line) {
+			t.Fatalf("Missing %q in\n%s", line, described)
+		}
+	}
+
+	_, err = Load(path, "woocommerce")
+	if err == nil || !strings.Contains(err.Error(), "woocommerce.key is required by the woocommerce backend, set WOO_KEY") {
+		t.Fatalf("Expected missing WooCommerce key - %v", err)
+	}
+}
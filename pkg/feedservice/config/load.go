@@ -0,0 +1,373 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	awinclient "stillgrove.com/gofeedyourself/pkg/awin/client"
+	"stillgrove.com/gofeedyourself/pkg/catalog"
+	"stillgrove.com/gofeedyourself/pkg/health"
+	"stillgrove.com/gofeedyourself/pkg/notify"
+	"stillgrove.com/gofeedyourself/pkg/scheduler"
+	"stillgrove.com/gofeedyourself/pkg/secrets"
+)
+
+// Sources of a setting
+const (
+	SourceYAML    = "yaml"
+	SourceEnv     = "env"
+	SourceSecret  = "secret"
+	SourceDefault = "default"
+)
+
+// ValidationError lists everything that is wrong with a config
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("Invalid config %s:\n - %s", e.Path, strings.Join(e.Problems, "\n - "))
+}
+
+// Load reads the config with the default secret providers, see LoadWith
+func Load(filePath, backend string) (cfg *File, err error) {
+	var problems []string
+	provider, err := secrets.Default()
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("Secrets - %v", err))
+	}
+
+	cfg, err = LoadWith(filePath, backend, provider)
+	if verr, ok := err.(ValidationError); ok {
+		verr.Problems = append(problems, verr.Problems...)
+		return cfg, verr
+	}
+	if err == nil && len(problems) > 0 {
+		return cfg, ValidationError{
+			Path:     filePath,
+			Problems: problems,
+		}
+	}
+	return cfg, err
+}
+
+// LoadWith reads the yaml file, overlays the environment and the secrets from provider,
+// applies the defaults, and validates the result for the backend and the enabled feeds
+func LoadWith(filePath, backend string, provider secrets.SecretProvider) (cfg *File, err error) {
+	cfg = &File{
+		sources: make(map[string]string),
+	}
+
+	yamlFile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return cfg, err
+	}
+
+	err = yaml.Unmarshal(yamlFile, cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("Parse %s - %v", filePath, err)
+	}
+
+	problems := cfg.overlay(provider)
+	problems = append(problems, cfg.Validate(backend)...)
+	if len(problems) > 0 {
+		return cfg, ValidationError{
+			Path:     filePath,
+			Problems: problems,
+		}
+	}
+
+	return cfg, nil
+}
+
+// overlay sets every field from its environment variable, secrets from the provider,
+// or from its default, the yaml is the fallback
+func (cfg *File) overlay(provider secrets.SecretProvider) (problems []string) {
+	for _, f := range cfg.fields() {
+		if !isZero(f.value) {
+			cfg.sources[f.Path] = SourceYAML
+		}
+	}
+
+	// the TD token is named after the website, which may come from the environment itself
+	for pass := 0; pass < 2; pass++ {
+		for _, f := range cfg.fields() {
+			source := SourceEnv
+			v := os.Getenv(f.Env)
+			if f.Secret {
+				var err error
+				source = SourceSecret
+				v, err = provider.Secret(f.Env)
+				if err != nil && err != secrets.ErrNotFound {
+					problems = append(problems, fmt.Sprintf("%s: %s - %v", f.Env, f.Path, err))
+					continue
+				}
+			}
+			if v == "" {
+				continue
+			}
+			err := set(f.value, v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s - %v", f.Env, f.Path, err))
+				continue
+			}
+			cfg.sources[f.Path] = source + " " + f.Env
+		}
+	}
+
+	for _, f := range cfg.fields() {
+		if f.Default == "" || !isZero(f.value) {
+			continue
+		}
+		set(f.value, f.Default)
+		cfg.sources[f.Path] = SourceDefault
+	}
+
+	return problems
+}
+
+// Validate returns the problems of the config for the backend and the enabled feeds
+func (cfg *File) Validate(backend string) (problems []string) {
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !contains(Backends, backend) {
+		add("Unknown backend %q, expected one of %s", backend, strings.Join(Backends, ", "))
+	}
+	known := append([]string{}, FeedNames...)
+	for i, source := range cfg.Files {
+		if contains(known, source.Name) {
+			add("files[%d]: the name %q is already taken", i, source.Name)
+		}
+		for _, problem := range source.Check() {
+			add("files[%d]: %s", i, problem)
+		}
+		known = append(known, source.Name)
+	}
+	for _, name := range cfg.Feeds {
+		if !contains(known, name) {
+			add("feeds: unknown feed %q, expected one of %s", name, strings.Join(known, ", "))
+		}
+	}
+
+	byPath := make(map[string]field)
+	for _, f := range cfg.fields() {
+		byPath[f.Path] = f
+	}
+	require := func(by string, paths ...string) {
+		for _, path := range paths {
+			f := byPath[path]
+			if !isZero(f.value) {
+				continue
+			}
+			if f.Secret {
+				add("%s is required by %s, set %s", path, by, f.Env)
+				continue
+			}
+			add("%s is required by %s, set it in the yaml or via %s", path, by, f.Env)
+		}
+	}
+
+	require("every run", requirements[""]...)
+	require("the "+backend+" backend", requirements[backend]...)
+	for _, name := range cfg.Feeds {
+		require("the "+name+" feed", requirements[name]...)
+	}
+	for _, name := range requiredSheets {
+		if cfg.GSheet[name].ID == "" || cfg.GSheet[name].CellRange == "" {
+			add("gsheet.%s needs an id and a range for the mapping tables", name)
+		}
+	}
+	if len(cfg.Notify.Email.Recipients) > 0 {
+		require("email notifications", "email.name", "email.server", "email.password")
+	}
+	if cfg.Control.Addr != "" {
+		require("the control plane", "control.token")
+	}
+	if backend == "catalog" && cfg.Catalog.UploadDir != "" {
+		require("the catalog upload", "ftp.host", "ftp.user", "ftp.password", "ftp.port")
+	}
+
+	if cfg.Time != "" {
+		_, err := scheduler.Parse(cfg.Time, cfg.CleanDays)
+		if err != nil {
+			add("time/clean_days: %v", err)
+		}
+	}
+	for path, share := range map[string]float64{
+		"woocommerce.deletion.max_delete_share": cfg.Woo.Deletion.MaxDeleteShare,
+		"woocommerce.deletion.max_drop_share":   cfg.Woo.Deletion.MaxDropShare,
+		"notifications.max_drop_share":          cfg.Notify.MaxDropShare,
+		"health.max_in_stock_shift":             cfg.Health.MaxInStockShift,
+		"health.max_count_drop":                 cfg.Health.MaxCountDrop,
+		"health.max_category_drop":              cfg.Health.MaxCategoryDrop,
+		"health.max_mapping_drop":               cfg.Health.MaxMappingDrop,
+		"awin.programmes.min_approval_share":    cfg.Awin.Programmes.MinApprovalShare,
+	} {
+		if share < 0 || share > 1 {
+			add("%s must be a share between 0 and 1, not %g", path, share)
+		}
+	}
+	if cfg.Awin.LookbackDays < 0 || cfg.Awin.LookbackDays > awinclient.MaxLookbackDays {
+		add("awin.lookback_days must be between 1 and %d, not %d", awinclient.MaxLookbackDays, cfg.Awin.LookbackDays)
+	}
+	filter := cfg.Awin.Programmes
+	if filter.MinEPC < 0 || filter.MaxValidationDays < 0 || filter.MinPercentage < 0 || filter.MinAmount < 0 {
+		add("awin.programmes: the minimums and max_validation_days must not be negative")
+	}
+	if cfg.Health.MaxPriceShift < 0 {
+		add("health.max_price_shift must not be negative")
+	}
+	if cfg.Health.Action != health.ActionFlag && cfg.Health.Action != health.ActionQuarantine {
+		add("health.action must be %s or %s, not %q", health.ActionFlag, health.ActionQuarantine, cfg.Health.Action)
+	}
+	deletion := cfg.Woo.Deletion
+	if deletion.SoftDeleteRuns < 0 || deletion.HideAfterDays < 0 || deletion.DeleteAfterDays < 0 {
+		add("woocommerce.deletion: runs and days must not be negative")
+	}
+	if deletion.HideAfterDays > 0 && deletion.DeleteAfterDays > 0 && deletion.DeleteAfterDays < deletion.HideAfterDays {
+		add("woocommerce.deletion.delete_after_days must not be shorter than hide_after_days")
+	}
+	for _, format := range cfg.Catalog.Formats {
+		if !contains(catalog.Formats, format) {
+			add("catalog.formats: unknown format %q, expected one of %s", format, strings.Join(catalog.Formats, ", "))
+		}
+	}
+	if _, err := url.ParseQuery(cfg.Catalog.Tracking); err != nil {
+		add("catalog.tracking must be query parameters like utm_source={channel} - %v", err)
+	}
+	for _, problem := range cfg.TD.Website.Check() {
+		add("tradedoubler.website.%s", problem)
+	}
+	for _, problem := range cfg.Ranking.Check() {
+		add("ranking.%s", problem)
+	}
+	for _, problem := range cfg.Shipping.Check() {
+		add("shipping.%s", problem)
+	}
+	if sheet, exists := cfg.GSheet["shipping"]; exists && (sheet.ID == "" || sheet.CellRange == "") {
+		add("gsheet.shipping needs an id and a range for the shipping rules")
+	}
+	if cfg.FTP.Port < 0 || cfg.FTP.Port > 65535 {
+		add("ftp.port %d is out of range", cfg.FTP.Port)
+	}
+	for path, u := range map[string]string{
+		"woocommerce.domain":        cfg.Woo.Domain,
+		"metrics.pushgateway":       cfg.Metrics.PushGateway,
+		"notifications.webhook.url": cfg.Notify.Webhook.URL,
+		"catalog.link":              cfg.Catalog.Link,
+	} {
+		if u == "" {
+			continue
+		}
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add("%s must be an http(s) URL, not %q", path, u)
+		}
+	}
+	for path, when := range map[string][]string{
+		"notifications.email.when":   cfg.Notify.Email.When,
+		"notifications.webhook.when": cfg.Notify.Webhook.When,
+	} {
+		for _, condition := range when {
+			if !notify.Valid(condition) {
+				add("%s: unknown condition %q", path, condition)
+			}
+		}
+	}
+
+	return problems
+}
+
+// Describe lists every field with its value and where it came from, secrets are masked
+func (cfg *File) Describe() []string {
+	var lines []string
+	for _, f := range cfg.fields() {
+		value := format(f.value)
+		if f.Secret && value != "" {
+			value = "***"
+		}
+		source := cfg.sources[f.Path]
+		if source == "" {
+			source = "unset"
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s (%s)", f.Path, value, source))
+	}
+	return lines
+}
+
+func set(value interface{}, s string) error {
+	switch v := value.(type) {
+	case *string:
+		*v = s
+	case *int:
+		i, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("expected a whole number")
+		}
+		*v = i
+	case *float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		*v = f
+	case *[]string:
+		*v = nil
+		for _, item := range strings.Split(s, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				*v = append(*v, item)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %T", value)
+	}
+	return nil
+}
+
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case *string:
+		return *v == ""
+	case *int:
+		return *v == 0
+	case *float64:
+		return *v == 0
+	case *[]string:
+		return len(*v) == 0
+	}
+	return true
+}
+
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case *string:
+		return *v
+	case *int:
+		return strconv.Itoa(*v)
+	case *float64:
+		return strconv.FormatFloat(*v, 'g', -1, 64)
+	case *[]string:
+		return strings.Join(*v, ",")
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
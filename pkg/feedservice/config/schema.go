@@ -0,0 +1,170 @@
+package config
+
+import (
+	"stillgrove.com/gofeedyourself/pkg/catalog"
+	"stillgrove.com/gofeedyourself/pkg/health"
+)
+
+// Backends lists the backends a config can be loaded for
+var Backends = []string{
+	"woocommerce",
+	"vsf-dump",
+	"csv",
+	"catalog",
+}
+
+// FeedNames lists the feeds that can be enabled with the feeds key, tradedoubler and awin by default
+var FeedNames = []string{
+	"tradedoubler",
+	"awin",
+	"adtraction",
+	"cj",
+}
+
+// requiredSheets are the Google Sheets every run loads its mapping tables from
+var requiredSheets = []string{
+	"categories",
+	"colors",
+	"patterns",
+	"sizes",
+	"genders",
+}
+
+// requirements lists the fields by path that a backend or an enabled feed needs, "" applies to every run
+var requirements = map[string][]string{
+	"": {
+		"country",
+		"locale",
+		"language",
+	},
+	"woocommerce": {
+		"woocommerce.domain",
+		"woocommerce.key",
+		"woocommerce.secret",
+		"ftp.host",
+		"ftp.user",
+		"ftp.password",
+		"ftp.port",
+		"time",
+	},
+	"catalog": {
+		"catalog.formats",
+		"catalog.title",
+		"catalog.link",
+	},
+	"tradedoubler": {
+		"tradedoubler.conversionTable",
+		"tradedoubler.website.name",
+		"tradedoubler.website.token",
+		"dynamodb.id",
+		"dynamodb.secret",
+		"dynamodb.productTable",
+	},
+	"awin": {
+		"awin.api_token",
+		"awin.feed_token",
+	},
+	"adtraction": {
+		"adtraction.token",
+		"adtraction.channel_id",
+	},
+	"cj": {
+		"cj.token",
+		"cj.company_id",
+		"cj.website_id",
+	},
+}
+
+// field declares where a setting comes from. The environment variable overlays the yaml,
+// secrets are resolved by the SecretProvider under that name and masked when the config is described.
+// The gsheet tables are only read from the yaml
+type field struct {
+	Path    string
+	Env     string
+	Secret  bool
+	Default string
+	value   interface{} // *string, *int, *float64, or *[]string
+}
+
+// fields returns every overlayable setting of cfg, the TD token variable is named after the website
+func (cfg *File) fields() []field {
+	return []field{
+		{Path: "country", Env: "GFY_COUNTRY", value: &cfg.Country},
+		{Path: "locale", Env: "GFY_LOCALE", value: &cfg.Locale},
+		{Path: "language", Env: "GFY_LANGUAGE", value: &cfg.Language},
+		{Path: "time", Env: "GFY_TIME", Default: "03:00", value: &cfg.Time},
+		{Path: "clean_days", Env: "GFY_CLEAN_DAYS", value: &cfg.CleanDays},
+		{Path: "feeds", Env: "GFY_FEEDS", Default: "tradedoubler,awin", value: &cfg.Feeds},
+
+		{Path: "woocommerce.domain", Env: "WOO_DOMAIN", value: &cfg.Woo.Domain},
+		{Path: "woocommerce.key", Env: "WOO_KEY", Secret: true, value: &cfg.Woo.key},
+		{Path: "woocommerce.secret", Env: "WOO_SECRET", Secret: true, value: &cfg.Woo.secret},
+		{Path: "woocommerce.deletion.max_delete_share", Env: "WOO_MAX_DELETE_SHARE", value: &cfg.Woo.Deletion.MaxDeleteShare},
+		{Path: "woocommerce.deletion.max_drop_share", Env: "WOO_MAX_DROP_SHARE", value: &cfg.Woo.Deletion.MaxDropShare},
+		{Path: "woocommerce.deletion.soft_delete_runs", Env: "WOO_SOFT_DELETE_RUNS", value: &cfg.Woo.Deletion.SoftDeleteRuns},
+		{Path: "woocommerce.deletion.hide_after_days", Env: "WOO_HIDE_AFTER_DAYS", value: &cfg.Woo.Deletion.HideAfterDays},
+		{Path: "woocommerce.deletion.delete_after_days", Env: "WOO_DELETE_AFTER_DAYS", value: &cfg.Woo.Deletion.DeleteAfterDays},
+
+		{Path: "tradedoubler.conversionTable", Env: "TD_CONVERSION_TABLE", value: &cfg.TD.ConversionTable},
+		{Path: "tradedoubler.website.name", Env: "TD_WEBSITE", value: &cfg.TD.Website.Name},
+		{Path: "tradedoubler.website.token", Env: "TD_TOKEN_" + cfg.TD.Website.Name, Secret: true, value: &cfg.TD.Website.Token},
+
+		{Path: "awin.api_token", Env: "AWIN_TOKEN", Secret: true, value: &cfg.Awin.apiToken},
+		{Path: "awin.feed_token", Env: "AWIN_FEED_TOKEN", Secret: true, value: &cfg.Awin.feedToken},
+		{Path: "awin.lookback_days", Env: "AWIN_LOOKBACK_DAYS", Default: "7", value: &cfg.Awin.LookbackDays},
+		{Path: "awin.programmes.min_approval_share", Env: "AWIN_MIN_APPROVAL_SHARE", value: &cfg.Awin.Programmes.MinApprovalShare},
+		{Path: "awin.programmes.min_epc", Env: "AWIN_MIN_EPC", value: &cfg.Awin.Programmes.MinEPC},
+		{Path: "awin.programmes.max_validation_days", Env: "AWIN_MAX_VALIDATION_DAYS", value: &cfg.Awin.Programmes.MaxValidationDays},
+		{Path: "awin.programmes.min_commission_percentage", Env: "AWIN_MIN_COMMISSION_PERCENTAGE", value: &cfg.Awin.Programmes.MinPercentage},
+		{Path: "awin.programmes.min_commission_amount", Env: "AWIN_MIN_COMMISSION_AMOUNT", value: &cfg.Awin.Programmes.MinAmount},
+		{Path: "awin.programmes.exclude_commission_groups", Env: "AWIN_EXCLUDE_COMMISSION_GROUPS", value: &cfg.Awin.Programmes.ExcludeGroups},
+
+		{Path: "adtraction.token", Env: "ADTRACTION_TOKEN", Secret: true, value: &cfg.Adtraction.token},
+		{Path: "adtraction.channel_id", Env: "ADTRACTION_CHANNEL_ID", value: &cfg.Adtraction.ChannelID},
+
+		{Path: "cj.token", Env: "CJ_TOKEN", Secret: true, value: &cfg.CJ.token},
+		{Path: "cj.company_id", Env: "CJ_COMPANY_ID", value: &cfg.CJ.CompanyID},
+		{Path: "cj.website_id", Env: "CJ_WEBSITE_ID", value: &cfg.CJ.WebsiteID},
+
+		{Path: "dynamodb.id", Env: "DYNAMO_ID", Secret: true, value: &cfg.Dynamo.ID},
+		{Path: "dynamodb.secret", Env: "DYNAMO_SECRET", Secret: true, value: &cfg.Dynamo.secret},
+		{Path: "dynamodb.productTable", Env: "DYNAMO_PRODUCT_TABLE", value: &cfg.Dynamo.ProductTable},
+
+		{Path: "ftp.host", Env: "FTP_HOST", value: &cfg.FTP.Host},
+		{Path: "ftp.user", Env: "FTP_USER", value: &cfg.FTP.User},
+		{Path: "ftp.port", Env: "FTP_PORT", value: &cfg.FTP.Port},
+		{Path: "ftp.password", Env: "FTP_PASS", Secret: true, value: &cfg.FTP.password},
+
+		{Path: "email.name", Env: "EMAIL_NAME", value: &cfg.Email.Name},
+		{Path: "email.server", Env: "EMAIL_SERVER", value: &cfg.Email.Server},
+		{Path: "email.password", Env: "EMAIL_PW", Secret: true, value: &cfg.Email.password},
+
+		{Path: "google.credentials", Env: "GOOGLE_CREDENTIALS", Secret: true, value: &cfg.google.credentials},
+		{Path: "google.token", Env: "GOOGLE_TOKEN", Secret: true, value: &cfg.google.token},
+
+		{Path: "control.addr", Env: "CONTROL_ADDR", value: &cfg.Control.Addr},
+		{Path: "control.token", Env: "CONTROL_TOKEN", Secret: true, value: &cfg.Control.token},
+
+		{Path: "metrics.pushgateway", Env: "PUSHGATEWAY_URL", value: &cfg.Metrics.PushGateway},
+
+		{Path: "health.action", Env: "HEALTH_ACTION", Default: health.ActionFlag, value: &cfg.Health.Action},
+		{Path: "health.max_count_drop", Env: "HEALTH_MAX_COUNT_DROP", value: &cfg.Health.MaxCountDrop},
+		{Path: "health.max_in_stock_shift", Env: "HEALTH_MAX_IN_STOCK_SHIFT", value: &cfg.Health.MaxInStockShift},
+		{Path: "health.max_price_shift", Env: "HEALTH_MAX_PRICE_SHIFT", value: &cfg.Health.MaxPriceShift},
+		{Path: "health.max_category_drop", Env: "HEALTH_MAX_CATEGORY_DROP", value: &cfg.Health.MaxCategoryDrop},
+		{Path: "health.max_mapping_drop", Env: "HEALTH_MAX_MAPPING_DROP", value: &cfg.Health.MaxMappingDrop},
+
+		{Path: "catalog.formats", Env: "CATALOG_FORMATS", Default: catalog.FormatGoogleXML + "," + catalog.FormatFacebookCSV, value: &cfg.Catalog.Formats},
+		{Path: "catalog.title", Env: "CATALOG_TITLE", value: &cfg.Catalog.Title},
+		{Path: "catalog.link", Env: "CATALOG_LINK", value: &cfg.Catalog.Link},
+		{Path: "catalog.description", Env: "CATALOG_DESCRIPTION", value: &cfg.Catalog.Description},
+		{Path: "catalog.tracking", Env: "CATALOG_TRACKING", value: &cfg.Catalog.Tracking},
+		{Path: "catalog.upload_dir", Env: "CATALOG_UPLOAD_DIR", value: &cfg.Catalog.UploadDir},
+
+		{Path: "notifications.max_drop_share", Env: "NOTIFY_MAX_DROP_SHARE", value: &cfg.Notify.MaxDropShare},
+		{Path: "notifications.email.recipients", Env: "NOTIFY_EMAIL_RECIPIENTS", value: &cfg.Notify.Email.Recipients},
+		{Path: "notifications.email.when", Env: "NOTIFY_EMAIL_WHEN", value: &cfg.Notify.Email.When},
+		{Path: "notifications.webhook.url", Env: "NOTIFY_WEBHOOK_URL", value: &cfg.Notify.Webhook.URL},
+		{Path: "notifications.webhook.when", Env: "NOTIFY_WEBHOOK_WHEN", value: &cfg.Notify.Webhook.When},
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 
 	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/metrics"
 )
 
 const toMeg uint64 = 1048576
@@ -30,6 +32,13 @@ type PipelineErrors struct {
 	production   bool
 	maxMemoryUse *uint64
 	mem          runtime.MemStats
+	onCritical   func(error) // called with the pipeline mux held, right before the process exits
+	keepAlive    bool        // a critical error panics with a pipelineStop instead of exiting
+}
+
+// pipelineStop is the panic value of a critical error in a long-running service, see recoverRun
+type pipelineStop struct {
+	err error
 }
 
 func NewPE(mux *sync.Mutex, productionFlag bool) PipelineErrors {
@@ -40,6 +49,16 @@ func NewPE(mux *sync.Mutex, productionFlag bool) PipelineErrors {
 	}
 }
 
+// OnCritical registers a last action before a critical error stops the pipeline
+func (pe *PipelineErrors) OnCritical(f func(error)) {
+	pe.onCritical = f
+}
+
+// KeepAlive stops only the pipeline on a critical error, the process keeps running
+func (pe *PipelineErrors) KeepAlive() {
+	pe.keepAlive = true
+}
+
 func (pe *PipelineErrors) GetMaxMemory() uint64 {
 	return *pe.maxMemoryUse
 }
@@ -75,6 +94,19 @@ func (pe *PipelineErrors) Log(e error, stageName string) {
 		})
 	}
 
+	pe.Critical = true
+	if pe.onCritical != nil {
+		pe.onCritical(fmt.Errorf("%s - %v", stageName, e))
+	}
+
+	if pe.keepAlive {
+		log.WithFields(log.Fields{
+			"critical error": e,
+			"other errors":   pe.Errors,
+		}).Errorln("Pipeline Stopped")
+		panic(pipelineStop{err: fmt.Errorf("%s - %v", stageName, e)})
+	}
+
 	log.WithFields(log.Fields{
 		"critical error": e,
 		"other errors":   pe.Errors,
@@ -107,5 +139,6 @@ func memLog(message string, mem runtime.MemStats, maxMemory *uint64) {
 
 	if mem.Alloc/toMeg > *maxMemory {
 		*maxMemory = mem.Alloc / toMeg
+		metrics.PeakMemory.Set(float64(mem.Alloc))
 	}
 }
@@ -0,0 +1,200 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
+)
+
+var (
+	// FemaleTerms mark women's products in categories and descriptions
+	FemaleTerms = [...]string{
+		"women",
+		"woman",
+		"female",
+	}
+	// MaleTerms mark men's products in categories and descriptions
+	MaleTerms = [...]string{
+		"men",
+		"man",
+		"male",
+	}
+	// UnisexTerms mark unisex products in categories and descriptions
+	UnisexTerms = [...]string{
+		"unisex",
+	}
+)
+
+// SplitSizes splits comma separated size lists
+func SplitSizes(str ...string) (out []string) {
+	var (
+		tmp []string
+	)
+	for i := range str {
+		tmp = strings.Split(str[i], ",")
+		for j := range tmp {
+			out = append(
+				out,
+				strings.TrimSpace(tmp[j]),
+			)
+		}
+	}
+	return out
+}
+
+// MapAvailability returns "instock" or "out of stock" for the first stock indicator
+func MapAvailability(str ...string) string {
+	for i := range str {
+		switch str[i] {
+		case "1", "instock", "in_stock", "in stock", "true", "yes":
+			return "instock"
+		default:
+			return "out of stock"
+		}
+	}
+	return "out of stock"
+}
+
+// MapColors returns the color groups of the candidates, unmapped terms are kept as they are
+func MapColors(mapping *Mapping, str ...string) (colors []string) {
+	var (
+		substr []string
+		terms  []string
+	)
+
+	for s := range str {
+		substr = collection.SplitList(str[s])
+		for i := range substr {
+			terms = append(terms, collection.Sanitize(substr[i]))
+		}
+
+		if strings.ToLower(str[s]) == "no color" {
+			colors = append(colors, "multi")
+		}
+	}
+
+	for i := range terms {
+		replacements, matched := collection.StrictFindReplace2(terms[i], mapping.ColorMap)
+		metrics.MappingLookups.Inc("colors", metrics.Result(matched))
+		if !matched {
+			colors = append(
+				colors,
+				terms[i],
+			)
+		}
+		for j := range replacements {
+			colors = append(
+				colors,
+				replacements[j],
+			)
+		}
+	}
+
+	return collection.UniqueNames(colors)
+}
+
+// MapGender returns women, men or unisex for the first candidate that mentions one of them as a whole word,
+// female terms go first as most of them contain a male one
+func MapGender(str ...string) string {
+	var (
+		substr []string
+	)
+	for s := range str {
+		substr = collection.SplitList(str[s])
+		for i := range substr {
+			words := make(map[string]struct{})
+			for _, word := range strings.FieldsFunc(strings.ToLower(substr[i]), notLetter) {
+				words[word] = struct{}{}
+			}
+			if containsAny(words, FemaleTerms[:]) {
+				return "women"
+			}
+			if containsAny(words, MaleTerms[:]) {
+				return "men"
+			}
+			if containsAny(words, UnisexTerms[:]) {
+				return "unisex"
+			}
+		}
+	}
+
+	return ""
+}
+
+func notLetter(r rune) bool {
+	return !unicode.IsLetter(r)
+}
+
+func containsAny(words map[string]struct{}, terms []string) bool {
+	for i := range terms {
+		if _, exists := words[terms[i]]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// MapCategories looks the candidates up in the category names, provider names the source of the categories
+func MapCategories(mapping *Mapping, provider string, str ...string) (categories []ProviderCategory, err error) {
+	var (
+		substr []string
+		terms  []string
+		gender rune
+		exists bool
+	)
+
+	uniques := make(map[string]struct{})
+	for s := range str {
+		substr = collection.SplitList(str[s])
+		for i := range substr {
+			term := strings.ToLower(substr[i])
+			_, exists = uniques[term]
+			if exists {
+				continue
+			}
+			terms = append(terms, term)
+			uniques[term] = struct{}{}
+		}
+	}
+
+	switch MapGender(terms...) {
+	case "women":
+		gender = 'w'
+		break
+	case "unisex":
+		gender = 'u'
+		break
+	case "men":
+		gender = 'm'
+		break
+	default:
+		return categories, fmt.Errorf("Couldn't parse gender - %v", terms)
+	}
+
+	for i := range terms {
+		replacements, matched := collection.StrictFindReplace2(terms[i], mapping.CatNameMap)
+		if !matched {
+			continue
+		}
+		for j := range replacements {
+			categories = append(
+				categories,
+				ProviderCategory{
+					ProviderName: provider,
+					Name:         strings.TrimSpace(replacements[j]),
+					Gender:       gender,
+				},
+			)
+		}
+	}
+
+	metrics.MappingLookups.Inc("categories", metrics.Result(len(categories) > 0))
+	if len(categories) == 0 {
+		return categories, fmt.Errorf("No categories found for - %v", terms)
+	}
+
+	return categories, nil
+}
@@ -47,3 +47,24 @@ func TestQueue(t *testing.T) {
 		}
 	}
 }
+
+func TestMapGender(t *testing.T) {
+	for _, c := range []struct {
+		candidates []string
+		expected   string
+	}{
+		{[]string{"Women > Dresses"}, "women"},
+		{[]string{"Women's Shoes"}, "women"},
+		{[]string{"female"}, "women"},
+		{[]string{"Men > Shirts"}, "men"},
+		{[]string{"male"}, "men"},
+		{[]string{"unisex"}, "unisex"},
+		{[]string{"kids"}, ""},
+		{[]string{"Shirts", "Menswear", "Man"}, "men"},
+		{[]string{"", "Accessories"}, ""},
+	} {
+		if gender := MapGender(c.candidates...); gender != c.expected {
+			t.Fatalf("%v - expected %q, got %q", c.candidates, c.expected, gender)
+		}
+	}
+}
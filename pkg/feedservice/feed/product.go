@@ -30,6 +30,7 @@ type Retailer struct {
 	Availability string `json:"availability"`
 	DeliveryTime string `json:"deliveryTime"`
 	ShippingCost string `json:"shippingCost"`
+	FeedID       int32  `json:"feedId,omitempty"` // feed of the offer, for the shipping rules
 	IsCrawler    bool
 	Sizes        []string `json:"sizes,omitempty"`
 }
@@ -48,6 +49,13 @@ type ProviderCategory struct {
 	Gender             rune   `json:"gender"` // mandatory!
 }
 
+// Transaction is a lead or sale of the product reported by the network
+type Transaction struct {
+	Time       int32   `json:"time"` // unix time
+	Lead       bool    `json:"lead"`
+	Commission float32 `json:"commission"`
+}
+
 // Product is the common currency of entites loaded from the network feeds
 type Product struct {
 	Name             string     `json:"name"`
@@ -75,11 +83,14 @@ type Product struct {
 	Language        string   `json:"language"`
 	WebsiteFeatures int32    //`json:"website_features"`
 	ExpectedValue   float32
-	Commision7d     float32  //`json:"-"`
-	Leads7d         int32    //`json:"-"`
-	Conversions7d   int32    //`json:"-"`
-	FromFeeds       []int32  `json:"fromFeeds"`
-	FromPrograms    []string `json:"fromPrograms"`
+	EPC             float32       // earnings per click of the program
+	Updated         int32         `json:"updated,omitempty"` // unix time the network last changed the offer, 0 if unknown
+	Commision7d     float32       //`json:"-"`
+	Leads7d         int32         //`json:"-"`
+	Conversions7d   int32         //`json:"-"`
+	Transactions    []Transaction `json:"transactions,omitempty"` // the dated part of the 7d counts, for the time decay of the ranking
+	FromFeeds       []int32       `json:"fromFeeds"`
+	FromPrograms    []string      `json:"fromPrograms"`
 	Categories      []int32
 	//WCAttributes    []attribute
 	//WCCategories       []int32
@@ -344,6 +355,13 @@ func (p *Product) MergeWith(newProduct *Product) error {
 	p.Leads7d += newProduct.Leads7d
 	p.Conversions7d += newProduct.Conversions7d
 	p.Commision7d += newProduct.Commision7d
+	p.Transactions = append(p.Transactions, newProduct.Transactions...)
+	if newProduct.EPC > p.EPC {
+		p.EPC = newProduct.EPC
+	}
+	if newProduct.Updated > p.Updated {
+		p.Updated = newProduct.Updated
+	}
 
 	for i := range newProduct.FromPrograms {
 		p.FromPrograms = append(p.FromPrograms, newProduct.FromPrograms[i])
@@ -360,23 +378,6 @@ func (p *Product) MergeWith(newProduct *Product) error {
 	return nil
 }
 
-// CalculateRanking is where we apply the formula to rank products
-func (p *Product) CalculateRanking() int32 {
-	w := struct {
-		leads       int32
-		conversions int32
-		features    int32
-	}{
-		leads:       2,
-		conversions: 5,
-		features:    10,
-	}
-
-	//commissionWeight := 20
-
-	return p.WebsiteFeatures*w.features + p.Leads7d*w.leads + p.Conversions7d*w.conversions //+ p.Commision7d*commissionWeight
-}
-
 // CalculateDiscounts looks updates the values for discounts based on current lowest and highest prices
 func (p *Product) CalculateDiscounts(binSize int) (err error) {
 	if binSize < 1 {
@@ -6,8 +6,11 @@ import (
 
 	"sync"
 	"sync/atomic"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/metrics"
 )
 
 const (
@@ -15,10 +18,18 @@ const (
 	MaxConcurrentRequests = 8
 )
 
+// Inspector looks at the products of a feed before they are merged, false drops them
+type Inspector func(feed string, products []Product) (keep bool)
+
+// Processor completes the products of a feed before they are inspected
+type Processor func(feed string, products []Product)
+
 //Queue allows to process multiple feeds at once
 type Queue struct {
 	queue          []Feed
 	productionFlag bool
+	inspect        Inspector
+	process        Processor
 }
 
 // NewQueueFromFeeds takes a slice of of the feed interfaces, returns pointer to Queue
@@ -44,6 +55,16 @@ func (q *Queue) AppendMany(f []Feed) {
 	}
 }
 
+// SetInspector registers a check for every downloaded feed
+func (q *Queue) SetInspector(inspect Inspector) {
+	q.inspect = inspect
+}
+
+// SetProcessor registers a step that completes the products of every downloaded feed
+func (q *Queue) SetProcessor(process Processor) {
+	q.process = process
+}
+
 // GetPM processes the queue of feeds and returns a deduplicated product map
 func (q *Queue) GetPM(strict bool) (productMap *ProductMap, err error) {
 	nsources := len(q.queue)
@@ -65,13 +86,24 @@ func (q *Queue) GetPM(strict bool) (productMap *ProductMap, err error) {
 			defer wg.Done()
 
 			for f := range input {
+				start := time.Now()
 				products, err := f.Get(q.productionFlag)
+				metrics.FeedDownloadSeconds.Set(time.Since(start).Seconds(), f.GetName())
 				if err != nil {
 					log.WithField("Error", err).Warningln("Failed to download feed from queue")
+					metrics.FeedErrors.Inc(f.GetName())
 					atomic.AddUint64(&errs, 1)
 					output <- []Product{}
 					continue
 				}
+				metrics.FeedProducts.Set(float64(len(products)), f.GetName())
+				if q.process != nil {
+					q.process(f.GetName(), products)
+				}
+				if q.inspect != nil && !q.inspect(f.GetName(), products) {
+					log.WithField("Feed", f.GetName()).Warnln("Dropped feed after inspection")
+					products = []Product{}
+				}
 				select {
 				case <-ctx.Done():
 					output <- []Product{}
@@ -0,0 +1,162 @@
+package feedservice
+
+import (
+	"fmt"
+
+	"stillgrove.com/gofeedyourself/pkg/adtraction"
+	awin "stillgrove.com/gofeedyourself/pkg/awin"
+	"stillgrove.com/gofeedyourself/pkg/cj"
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/filefeed"
+	td "stillgrove.com/gofeedyourself/pkg/tradedoubler"
+)
+
+// Feeds returns the configured feeds, limited to the ones selected with SetFeeds
+func (p *FeedService) Feeds() ([]feed.Feed, error) {
+	mappings, err := p.getMappings()
+	if err != nil {
+		return nil, fmt.Errorf("Load Mappings - %v", err)
+	}
+
+	feeds, err := p.newFeeds(mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectFeeds(feeds, p.options().onlyFeeds)
+}
+
+// newFeeds initializes the feeds enabled in the config with the given mapping tables
+func (p *FeedService) newFeeds(mappings *mappingSet) ([]feed.Feed, error) {
+	cc, loc, lang, err := p.cfg.GetLocale()
+	if err != nil {
+		return nil, fmt.Errorf("Load Country/Locale/Language from Config - %v", err)
+	}
+
+	locale, err := feed.NewLocale(cc, lang, loc)
+	if err != nil {
+		return nil, fmt.Errorf("Parse Locale from Config - %v", err)
+	}
+
+	var feeds []feed.Feed
+	for _, name := range p.cfg.GetFeeds() {
+		var f feed.Feed
+		switch name {
+		case "tradedoubler":
+			f, err = p.newTradedoubler(locale, lang, mappings)
+		case "awin":
+			f, err = p.newAwin(locale, mappings)
+		case "adtraction":
+			f, err = p.newAdtraction(locale, mappings)
+		case "cj":
+			f, err = p.newCJ(locale, mappings)
+		default:
+			f, err = p.newFile(locale, name, mappings)
+		}
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	if len(feeds) == 0 {
+		return nil, fmt.Errorf("No feeds enabled")
+	}
+
+	return feeds, nil
+}
+
+func (p *FeedService) newTradedoubler(locale *feed.Locale, lang string, mappings *mappingSet) (feed.Feed, error) {
+	convTable, website, err := p.cfg.GetTD()
+	if err != nil {
+		return nil, fmt.Errorf("Load TD config - %v", err)
+	}
+
+	dynamoID, dynamoSecret, _, err := p.cfg.GetDynamo()
+	if err != nil {
+		return nil, fmt.Errorf("Load Dynamo Config - %v", err)
+	}
+
+	maps := mappings.maps
+	tradedoubler, err := td.NewFeed(
+		locale,
+		website.Token,
+		dynamoID,
+		dynamoSecret,
+		convTable,
+		maps[0],
+		maps[1],
+		maps[2],
+		maps[3],
+		mappings.catNames,
+		lang,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Initialize Tradedoubler Connection - %v", err)
+	}
+	tradedoubler.SetSelection(website.Selection)
+	return tradedoubler, nil
+}
+
+func (p *FeedService) newAwin(locale *feed.Locale, mappings *mappingSet) (feed.Feed, error) {
+	awinAPIToken, awinFeedToken, err := p.cfg.GetAwin()
+	if err != nil {
+		return nil, fmt.Errorf("Load Awin config - %v", err)
+	}
+
+	aw, err := awin.NewAwin(
+		locale,
+		awinAPIToken,
+		awinFeedToken,
+		p.cfg.GetAwinLookback(),
+		mappings.mapping(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Initialize Awin Connection - %v", err)
+	}
+	aw.Client.SetFilter(p.cfg.GetAwinFilter())
+	return aw, nil
+}
+
+func (p *FeedService) newAdtraction(locale *feed.Locale, mappings *mappingSet) (feed.Feed, error) {
+	token, channelID, err := p.cfg.GetAdtraction()
+	if err != nil {
+		return nil, fmt.Errorf("Load Adtraction config - %v", err)
+	}
+
+	ad, err := adtraction.NewAdtraction(
+		locale,
+		token,
+		channelID,
+		mappings.mapping(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Initialize Adtraction Connection - %v", err)
+	}
+	return ad, nil
+}
+
+func (p *FeedService) newCJ(locale *feed.Locale, mappings *mappingSet) (feed.Feed, error) {
+	token, companyID, websiteID, err := p.cfg.GetCJ()
+	if err != nil {
+		return nil, fmt.Errorf("Load CJ config - %v", err)
+	}
+
+	f, err := cj.NewCJ(locale, token, companyID, websiteID, mappings.mapping())
+	if err != nil {
+		return nil, fmt.Errorf("Initialize CJ Connection - %v", err)
+	}
+	return f, nil
+}
+
+func (p *FeedService) newFile(locale *feed.Locale, name string, mappings *mappingSet) (feed.Feed, error) {
+	source, err := p.cfg.GetFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown feed %s", name)
+	}
+
+	f, err := filefeed.NewFeed(locale, source, mappings.mapping())
+	if err != nil {
+		return nil, fmt.Errorf("Initialize File Feed - %v", err)
+	}
+	return f, nil
+}
@@ -11,12 +11,11 @@ import (
 	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
 	"stillgrove.com/gofeedyourself/pkg/storefront"
 
-	awin "stillgrove.com/gofeedyourself/pkg/awin"
 	crawlers "stillgrove.com/gofeedyourself/pkg/crawlers"
 	cfg "stillgrove.com/gofeedyourself/pkg/feedservice/config"
 	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
 	"stillgrove.com/gofeedyourself/pkg/sftp"
-	td "stillgrove.com/gofeedyourself/pkg/tradedoubler"
 	woo "stillgrove.com/gofeedyourself/pkg/woocommerce"
 )
 
@@ -31,6 +30,7 @@ var (
 		"woocommerce",
 		"vsf-dump",
 		"csv",
+		"catalog",
 	}
 )
 
@@ -42,6 +42,12 @@ type FeedService struct {
 	cfg            *cfg.File
 	backend        string
 	doUpdate       bool
+	forceDelete    bool
+	dryRun         bool
+	onlyFeeds      []string
+	mappings       *mappingSet
+	tracker        *runTracker
+	triggered      sync.WaitGroup // triggered runs, waited for on shutdown
 }
 
 // New initializes and returns a FeedService pointer
@@ -50,6 +56,7 @@ func New(cfg *cfg.File, backend string, productionFlag bool) (p *FeedService, er
 		productionFlag: productionFlag,
 		mux:            new(sync.Mutex),
 		cfg:            cfg,
+		tracker:        newRunTracker(),
 	}
 
 	p.errs = NewPE(
@@ -65,26 +72,50 @@ func New(cfg *cfg.File, backend string, productionFlag bool) (p *FeedService, er
 	return p, nil
 }
 
+// runOptions are the settings of a single run
+type runOptions struct {
+	dryRun    bool
+	onlyFeeds []string
+}
+
+// options returns the settings of SetDryRun and SetFeeds
+func (p *FeedService) options() runOptions {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return runOptions{
+		dryRun:    p.dryRun,
+		onlyFeeds: p.onlyFeeds,
+	}
+}
+
 func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
+	p.run(applyUpdate, purgeImages, p.options())
+}
+
+// run is Run with the settings passed in, so triggered runs don't share them with the FeedService
+func (p *FeedService) run(applyUpdate bool, purgeImages bool, opts runOptions) {
 	defer track(time.Now(), "FeedService")
 
 	var (
-		err          error
-		loc          string
-		lang         string
-		cc           string
-		convTable    string
-		dynamoID     string
-		dynamoSecret string
-
-		mapNames = [...]string{
-			"colors",
-			"patterns",
-			"sizes",
-			"genders",
-		}
+		err error
+		loc string
+		wc  *woo.WooConnection
 	)
-	doUpdate := applyUpdate || p.productionFlag
+	doUpdate := (applyUpdate || p.productionFlag) && !opts.dryRun
+
+	p.tracker.start(!doUpdate, opts.onlyFeeds)
+	p.errs.OnCritical(func(err error) {
+		p.tracker.finish(wc)
+		p.notify(err)
+	})
+	defer func() {
+		if p.errs.Critical {
+			// finished and notified by OnCritical
+			return
+		}
+		p.tracker.finish(wc)
+		p.notify(nil)
+	}()
 
 	log.WithFields(
 		log.Fields{
@@ -102,63 +133,21 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 		p.errs.Log(fmt.Errorf("No internet connection detected"), "Check Connection")
 	}
 
-	cc, loc, lang, err = p.cfg.GetLocale()
+	_, loc, _, err = p.cfg.GetLocale()
 	p.errs.Log(err, "Load Country/Locale/Language from Config")
 
-	locale, err := feed.NewLocale(cc, lang, loc)
-	p.errs.Log(err, "Parse Locale from Config")
+	p.tracker.setStage("Load Mappings")
+	mappings, err := p.getMappings()
+	p.errs.Log(err, "Load Mappings")
 
-	convTable, website, err := p.cfg.GetTD()
-	p.errs.Log(err, "Load TD config")
+	all, err := p.newFeeds(mappings)
+	p.errs.Log(err, "Initialize Feeds")
 
-	awinAPIToken, awinFeedToken, err := p.cfg.GetAwin()
-	p.errs.Log(err, "Load Awin config")
-
-	dynamoID, dynamoSecret, _, err = p.cfg.GetDynamo()
-	p.errs.Log(err, "Load Dynamo Config")
-
-	maps := make([]map[string][]*string, len(mapNames))
-	for i := range mapNames {
-		maps[i], err = p.cfg.GetMapping(mapNames[i])
-		p.errs.Log(err, fmt.Sprintf("Load %s Mapping", mapNames[i]))
-	}
-
-	catMap, catNameMap, err := p.cfg.GetCategoryMaps()
-	p.errs.Log(err, "Get Category Map")
-
-	td, err := td.NewFeed(
-		locale,
-		website.Token,
-		dynamoID,
-		dynamoSecret,
-		convTable,
-		maps[0],
-		maps[1],
-		maps[2],
-		maps[3],
-		catNameMap,
-		lang,
-	)
-	p.errs.Log(err, "Initialize Tradedoubler Connection")
-
-	aw, err := awin.NewAwin(
-		locale,
-		awinAPIToken,
-		awinFeedToken,
-		&feed.Mapping{
-			ColorMap:   maps[0],
-			SizeMap:    maps[1],
-			GenderMap:  maps[2],
-			PatternMap: maps[3],
-			CatNameMap: catNameMap,
-		},
-	)
+	feeds, err := selectFeeds(all, opts.onlyFeeds)
+	p.errs.Log(err, "Select Feeds")
 
 	q := feed.NewQueueFromFeeds(
-		[]feed.Feed{
-			td,
-			aw,
-		},
+		feeds,
 		p.productionFlag,
 	)
 	// IMPORTANT: Disabling Crawlers for now, just slowing down the testing, haven't worked out proper way to get SKU / GTin
@@ -166,14 +155,28 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 		q.AppendMany(crawlers.GetCrawlFeeds())
 	}
 
+	q.SetProcessor(shippingProcessor(p.cfg))
+
+	monitor, err := p.newHealthMonitor()
+	if err != nil {
+		log.WithField("Error", err).Warnln("Running without feed health checks")
+	} else {
+		q.SetInspector(monitor.Inspect)
+	}
+
 	if p.backend == "woocommerce" {
 		domain, key, secret, err := p.cfg.GetWoo()
 		p.errs.Log(err, "Load WC Config")
 
 		w, err := woo.NewWooConnection(domain, key, secret, loc)
 		p.errs.Log(err, "Initialize WC Connection")
+		w.SetDeletionGuard(p.getDeletionGuard())
+		w.SetRanking(p.cfg.GetRanking())
+		wc = &w
+
 		newestProducts := new(feed.ProductMap)
 		for r := 0; r < Retries; r++ {
+			p.tracker.setStage("Load Products")
 			newestProducts, err = q.GetPM(true)
 			if err != nil {
 				log.Printf("Loading products - %v", err)
@@ -183,9 +186,23 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 			p.errs.Log(err, "Load Products")
 
 			np, nf, nc := newestProducts.Stats()
+			p.tracker.setLoaded(feeds, int(np))
 			log.Printf("Fetched %d products from %d feeds and sources with %d categories\n", np, nf, nc)
 
-			err = w.PrepareUpdate(newestProducts, catMap, p.productionFlag, purgeImages)
+			// the products of quarantined feeds stay in the shop as they are
+			w.SetPartialUpdate(len(opts.onlyFeeds) > 0 || (monitor != nil && len(monitor.Quarantined()) > 0))
+
+			if doUpdate {
+				// every attempt starts a new journal, --resume only replays the latest one
+				err = w.StartJournal()
+				if err != nil {
+					log.WithField("Error", err).Warnln("Running without request journal")
+				}
+			}
+
+			p.tracker.setStage("Prepare Update")
+			// a dry run must not write categories or terms either
+			err = w.PrepareUpdate(newestProducts, doUpdate, purgeImages)
 			if err != nil {
 				log.Printf("Failed to prepare update - %v", err)
 				continue
@@ -198,6 +215,7 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 			}
 
 			if p.productionFlag {
+				p.tracker.setStage("Delete Products")
 				err = w.ApplyUpdate("delete", output)
 				if err != nil {
 					log.WithField("Error", err).Errorln("Failed to delete products")
@@ -205,6 +223,7 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 				}
 
 				if purgeImages {
+					p.tracker.setStage("Purge Images")
 					err = p.PurgeImages()
 					if err != nil {
 						log.WithField("Error", err).Errorln("Failed to purge images from FTP")
@@ -212,6 +231,7 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 				}
 			}
 
+			p.tracker.setStage("Update Products")
 			err = w.ApplyUpdate("createupdate", output)
 			if err == nil {
 				log.WithField("Queue", "createupdate").Infoln("Succeeded")
@@ -237,6 +257,7 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 			p.errs.Log(err, "Load Products")
 
 			np, nf, nc := newestProducts.Stats()
+			p.tracker.setLoaded(feeds, int(np))
 			log.Printf("Fetched %d products from %d feeds and sources with %d categories\n", np, nf, nc)
 
 			d, err := storefront.NewFromFeed(newestProducts)
@@ -279,6 +300,13 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 		if !done {
 			p.errs.Log(fmt.Errorf("Ran through all the allowed retries"), "Create Product CSV Dump")
 		}
+	} else if p.backend == "catalog" {
+		err = p.exportCatalog(q, feeds, doUpdate)
+		p.errs.Log(err, "Export Catalog")
+	}
+
+	if monitor != nil {
+		p.finishHealth(monitor, doUpdate)
 	}
 
 	if len(p.errs.Errors) > 0 {
@@ -297,6 +325,71 @@ func (p *FeedService) Run(applyUpdate bool, purgeImages bool) {
 	}
 }
 
+// OverrideDeletionGuard applies all deletions of the next run, even if they exceed the limits from the config
+func (p *FeedService) OverrideDeletionGuard() {
+	p.forceDelete = true
+}
+
+// SetDryRun makes the next runs write the requests to the logs folder instead of applying them
+func (p *FeedService) SetDryRun(dryRun bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.dryRun = dryRun
+}
+
+// SetFeeds limits the next runs to the feeds whose names start with one of names
+func (p *FeedService) SetFeeds(names []string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.onlyFeeds = names
+}
+
+func (p *FeedService) getDeletionGuard() woo.DeletionGuard {
+	guard := woo.DefaultDeletionGuard
+
+	maxDeleteShare, maxDropShare, softDeleteRuns := p.cfg.GetDeletionGuard()
+	if maxDeleteShare > 0 {
+		guard.MaxDeleteShare = maxDeleteShare
+	}
+	if maxDropShare > 0 {
+		guard.MaxDropShare = maxDropShare
+	}
+	if softDeleteRuns > 0 {
+		guard.SoftDeleteRuns = softDeleteRuns
+	}
+	hideAfterDays, deleteAfterDays := p.cfg.GetProductLifecycle()
+	if hideAfterDays > 0 {
+		guard.HideAfter = time.Duration(hideAfterDays) * 24 * time.Hour
+	}
+	if deleteAfterDays > 0 {
+		guard.DeleteAfter = time.Duration(deleteAfterDays) * 24 * time.Hour
+	}
+	guard.Override = p.forceDelete
+
+	return guard
+}
+
+// Resume replays the batch requests of an interrupted WooCommerce sync that never succeeded
+func (p *FeedService) Resume() {
+	defer track(time.Now(), "FeedService")
+
+	if p.backend != "woocommerce" {
+		p.errs.Log(fmt.Errorf("Resume is only implemented for woocommerce, not %s", p.backend), "Check Backend setting")
+	}
+
+	domain, key, secret, err := p.cfg.GetWoo()
+	p.errs.Log(err, "Load WC Config")
+
+	_, locale, _, err := p.cfg.GetLocale()
+	p.errs.Log(err, "Load Locale from Config")
+
+	w, err := woo.NewWooConnection(domain, key, secret, locale)
+	p.errs.Log(err, "Initialize WC Connection")
+
+	err = w.ResumeUpdate()
+	p.errs.Log(err, "Resume Update")
+}
+
 // PurgeProducts deletes all the products from the WooCommerce backend
 func (p *FeedService) PurgeProducts() {
 	defer track(time.Now(), "FeedService")
@@ -361,6 +454,7 @@ func (p *FeedService) PurgeImages() error {
 	for i := range files {
 		if i%250 == 0 {
 			progressBar(i, len(files))
+			p.tracker.setProgress(i, len(files))
 		}
 		matched = false
 		if files[i].IsDir() == true {
@@ -398,6 +492,7 @@ func progressBar(completed, total int) {
 
 func track(start time.Time, name string) {
 	elapsed := time.Since(start)
+	metrics.StageSeconds.Set(elapsed.Seconds(), name)
 
 	log.WithField("time elapsed", elapsed).Info(name)
 }
@@ -409,8 +504,28 @@ func checkBackend(backend string) (b string, err error) {
 		}
 	}
 	if b == "" {
-		return b, fmt.Errorf("Only implemented backends as are: 'woocommerce', 'csv', and 'vsf-dump'")
+		return b, fmt.Errorf("Only implemented backends as are: 'woocommerce', 'csv', 'vsf-dump', and 'catalog'")
 	}
 
 	return b, nil
 }
+
+// shippingProcessor returns the processor applying the shipping rules, nil without any.
+// The rules of the config are applied even if the shipping sheet fails
+func shippingProcessor(c *cfg.File) feed.Processor {
+	rules, err := c.GetShipping()
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Rules": len(rules),
+				"Error": err,
+			},
+		).Warnln("Running without the shipping sheet")
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return func(_ string, products []feed.Product) {
+		rules.Apply(products)
+	}
+}
@@ -0,0 +1,98 @@
+package feedservice
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/cache"
+	"stillgrove.com/gofeedyourself/pkg/health"
+)
+
+// healthTTL - the feed profiles are dropped if there was no run for this long
+const healthTTL = 60 * 24 * time.Hour
+
+func openHealthCache() (cache.Cache, error) {
+	c, err := cache.NewBadgerCache(CacheDir()+"/health", healthTTL)
+	if err != nil {
+		return c, fmt.Errorf("Init feed health cache - %v", err)
+	}
+	return c, nil
+}
+
+// HealthRecords returns the stored profiles of the feeds
+func HealthRecords() (map[string]health.Record, error) {
+	c, err := openHealthCache()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	return health.LoadRecords(c)
+}
+
+// AcceptFeedHealth makes the latest profile of a feed its baseline, so the next run doesn't flag it again
+func AcceptFeedHealth(name string) error {
+	c, err := openHealthCache()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	records, err := health.LoadRecords(c)
+	if err != nil {
+		return err
+	}
+	err = health.Accept(records, name)
+	if err != nil {
+		return err
+	}
+	return health.StoreRecords(c, records)
+}
+
+// newHealthMonitor compares the feeds of the run against their stored profiles
+func (p *FeedService) newHealthMonitor() (*health.Monitor, error) {
+	records, err := HealthRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	h := p.cfg.GetHealth()
+	return health.NewMonitor(
+		records,
+		h.Action,
+		health.Thresholds{
+			MaxCountDrop:    h.MaxCountDrop,
+			MaxInStockShift: h.MaxInStockShift,
+			MaxPriceShift:   h.MaxPriceShift,
+			MaxCategoryDrop: h.MaxCategoryDrop,
+			MaxMappingDrop:  h.MaxMappingDrop,
+		},
+	)
+}
+
+// finishHealth reports the anomalies of the run and keeps the profiles if the update was applied
+func (p *FeedService) finishHealth(m *health.Monitor, keep bool) {
+	for _, problem := range m.Problems() {
+		p.errs.Log(
+			PipelineError{
+				IsNonCritical: true,
+				Message:       fmt.Errorf("%s", problem),
+			},
+			"Feed Health",
+		)
+	}
+	if !keep {
+		return
+	}
+
+	c, err := openHealthCache()
+	if err == nil {
+		err = health.StoreRecords(c, m.Records())
+		c.Close()
+	}
+	if err != nil {
+		log.WithField("Error", err).Warnln("Failed to store feed health")
+	}
+}
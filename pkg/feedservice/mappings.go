@@ -0,0 +1,170 @@
+package feedservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// mapNames lists the mapping tables loaded from Google Sheets, in the order the feeds expect them
+var mapNames = [...]string{
+	"colors",
+	"patterns",
+	"sizes",
+	"genders",
+}
+
+// mappingSet holds the mapping tables used by the feeds
+type mappingSet struct {
+	maps     []map[string][]*string
+	catNames map[string][]*string
+	loaded   time.Time
+}
+
+// mapping returns the tables in the form the feeds use them
+func (m *mappingSet) mapping() *feed.Mapping {
+	return &feed.Mapping{
+		ColorMap:   m.maps[0],
+		SizeMap:    m.maps[1],
+		GenderMap:  m.maps[2],
+		PatternMap: m.maps[3],
+		CatNameMap: m.catNames,
+	}
+}
+
+// ReloadMappings loads the mapping tables from Google Sheets, the next run uses them
+func (p *FeedService) ReloadMappings() error {
+	m := &mappingSet{
+		maps: make([]map[string][]*string, len(mapNames)),
+	}
+
+	var err error
+	for i := range mapNames {
+		m.maps[i], err = p.cfg.GetMapping(mapNames[i])
+		if err != nil {
+			return fmt.Errorf("Load %s Mapping - %v", mapNames[i], err)
+		}
+	}
+
+	m.catNames, err = p.cfg.GetCategoryNames()
+	if err != nil {
+		return fmt.Errorf("Get Category Names - %v", err)
+	}
+	m.loaded = time.Now()
+
+	p.mux.Lock()
+	p.mappings = m
+	p.mux.Unlock()
+
+	log.WithField("Category Names", len(m.catNames)).Infoln("Loaded mappings")
+
+	return nil
+}
+
+// getMappings returns the loaded mapping tables and loads them if there are none yet
+func (p *FeedService) getMappings() (*mappingSet, error) {
+	p.mux.Lock()
+	m := p.mappings
+	p.mux.Unlock()
+	if m != nil {
+		return m, nil
+	}
+
+	err := p.ReloadMappings()
+	if err != nil {
+		return m, err
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.mappings, nil
+}
+
+// Ready reports whether the FeedService can run, i.e. the mappings could be loaded
+func (p *FeedService) Ready() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.mappings == nil {
+		return fmt.Errorf("Mappings not loaded")
+	}
+	return nil
+}
+
+// MappingStats describes a loaded mapping table
+type MappingStats struct {
+	Name   string
+	Keys   int // source terms
+	Values int // distinct target terms
+}
+
+// MappingReport returns the size of every mapping table, loading them if needed
+func (p *FeedService) MappingReport() ([]MappingStats, error) {
+	m, err := p.getMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]MappingStats, 0, len(m.maps)+1)
+	for i := range mapNames {
+		stats = append(stats, newMappingStats(mapNames[i], m.maps[i]))
+	}
+	stats = append(stats, newMappingStats("categories", m.catNames))
+
+	return stats, nil
+}
+
+// ExportMappings writes every mapping table as JSON file to dir, e.g. to review or diff them
+func (p *FeedService) ExportMappings(dir string) error {
+	m, err := p.getMappings()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("Create %s - %v", dir, err)
+	}
+
+	tables := map[string]map[string][]*string{
+		"categories": m.catNames,
+	}
+	for i := range mapNames {
+		tables[mapNames[i]] = m.maps[i]
+	}
+	for name, table := range tables {
+		b, err := json.MarshalIndent(table, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Encode %s Mapping - %v", name, err)
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, name+".json"), b, 0644)
+		if err != nil {
+			return fmt.Errorf("Write %s Mapping - %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func newMappingStats(name string, table map[string][]*string) MappingStats {
+	values := make(map[string]struct{})
+	for _, targets := range table {
+		for _, t := range targets {
+			if t != nil {
+				values[*t] = struct{}{}
+			}
+		}
+	}
+	return MappingStats{
+		Name:   name,
+		Keys:   len(table),
+		Values: len(values),
+	}
+}
@@ -0,0 +1,71 @@
+package feedservice
+
+import (
+	"strings"
+
+	"stillgrove.com/gofeedyourself/pkg/email"
+	cfg "stillgrove.com/gofeedyourself/pkg/feedservice/config"
+	"stillgrove.com/gofeedyourself/pkg/notify"
+)
+
+// notify sends the summary of the finished run to the configured targets whose rules match,
+// failed is set if the run stopped on a critical error
+func (p *FeedService) notify(failed error) {
+	n := p.cfg.GetNotifications()
+	targets := p.notifyTargets(n)
+	if len(targets) == 0 {
+		return
+	}
+
+	notify.Send(targets, p.summary(failed != nil), n.MaxDropShare)
+}
+
+func (p *FeedService) notifyTargets(n cfg.Notifications) (targets []notify.Target) {
+	if len(n.Email.Recipients) > 0 {
+		name, server, pass := p.cfg.GetEmail()
+		targets = append(
+			targets,
+			notify.Target{
+				Notifier: notify.NewEmail(email.NewEmail(name, server, pass), n.Email.Recipients),
+				When:     n.Email.When,
+			},
+		)
+	}
+	if n.Webhook.URL != "" {
+		targets = append(
+			targets,
+			notify.Target{
+				Notifier: notify.NewWebhook(n.Webhook.URL),
+				When:     n.Webhook.When,
+			},
+		)
+	}
+	return targets
+}
+
+// summary collects the results of the latest run, it must not take the pipeline mux
+func (p *FeedService) summary(failed bool) notify.Summary {
+	report := p.tracker.getReport()
+	country, _, _, _ := p.cfg.GetLocale()
+
+	s := notify.Summary{
+		Country:  country,
+		Started:  report.Status.Started,
+		Finished: report.Status.Finished,
+		DryRun:   report.Status.DryRun,
+		Feeds:    report.Status.Loaded,
+		Products: report.Status.Products,
+		Failed:   failed,
+	}
+	if report.Diff != nil {
+		s.Create = len(report.Diff.Create)
+		s.Update = len(report.Diff.Update)
+		s.Delete = len(report.Diff.Delete)
+		s.Unchanged = report.Diff.Unchanged
+	}
+	for i := range p.errs.Errors {
+		s.Errors = append(s.Errors, strings.TrimSpace(p.errs.Errors[i].Error()))
+	}
+
+	return s
+}
@@ -0,0 +1,53 @@
+package feedservice
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/ranking"
+)
+
+// ExplainRanking loads the feeds and breaks down the scores of the products whose name, SKU or key contains the query,
+// the best ranked ones first and at most top of them
+func (p *FeedService) ExplainRanking(query string, top int) ([]ranking.Explanation, error) {
+	feeds, err := p.Feeds()
+	if err != nil {
+		return nil, err
+	}
+	products, err := feed.NewQueueFromFeeds(feeds, p.productionFlag).GetPM(false)
+	if err != nil {
+		return nil, fmt.Errorf("Load Products - %v", err)
+	}
+	all, _, _, _ := products.Get()
+
+	var (
+		model        = p.cfg.GetRanking()
+		now          = time.Now()
+		explanations []ranking.Explanation
+	)
+	query = strings.ToLower(query)
+	for key, product := range all {
+		if query != "" &&
+			!strings.Contains(strings.ToLower(product.Name), query) &&
+			!strings.Contains(strings.ToLower(product.SKU), query) &&
+			!strings.Contains(strconv.FormatUint(key, 10), query) {
+			continue
+		}
+		explanations = append(explanations, model.Explain(product, now))
+	}
+
+	sort.Slice(explanations, func(i, j int) bool {
+		if explanations[i].Score == explanations[j].Score {
+			return explanations[i].Key < explanations[j].Key
+		}
+		return explanations[i].Score > explanations[j].Score
+	})
+	if top > 0 && len(explanations) > top {
+		explanations = explanations[:top]
+	}
+	return explanations, nil
+}
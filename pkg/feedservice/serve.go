@@ -0,0 +1,129 @@
+package feedservice
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/scheduler"
+)
+
+// LockFile returns the path of the lock file shared by scheduled and one-off runs
+func LockFile() string {
+	return CacheDir() + "/feedservice.lock"
+}
+
+// Serve runs the FeedService every day at the configured time until a signal arrives on stop,
+// on the configured clean days the image assets are purged and uploaded again
+func (p *FeedService) Serve(stop <-chan os.Signal) error {
+	runAt, cleanDays, err := p.cfg.GetSchedule()
+	if err != nil {
+		return fmt.Errorf("Load schedule - %v", err)
+	}
+	schedule, err := scheduler.Parse(runAt, cleanDays)
+	if err != nil {
+		return fmt.Errorf("Parse schedule - %v", err)
+	}
+
+	log.WithFields(
+		log.Fields{
+			"Time":       runAt,
+			"Clean Days": cleanDays,
+			"Backend":    p.backend,
+		},
+	).Infoln("FeedService serving")
+
+	scheduler.NewDaemon(schedule, LockFile(), p.scheduledRun).Serve(stop)
+
+	log.Infoln("Waiting for triggered runs")
+	p.triggered.Wait()
+
+	return nil
+}
+
+// RunLocked runs the FeedService once, unless a scheduled run is in progress
+func (p *FeedService) RunLocked(applyUpdate bool, purgeImages bool) error {
+	lock, err := scheduler.AcquireLock(LockFile())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	p.Run(applyUpdate, purgeImages)
+	return nil
+}
+
+// Trigger starts a run in the background, unless another run holds the lock. A dry run only writes
+// the requests to the logs folder, feeds limits the run to the feeds whose names start with one of them
+func (p *FeedService) Trigger(dryRun bool, feeds []string) error {
+	lock, err := scheduler.AcquireLock(LockFile())
+	if err != nil {
+		return err
+	}
+
+	p.triggered.Add(1)
+	go func() {
+		defer p.triggered.Done()
+		defer lock.Release()
+
+		p.resetErrors()
+		err := p.recoverRun(p.productionFlag, false, runOptions{dryRun: dryRun, onlyFeeds: feeds})
+		if err != nil {
+			log.WithField("Error", err).Errorln("Triggered run stopped")
+		}
+	}()
+
+	return nil
+}
+
+// Status returns the progress of the current or latest run
+func (p *FeedService) Status() RunStatus {
+	return p.tracker.getStatus()
+}
+
+// Report returns the queue reports and the diff of the latest run
+func (p *FeedService) Report() RunReport {
+	return p.tracker.getReport()
+}
+
+func (p *FeedService) scheduledRun(clean bool) {
+	// scheduled runs always pick up the latest mappings
+	p.mux.Lock()
+	p.mappings = nil
+	p.mux.Unlock()
+
+	p.resetErrors()
+	err := p.recoverRun(p.productionFlag, clean, p.options())
+	if err != nil {
+		log.WithField("Error", err).Errorln("Scheduled run stopped, waiting for the next one")
+	}
+}
+
+// recoverRun runs the FeedService and returns the critical error that stopped it, the process keeps running
+func (p *FeedService) recoverRun(applyUpdate bool, purgeImages bool, opts runOptions) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		stop, ok := r.(pipelineStop)
+		if !ok {
+			panic(r)
+		}
+		err = stop.err
+	}()
+
+	p.run(applyUpdate, purgeImages, opts)
+	return nil
+}
+
+// resetErrors drops the errors of the last run, they are already logged.
+// The service outlives its runs, so critical errors only stop the run
+func (p *FeedService) resetErrors() {
+	p.errs = NewPE(
+		p.mux,
+		p.productionFlag,
+	)
+	p.errs.KeepAlive()
+}
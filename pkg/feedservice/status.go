@@ -0,0 +1,163 @@
+package feedservice
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	woo "stillgrove.com/gofeedyourself/pkg/woocommerce"
+	gwc "stillgrove.com/gofeedyourself/pkg/woocommerce/client"
+)
+
+// RunStatus shows what the FeedService is doing
+type RunStatus struct {
+	Running   bool      `json:"running"`
+	DryRun    bool      `json:"dry_run"`
+	Feeds     []string  `json:"feeds,omitempty"`
+	Loaded    []string  `json:"loaded,omitempty"` // names of the feeds in the run
+	Products  int       `json:"products"`         // products loaded from the feeds
+	Stage     string    `json:"stage"`
+	Completed int       `json:"completed"`
+	Total     int       `json:"total"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+}
+
+// RunReport holds the results of the latest run
+type RunReport struct {
+	Status     RunStatus                  `json:"status"`
+	Queues     map[string]gwc.QueueReport `json:"queues"`
+	Diff       *woo.DiffReport            `json:"diff,omitempty"`
+	Exclusions map[string][]string        `json:"exclusions,omitempty"` // advertisers left out by feed
+}
+
+// excluder is implemented by the feeds that leave out advertisers, e.g. the Awin programmes failing the filter
+type excluder interface {
+	Exclusions() []string
+}
+
+// runTracker is shared between the running pipeline and the control plane
+type runTracker struct {
+	mux    *sync.Mutex
+	status RunStatus
+	report RunReport
+	feeds  []feed.Feed // loaded in the run
+}
+
+func newRunTracker() *runTracker {
+	return &runTracker{
+		mux: new(sync.Mutex),
+	}
+}
+
+func (t *runTracker) start(dryRun bool, feeds []string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.status = RunStatus{
+		Running: true,
+		DryRun:  dryRun,
+		Feeds:   feeds,
+		Stage:   "Started",
+		Started: time.Now(),
+	}
+}
+
+func (t *runTracker) setStage(stage string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.status.Stage = stage
+	t.status.Completed = 0
+	t.status.Total = 0
+}
+
+func (t *runTracker) setLoaded(feeds []feed.Feed, products int) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.status.Loaded = nil
+	t.feeds = feeds
+	for i := range feeds {
+		t.status.Loaded = append(t.status.Loaded, feeds[i].GetName())
+	}
+	t.status.Products = products
+}
+
+func (t *runTracker) setProgress(completed, total int) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.status.Completed = completed
+	t.status.Total = total
+}
+
+// finish keeps the reports of the WC connection, w is nil for the other backends
+func (t *runTracker) finish(w *woo.WooConnection) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.status.Running = false
+	t.status.Stage = "Finished"
+	t.status.Finished = time.Now()
+
+	t.report = RunReport{
+		Status: t.status,
+		Queues: make(map[string]gwc.QueueReport),
+	}
+	for i := range t.feeds {
+		if e, ok := t.feeds[i].(excluder); ok && len(e.Exclusions()) > 0 {
+			if t.report.Exclusions == nil {
+				t.report.Exclusions = make(map[string][]string)
+			}
+			t.report.Exclusions[t.feeds[i].GetName()] = e.Exclusions()
+		}
+	}
+	t.feeds = nil
+	if w == nil {
+		return
+	}
+	for _, name := range []string{"delete", "createupdate"} {
+		report, exists := w.Connection.GetQueueReport(name)
+		if exists {
+			t.report.Queues[name] = report
+		}
+	}
+	t.report.Diff = w.GetDiffReport()
+}
+
+func (t *runTracker) getStatus() RunStatus {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	return t.status
+}
+
+func (t *runTracker) getReport() RunReport {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	return t.report
+}
+
+// selectFeeds keeps the feeds whose name starts with one of names, e.g. "awin" for "Awin - SE"
+func selectFeeds(feeds []feed.Feed, names []string) (selected []feed.Feed, err error) {
+	if len(names) == 0 {
+		return feeds, nil
+	}
+	for i := range feeds {
+		name := strings.ToLower(feeds[i].GetName())
+		for j := range names {
+			if strings.HasPrefix(name, strings.ToLower(strings.TrimSpace(names[j]))) {
+				selected = append(selected, feeds[i])
+				break
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return selected, fmt.Errorf("No feed matches %v", names)
+	}
+	return selected, nil
+}
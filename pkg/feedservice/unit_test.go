@@ -0,0 +1,72 @@
+// +build unit
+// +build !integration
+
+package feedservice
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	cfg "stillgrove.com/gofeedyourself/pkg/feedservice/config"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+func TestKeepAliveUnit(t *testing.T) {
+	pe := NewPE(new(sync.Mutex), false)
+	pe.KeepAlive()
+
+	var notified error
+	pe.OnCritical(func(err error) {
+		notified = err
+	})
+
+	defer func() {
+		stop, ok := recover().(pipelineStop)
+		if !ok || !strings.Contains(stop.err.Error(), "Load Products") {
+			t.Fatalf("Expected the run to stop with the error - %v", stop.err)
+		}
+		if notified == nil || !pe.Critical {
+			t.Fatal("Expected the critical error to be notified")
+		}
+	}()
+	pe.Log(errors.New("No products"), "Load Products")
+	t.Fatal("Critical error didn't stop the run")
+}
+
+func TestShippingSheetFailureUnit(t *testing.T) {
+	// the sheet has no range, so it fails without a request
+	var c cfg.File
+	err := yaml.Unmarshal([]byte(`
+shipping:
+  - retailer: Shop
+    price: 49
+    delivery_time: 2-4 days
+gsheet:
+  shipping:
+    id: sheet
+`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetShipping(); err == nil {
+		t.Fatal("Expected the shipping sheet to fail")
+	}
+
+	process := shippingProcessor(&c)
+	if process == nil {
+		t.Fatal("Expected the rules of the config without the sheet")
+	}
+	products := []feed.Product{{Retailers: []feed.Retailer{{Name: "Shop", Price: "399", Currency: "SEK"}}}}
+	process("test", products)
+	if r := products[0].Retailers[0]; r.ShippingCost != "49" || r.DeliveryTime != "2-4 days" {
+		t.Fatalf("Expected the rules of the config to be applied - %+v", r)
+	}
+
+	if shippingProcessor(&cfg.File{}) != nil {
+		t.Fatal("Expected no processor without rules")
+	}
+}
@@ -0,0 +1,267 @@
+package filefeed
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// SampleSize describes the limit of rows to read when not in production mode
+const SampleSize = 5000
+
+// Feed reads the products of a merchant feed file as described by its Source
+type Feed struct {
+	source Source
+	m      *feed.Mapping
+	locale *feed.Locale
+}
+
+// NewFeed returns the feed of a checked source
+func NewFeed(locale *feed.Locale, source Source, mapping *feed.Mapping) (*Feed, error) {
+	problems := source.Check()
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("Invalid feed %s - %s", source.Name, strings.Join(problems, ", "))
+	}
+	if source.ID == 0 {
+		h := fnv.New32a()
+		h.Write([]byte(source.Name))
+		source.ID = int32(h.Sum32() & 0x7fffffff)
+	}
+	return &Feed{
+		source: source,
+		m:      mapping,
+		locale: locale,
+	}, nil
+}
+
+// GetName identifies the feed source
+func (f Feed) GetName() string {
+	return f.source.Name
+}
+
+// GetLocale returns the locale of the feed
+func (f Feed) GetLocale() *feed.Locale {
+	return f.locale
+}
+
+// Get reads the file and converts its rows, rows that can't be mapped are dropped
+func (f Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
+	body, err := f.source.open()
+	if err != nil {
+		return outProducts, fmt.Errorf("Open %s - %v", f.source.Location, err)
+	}
+	defer body.Close()
+
+	var rows, dropped int
+	if f.source.Format == FormatGoogle {
+		outProducts, rows, dropped, err = f.getGoogle(body, productionFlag)
+	} else {
+		err = f.source.readRows(body, func(r row) bool {
+			rows++
+			p, err := f.toFeedProduct(r)
+			if err != nil {
+				dropped++
+				f.drop(err)
+			} else if p.Active {
+				outProducts = append(outProducts, *p)
+			}
+			return productionFlag || rows < SampleSize
+		})
+	}
+	if err != nil {
+		return outProducts, fmt.Errorf("Read %s - %v", f.source.Name, err)
+	}
+
+	log.WithFields(
+		log.Fields{
+			"Feed":     f.source.Name,
+			"Rows":     rows,
+			"Dropped":  dropped,
+			"Products": len(outProducts),
+		},
+	).Infoln("Read Feed File")
+
+	if len(outProducts) == 0 {
+		return outProducts, fmt.Errorf("No valid products in the feed")
+	}
+
+	return outProducts, nil
+}
+
+func (f Feed) drop(err error) {
+	log.WithFields(
+		log.Fields{
+			"Error":  err,
+			"Source": f.source.Name,
+		},
+	).Debugln("Dropping Product")
+}
+
+// value returns the first non-empty column of a field, or its constant
+func (f Feed) value(r row, field string) string {
+	for _, column := range strings.Split(f.source.Columns[field], ",") {
+		v := r[strings.TrimSpace(column)]
+		if v != "" {
+			return v
+		}
+	}
+	return f.source.Constants[field]
+}
+
+// values returns every non-empty column of a field, or its constant
+func (f Feed) values(r row, field string) (out []string) {
+	for _, column := range strings.Split(f.source.Columns[field], ",") {
+		v := r[strings.TrimSpace(column)]
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	if len(out) == 0 && f.source.Constants[field] != "" {
+		out = append(out, f.source.Constants[field])
+	}
+	return out
+}
+
+// toFeedProduct maps a row like awin.Product.ToFeedProduct maps an Awin product
+func (f Feed) toFeedProduct(r row) (productOut *feed.Product, err error) {
+	link := f.value(r, "retailer.link")
+	productOut = &feed.Product{
+		Name:             f.value(r, "name"),
+		SKU:              f.value(r, "sku"),
+		Color:            collection.CollateStrings(f.value(r, "color"), "multi"),
+		Description:      f.value(r, "description"),
+		ShortDescription: f.value(r, "short_description"),
+		ImageURL:         f.value(r, "image_url"),
+		Brand:            f.value(r, "brand"),
+		Material:         f.value(r, "material"),
+		Language:         f.locale.Locale,
+		Retailers: []feed.Retailer{
+			feed.Retailer{
+				Link:         link,
+				Logo:         f.value(r, "retailer.logo"),
+				Name:         collection.CollateStrings(f.value(r, "retailer.name"), f.source.Name),
+				Currency:     f.value(r, "retailer.currency"),
+				Availability: feed.MapAvailability(strings.ToLower(f.value(r, "retailer.availability"))),
+				FeedID:       f.source.ID,
+				DeliveryTime: f.value(r, "retailer.delivery_time"),
+				ShippingCost: f.value(r, "retailer.shipping_cost"),
+				Sizes:        feed.SplitSizes(f.value(r, "retailer.sizes")),
+			},
+		},
+		FromFeeds: []int32{f.source.ID},
+	}
+
+	// ----------------------
+	// Price logic ----------
+	// ----------------------
+
+	price, err := ParsePrice(f.value(r, "retailer.price"))
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to parse price - %v", err)
+	}
+	highest, _ := ParsePrice(f.value(r, "retailer.highest_price"))
+	if highest < price {
+		highest = price
+	}
+	productOut.Retailers[0].Price = strconv.FormatFloat(price, 'f', 2, 32)
+	productOut.Retailers[0].HighestPrice = float32(highest)
+	productOut.LowestPrice, productOut.HighestPrice = float32(price), float32(highest)
+	err = productOut.CalculateDiscounts(10)
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to parse prices - %v", err)
+	}
+
+	// ----------------------
+	// Color logic ----------
+	// ----------------------
+
+	colorCandidates := []string{
+		f.value(r, "color"),
+		productOut.Color,
+		strings.Replace(productOut.Name, productOut.Brand, "", 1),
+	}
+	productOut.ColorGroups = feed.MapColors(f.m, colorCandidates...)
+	if len(productOut.ColorGroups) == 0 {
+		return productOut, fmt.Errorf("Failed to parse color - %v", colorCandidates)
+	}
+
+	// ----------------------
+	// Gender logic ---------
+	// ----------------------
+
+	categories := f.values(r, "categories")
+	productOut.Gender = feed.MapGender(append(f.values(r, "gender"), categories...)...)
+	if productOut.Gender == "" {
+		return productOut, fmt.Errorf("Failed to parse gender - %v", categories)
+	}
+
+	// ----------------------
+	// Category logic -------
+	// ----------------------
+
+	productOut.OriginalCategories = categories
+	productOut.ProviderCategories, err = feed.MapCategories(
+		f.m,
+		f.source.Name,
+		append([]string{productOut.Gender}, categories...)...,
+	)
+	if len(categories) == 0 && err != nil {
+		return productOut, fmt.Errorf("Failed to parse categories - %v", err)
+	}
+
+	// ----------------------
+	// Offer logic ----------
+	// ----------------------
+
+	productOut.RetailerMap = map[uint64]struct{}{
+		collection.HashKey(link): struct{}{},
+	}
+	productOut.Active = productOut.Retailers[0].Availability == "instock"
+
+	// ----------------------
+	// SKU logic ------------
+	// ----------------------
+
+	if productOut.SKU == "" || link == "" {
+		return productOut, fmt.Errorf("No identifier or link found - %s", productOut.Name)
+	}
+	err = productOut.SetKey()
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to set key - %v", err)
+	}
+
+	return productOut, nil
+}
+
+// ParsePrice reads prices like 499, 499.00 SEK, 1,299.00, 1,299 or 1 299,00 kr. The last separator is the decimal one
+// if one or two digits follow it, otherwise it separates thousands
+func ParsePrice(s string) (float64, error) {
+	var (
+		digits    []rune
+		separator = -1
+	)
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == '.' || c == ',':
+			separator = len(digits)
+		}
+	}
+	number := string(digits)
+	if fraction := len(digits) - separator; separator > 0 && fraction >= 1 && fraction <= 2 {
+		number = string(digits[:separator]) + "." + string(digits[separator:])
+	}
+
+	price, err := strconv.ParseFloat(number, 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("No price in %q", s)
+	}
+	return price, nil
+}
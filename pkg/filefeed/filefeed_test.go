@@ -0,0 +1,283 @@
+// +build unit
+// +build !integration
+
+package filefeed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/shipping"
+)
+
+func testFeed(t *testing.T, dir string, source Source, content []byte) *Feed {
+	source.Location = filepath.Join(dir, source.Format)
+	err := ioutil.WriteFile(source.Location, content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blue, dress := "blue", "dresses"
+	locale, err := feed.NewLocale("SE", "sv", "sv_se")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFeed(
+		locale,
+		source,
+		&feed.Mapping{
+			ColorMap:   map[string][]*string{"navy": {&blue}},
+			CatNameMap: map[string][]*string{"dresses": {&dress}},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestFeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filefeed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("csv", func(t *testing.T) { testCSV(t, dir) })
+	t.Run("xml", func(t *testing.T) { testXML(t, dir) })
+	t.Run("google", func(t *testing.T) { testGoogle(t, dir) })
+}
+
+func testCSV(t *testing.T, dir string) {
+	var content bytes.Buffer
+	gz := gzip.NewWriter(&content)
+	gz.Write([]byte("id;title;colour;cat;link;price;old_price;stock;sizes\n" +
+		"1;Summer Dress;Navy;Women > Dresses;https://shop.se/1;\"1 299,00 kr\";1 599,00;in stock;S, M\n" +
+		"2;Sold out;Navy;Women > Dresses;https://shop.se/2;199;;out of stock;S\n" +
+		"3;No price;Navy;Women > Dresses;https://shop.se/3;;;in stock;S\n" +
+		"4;Linen Shirt;Navy;Men > Shirts;https://shop.se/4;299;;in stock;L\n" +
+		"5;Tote Bag;Navy;Unisex > Bags;https://shop.se/5;199;;in stock;\n"))
+	gz.Close()
+
+	f := testFeed(
+		t,
+		dir,
+		Source{
+			Name:      "Shop",
+			ID:        4711,
+			Format:    FormatCSV,
+			Delimiter: ";",
+			Columns: map[string]string{
+				"sku":                    "ean, id",
+				"name":                   "title",
+				"color":                  "colour",
+				"categories":             "cat",
+				"retailer.link":          "link",
+				"retailer.price":         "price",
+				"retailer.highest_price": "old_price",
+				"retailer.availability":  "stock",
+				"retailer.sizes":         "sizes",
+			},
+			Constants: map[string]string{
+				"retailer.currency": "SEK",
+			},
+		},
+		content.Bytes(),
+	)
+
+	products, err := f.Get(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 3 {
+		t.Fatalf("Expected the products in stock with a price - %+v", products)
+	}
+	bySKU := make(map[string]feed.Product)
+	for i := range products {
+		bySKU[products[i].SKU] = products[i]
+	}
+	if bySKU["4"].Gender != "men" || bySKU["5"].Gender != "unisex" {
+		t.Fatalf("Wrong genders - %q and %q", bySKU["4"].Gender, bySKU["5"].Gender)
+	}
+	p := bySKU["1"]
+	if p.SKU != "1" || p.LowestPrice != 1299 || p.HighestPrice != 1599 || p.Discount != 18 {
+		t.Fatalf("Wrong prices - %+v", p)
+	}
+	if p.Retailers[0].Currency != "SEK" || p.Retailers[0].Name != "Shop" || len(p.Retailers[0].Sizes) != 2 {
+		t.Fatalf("Wrong retailer - %+v", p.Retailers[0])
+	}
+	if !collection.StringInList("blue", p.ColorGroups) || p.Gender != "women" {
+		t.Fatalf("Wrong mapping - %v %s", p.ColorGroups, p.Gender)
+	}
+	if len(p.ProviderCategories) != 1 || p.ProviderCategories[0].Name != "dresses" {
+		t.Fatalf("Wrong categories - %+v", p.ProviderCategories)
+	}
+
+	// the rule of the feed goes before the one of the retailer name
+	shipping.Rules{
+		{Retailer: "Shop", Price: 59},
+		{FeedID: 4711, Price: 29, DeliveryTime: "1-2 days"},
+	}.Apply(products)
+	if r := products[0].Retailers[0]; r.FeedID != 4711 || r.ShippingCost != "29" || r.DeliveryTime != "1-2 days" {
+		t.Fatalf("Expected the shipping rule of the feed - %+v", r)
+	}
+}
+
+func testXML(t *testing.T, dir string) {
+	content := []byte(`<?xml version="1.0"?>
+<rss xmlns:g="http://base.google.com/ns/1.0"><channel>
+<item>
+	<g:id>42</g:id>
+	<title>Dress</title>
+	<g:price currency="SEK">499.00 SEK</g:price>
+	<g:availability>in stock</g:availability>
+	<g:product_type>Women</g:product_type>
+	<g:product_type>Dresses</g:product_type>
+	<link>https://shop.se/42</link>
+	<g:shipping><g:price>49 SEK</g:price></g:shipping>
+</item>
+</channel></rss>`)
+
+	f := testFeed(
+		t,
+		dir,
+		Source{
+			Name:   "Shop",
+			Format: FormatXML,
+			Rows:   "//item",
+			Columns: map[string]string{
+				"sku":                    "id",
+				"name":                   "title",
+				"categories":             "product_type",
+				"retailer.link":          "link",
+				"retailer.price":         "price",
+				"retailer.currency":      "price/@currency",
+				"retailer.availability":  "availability",
+				"retailer.shipping_cost": "shipping/price",
+			},
+		},
+		content,
+	)
+
+	products, err := f.Get(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := products[0]
+	if p.SKU != "42" || p.LowestPrice != 499 || p.Retailers[0].Currency != "SEK" || p.Retailers[0].ShippingCost != "49 SEK" {
+		t.Fatalf("Wrong product - %+v", p)
+	}
+	if len(p.OriginalCategories) != 1 || p.OriginalCategories[0] != "Women, Dresses" {
+		t.Fatalf("Repeated elements not joined - %v", p.OriginalCategories)
+	}
+}
+
+func testGoogle(t *testing.T, dir string) {
+	item := func(id, size, price, availability string) string {
+		return `<item>
+	<g:id>` + id + `</g:id>
+	<g:item_group_id>D1</g:item_group_id>
+	<title>Dress</title>
+	<link>https://shop.se/d1?size=` + size + `</link>
+	<g:color>Navy</g:color>
+	<g:size>` + size + `</g:size>
+	<g:gender>female</g:gender>
+	<g:price>` + price + `</g:price>
+	<g:sale_price>399 SEK</g:sale_price>
+	<g:availability>` + availability + `</g:availability>
+	<g:google_product_category>2271</g:google_product_category>
+	<g:product_type>Women &gt; Dresses</g:product_type>
+</item>`
+	}
+	content := []byte(`<?xml version="1.0"?><rss xmlns:g="http://base.google.com/ns/1.0"><channel>` +
+		item("D1-S", "S", "499.00 SEK", "out of stock") +
+		item("D1-M", "M", "499.00 SEK", "in stock") +
+		item("D1-L", "L", "599.00 SEK", "in_stock") +
+		// the category says women, g:gender takes precedence
+		`<item>
+	<g:id>S1</g:id>
+	<title>Shirt</title>
+	<link>https://shop.se/s1</link>
+	<g:color>Navy</g:color>
+	<g:gender>male</g:gender>
+	<g:price>299 SEK</g:price>
+	<g:availability>in stock</g:availability>
+	<g:product_type>Women &amp; Men &gt; Shirts</g:product_type>
+</item>` +
+		`</channel></rss>`)
+
+	f := testFeed(
+		t,
+		dir,
+		Source{
+			Name:   "Shop",
+			Format: FormatGoogle,
+		},
+		content,
+	)
+
+	products, err := f.Get(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 {
+		t.Fatalf("Variants not grouped - %+v", products)
+	}
+	bySKU := make(map[string]feed.Product)
+	for i := range products {
+		bySKU[products[i].SKU] = products[i]
+	}
+	if bySKU["D1"].Gender != "women" || bySKU["S1"].Gender != "men" {
+		t.Fatalf("Wrong genders - %q and %q", bySKU["D1"].Gender, bySKU["S1"].Gender)
+	}
+	p := bySKU["D1"]
+	r := p.Retailers[0]
+	if p.SKU != "D1" || p.LowestPrice != 399 || p.HighestPrice != 599 || r.Currency != "SEK" {
+		t.Fatalf("Wrong product - %+v", p)
+	}
+	if len(r.Sizes) != 2 || r.Sizes[0] != "L" || r.Sizes[1] != "M" {
+		t.Fatalf("Expected the sizes in stock - %v", r.Sizes)
+	}
+	if len(p.ProviderCategories) != 1 || p.ProviderCategories[0].ProviderCategoryID != 2271 ||
+		p.ProviderCategories[0].ProviderName != "google" {
+		t.Fatalf("Wrong categories - %+v", p.ProviderCategories)
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	for in, expected := range map[string]float64{
+		"499":         499,
+		"499.00 SEK":  499,
+		"499,5":       499.5,
+		"1,299":       1299,
+		"1.299,00":    1299,
+		"1,299.00":    1299,
+		"1 299,00 kr": 1299,
+		"SEK 12.345":  12345,
+	} {
+		price, err := ParsePrice(in)
+		if err != nil || price != expected {
+			t.Fatalf("Wrong price for %q - %v instead of %v (%v)", in, price, expected, err)
+		}
+	}
+	if _, err := ParsePrice("free"); err == nil {
+		t.Fatal("Expected an error without digits")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	problems := Source{
+		Format:  FormatXML,
+		Columns: map[string]string{"colour": "color"},
+	}.Check()
+	// name, location, rows, unknown field, and the four required fields
+	if len(problems) != 8 {
+		t.Fatalf("Expected 8 problems - %v", problems)
+	}
+}
@@ -0,0 +1,310 @@
+package filefeed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// googleItem is an item of a Google Merchant Center feed, RSS 2.0 or Atom 1.0.
+// The tags have no namespace, so both g:price and price match
+type googleItem struct {
+	ID                    string       `xml:"id"`
+	Title                 string       `xml:"title"`
+	Description           string       `xml:"description"`
+	Summary               string       `xml:"summary"` // atom
+	Links                 []googleLink `xml:"link"`
+	ImageLink             string       `xml:"image_link"`
+	Brand                 string       `xml:"brand"`
+	GTIN                  string       `xml:"gtin"`
+	MPN                   string       `xml:"mpn"`
+	Color                 string       `xml:"color"`
+	Size                  string       `xml:"size"`
+	Gender                string       `xml:"gender"`
+	Material              string       `xml:"material"`
+	Price                 string       `xml:"price"`
+	SalePrice             string       `xml:"sale_price"`
+	Availability          string       `xml:"availability"`
+	ItemGroupID           string       `xml:"item_group_id"`
+	GoogleProductCategory string       `xml:"google_product_category"`
+	ProductType           []string     `xml:"product_type"`
+	Shipping              []struct {
+		Country string `xml:"country"`
+		Price   string `xml:"price"`
+	} `xml:"shipping"`
+}
+
+// googleLink is the text of an RSS link or the href of an Atom link
+type googleLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:",chardata"`
+}
+
+func (i googleItem) link() string {
+	for _, l := range i.Links {
+		if link := strings.TrimSpace(collection.CollateStrings(l.Text, l.Href)); link != "" {
+			return link
+		}
+	}
+	return ""
+}
+
+// readGoogle calls fn for every item or entry until fn returns false
+func readGoogle(r io.Reader, fn func(googleItem) bool) error {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Read xml - %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || (start.Name.Local != "item" && start.Name.Local != "entry") {
+			continue
+		}
+		var item googleItem
+		err = decoder.DecodeElement(&item, &start)
+		if err != nil {
+			return fmt.Errorf("Read item - %v", err)
+		}
+		if !fn(item) {
+			return nil
+		}
+	}
+}
+
+// getGoogle reads the items and groups the variants of an item_group_id and color into one product,
+// the in stock sizes of the variants become the sizes of its retailer
+func (f Feed) getGoogle(r io.Reader, productionFlag bool) (outProducts []feed.Product, items, dropped int, err error) {
+	var (
+		groups = make(map[string]*feed.Product)
+		order  []string
+	)
+	err = readGoogle(r, func(item googleItem) bool {
+		items++
+		p, err := f.googleToFeedProduct(item)
+		if err != nil {
+			dropped++
+			f.drop(err)
+			return productionFlag || items < SampleSize
+		}
+
+		group := collection.CollateStrings(item.ItemGroupID, p.SKU) + "-" + collection.SanitizeHard(p.Color)
+		existing, ok := groups[group]
+		if !ok {
+			groups[group] = p
+			order = append(order, group)
+			return productionFlag || items < SampleSize
+		}
+		mergeVariant(existing, p)
+		return productionFlag || items < SampleSize
+	})
+	if err != nil {
+		return outProducts, items, dropped, err
+	}
+
+	for _, group := range order {
+		p := groups[group]
+		if !p.Active {
+			continue
+		}
+		sort.Strings(p.Retailers[0].Sizes)
+		outProducts = append(outProducts, *p)
+	}
+	return outProducts, items, dropped, nil
+}
+
+// mergeVariant adds the size and price of a variant to the product of its group
+func mergeVariant(p, variant *feed.Product) {
+	if !variant.Active {
+		return
+	}
+	r, v := &p.Retailers[0], variant.Retailers[0]
+	if !p.Active || variant.LowestPrice < p.LowestPrice {
+		r.Price, r.Link = v.Price, v.Link
+		p.LowestPrice = variant.LowestPrice
+		p.RetailerMap = variant.RetailerMap
+	}
+	if variant.HighestPrice > p.HighestPrice {
+		p.HighestPrice = variant.HighestPrice
+		r.HighestPrice = v.HighestPrice
+	}
+	if !p.Active {
+		r.Sizes = nil
+	}
+	for _, size := range v.Sizes {
+		if !collection.StringInList(size, r.Sizes) {
+			r.Sizes = append(r.Sizes, size)
+		}
+	}
+	r.Availability = "instock"
+	p.Active = true
+	p.Discount, p.DiscountBins = 0, nil
+	p.CalculateDiscounts(10)
+}
+
+// googleToFeedProduct maps an item like awin.Product.ToFeedProduct maps an Awin product,
+// the item_group_id is the SKU so the variants share a key
+func (f Feed) googleToFeedProduct(item googleItem) (productOut *feed.Product, err error) {
+	link := item.link()
+	price, currency, err := parseGooglePrice(item.Price)
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to parse price - %v", err)
+	}
+	salePrice, _, err := parseGooglePrice(item.SalePrice)
+	if err != nil || salePrice > price {
+		salePrice = price
+	}
+	currency = collection.CollateStrings(currency, f.source.Constants["retailer.currency"])
+
+	var shipping string
+	for _, s := range item.Shipping {
+		if s.Country == "" || strings.EqualFold(s.Country, f.locale.TwoLetterCode) {
+			shipping = s.Price
+			break
+		}
+	}
+
+	productOut = &feed.Product{
+		Name:        item.Title,
+		SKU:         collection.CollateStrings(item.ItemGroupID, item.GTIN, item.ID, item.MPN),
+		Color:       collection.CollateStrings(item.Color, "multi"),
+		Description: collection.CollateStrings(item.Description, item.Summary),
+		ImageURL:    item.ImageLink,
+		Brand:       item.Brand,
+		Material:    item.Material,
+		Language:    f.locale.Locale,
+		Retailers: []feed.Retailer{
+			feed.Retailer{
+				Link:         link,
+				Logo:         f.source.Constants["retailer.logo"],
+				Name:         collection.CollateStrings(f.source.Constants["retailer.name"], f.source.Name),
+				Price:        strconv.FormatFloat(salePrice, 'f', 2, 32),
+				HighestPrice: float32(price),
+				Currency:     currency,
+				Availability: feed.MapAvailability(strings.ToLower(item.Availability)),
+				FeedID:       f.source.ID,
+				DeliveryTime: f.source.Constants["retailer.delivery_time"],
+				ShippingCost: collection.CollateStrings(shipping, f.source.Constants["retailer.shipping_cost"]),
+				Sizes:        feed.SplitSizes(item.Size),
+			},
+		},
+		LowestPrice:  float32(salePrice),
+		HighestPrice: float32(price),
+		FromFeeds:    []int32{f.source.ID},
+	}
+	err = productOut.CalculateDiscounts(10)
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to parse prices - %v", err)
+	}
+
+	// ----------------------
+	// Color logic ----------
+	// ----------------------
+
+	productOut.ColorGroups = feed.MapColors(
+		f.m,
+		item.Color,
+		productOut.Color,
+		strings.Replace(item.Title, item.Brand, "", 1),
+	)
+	if len(productOut.ColorGroups) == 0 {
+		return productOut, fmt.Errorf("Failed to parse color - %s", item.Color)
+	}
+
+	// ----------------------
+	// Gender logic ---------
+	// ----------------------
+
+	categories := append([]string{item.GoogleProductCategory}, item.ProductType...)
+	productOut.Gender = googleGender(item.Gender)
+	if productOut.Gender == "" {
+		productOut.Gender = feed.MapGender(append([]string{f.source.Constants["gender"]}, categories...)...)
+	}
+	if productOut.Gender == "" {
+		return productOut, fmt.Errorf("Failed to parse gender - %v", categories)
+	}
+
+	// ----------------------
+	// Category logic -------
+	// ----------------------
+
+	for _, c := range categories {
+		if c != "" {
+			productOut.OriginalCategories = append(productOut.OriginalCategories, c)
+		}
+	}
+	productOut.ProviderCategories, err = feed.MapCategories(
+		f.m,
+		"google",
+		append([]string{productOut.Gender}, categories...)...,
+	)
+	if err == nil {
+		// numeric categories are ids of the Google product taxonomy
+		id, _ := strconv.Atoi(strings.TrimSpace(item.GoogleProductCategory))
+		for i := range productOut.ProviderCategories {
+			productOut.ProviderCategories[i].ProviderCategoryID = id
+		}
+	}
+	if len(productOut.OriginalCategories) == 0 && err != nil {
+		return productOut, fmt.Errorf("Failed to parse categories - %v", err)
+	}
+
+	// ----------------------
+	// Offer logic ----------
+	// ----------------------
+
+	productOut.RetailerMap = map[uint64]struct{}{
+		collection.HashKey(link): struct{}{},
+	}
+	productOut.Active = productOut.Retailers[0].Availability == "instock"
+
+	// ----------------------
+	// SKU logic ------------
+	// ----------------------
+
+	if productOut.SKU == "" || link == "" {
+		return productOut, fmt.Errorf("No identifier or link found - %s", item.Title)
+	}
+	err = productOut.SetKey()
+	if err != nil {
+		return productOut, fmt.Errorf("Failed to set key - %v", err)
+	}
+
+	return productOut, nil
+}
+
+// parseGooglePrice reads prices like 499.00 SEK, the currency is the ISO 4217 code
+func parseGooglePrice(s string) (price float64, currency string, err error) {
+	for _, field := range strings.Fields(s) {
+		if len(field) == 3 && strings.IndexFunc(field, func(r rune) bool { return !unicode.IsUpper(r) }) < 0 {
+			currency = field
+		}
+	}
+	price, err = ParsePrice(s)
+	return price, currency, err
+}
+
+// googleGender maps the documented values of g:gender, empty for anything else
+func googleGender(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "female":
+		return "women"
+	case "male":
+		return "men"
+	case "unisex":
+		return "unisex"
+	}
+	return ""
+}
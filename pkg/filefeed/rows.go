@@ -0,0 +1,155 @@
+package filefeed
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// row maps the column names to the values of one product
+type row map[string]string
+
+// readRows calls fn for every row of the content until fn returns false
+func (s Source) readRows(r io.Reader, fn func(row) bool) error {
+	switch s.Format {
+	case FormatCSV:
+		delimiter := ','
+		if s.Delimiter != "" {
+			delimiter = []rune(s.Delimiter)[0]
+		}
+		return readCSV(r, delimiter, fn)
+	case FormatTSV:
+		return readCSV(r, '\t', fn)
+	case FormatXML:
+		return readXML(r, s.Rows, fn)
+	}
+	return fmt.Errorf("Unknown format %q", s.Format)
+}
+
+func readCSV(r io.Reader, delimiter rune, fn func(row) bool) error {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("Read header - %v", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		line++
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Read line %d - %v", line, err)
+		}
+
+		values := make(row, len(header))
+		for i := range record {
+			if i < len(header) {
+				values[header[i]] = strings.TrimSpace(record[i])
+			}
+		}
+		if !fn(values) {
+			return nil
+		}
+	}
+}
+
+// readXML streams the elements selected by path, a row holds the text of the descendants
+// by their path relative to the row element, e.g. price or shipping/price, and attributes as @name.
+// Only local names are used, so g:price is price. Repeated elements are joined with commas
+func readXML(r io.Reader, path string, fn func(row) bool) error {
+	var (
+		decoder  = xml.NewDecoder(r)
+		selector = strings.Split(strings.Trim(path, "/"), "/")
+		anywhere = strings.HasPrefix(path, "//") || !strings.HasPrefix(path, "/")
+		stack    []string
+		values   row
+		depth    int // depth of the current row element, 0 outside of rows
+		text     strings.Builder
+	)
+	decoder.Strict = false
+
+	add := func(key, value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		if values[key] != "" {
+			value = values[key] + ", " + value
+		}
+		values[key] = value
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Read xml - %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if depth == 0 && matches(stack, selector, anywhere) {
+				depth = len(stack)
+				values = make(row)
+			}
+			if depth == 0 {
+				continue
+			}
+			prefix := strings.Join(stack[depth:], "/")
+			if prefix != "" {
+				prefix += "/"
+			}
+			for _, attr := range t.Attr {
+				add(prefix+"@"+attr.Name.Local, attr.Value)
+			}
+			text.Reset()
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if depth > 0 && len(stack) > depth {
+				add(strings.Join(stack[depth:], "/"), text.String())
+				text.Reset()
+			}
+			if depth > 0 && len(stack) == depth {
+				depth = 0
+				if !fn(values) {
+					return nil
+				}
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// matches reports whether the element stack is selected, anywhere allows any ancestors
+func matches(stack, selector []string, anywhere bool) bool {
+	if len(stack) < len(selector) || (!anywhere && len(stack) != len(selector)) {
+		return false
+	}
+	offset := len(stack) - len(selector)
+	for i := range selector {
+		if selector[i] != stack[offset+i] {
+			return false
+		}
+	}
+	return true
+}
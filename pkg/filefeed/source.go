@@ -0,0 +1,202 @@
+package filefeed
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Formats of a Source
+const (
+	FormatCSV = "csv"
+	FormatTSV = "tsv"
+	FormatXML = "xml"
+	// FormatGoogle is a Google Merchant Center feed, the columns are predefined
+	FormatGoogle = "google"
+)
+
+// Fields the columns and constants can be set for, the retailer fields describe the single offer of a row
+var Fields = []string{
+	"sku",
+	"name",
+	"description",
+	"short_description",
+	"brand",
+	"image_url",
+	"color",
+	"gender",
+	"categories",
+	"material",
+	"retailer.name",
+	"retailer.logo",
+	"retailer.link",
+	"retailer.price",
+	"retailer.highest_price",
+	"retailer.currency",
+	"retailer.availability",
+	"retailer.delivery_time",
+	"retailer.shipping_cost",
+	"retailer.sizes",
+}
+
+// required fields need a column or a constant
+var required = []string{
+	"sku",
+	"name",
+	"retailer.link",
+	"retailer.price",
+}
+
+// Source describes a merchant feed file and how its columns map to the product fields.
+// Gzip and zip compressed files are detected by their content
+type Source struct {
+	Name      string            `yaml:"name"`
+	ID        int32             `yaml:"id"`        // feed id stored in the products, derived from the name if empty
+	Location  string            `yaml:"location"`  // http(s) URL or local path
+	Format    string            `yaml:"format"`    // csv, tsv, xml, or google
+	Delimiter string            `yaml:"delimiter"` // csv only, comma by default
+	Rows      string            `yaml:"rows"`      // xml only, path of the product elements, e.g. /rss/channel/item or //item
+	Columns   map[string]string `yaml:"columns"`   // field to comma separated columns, the first non-empty one wins
+	Constants map[string]string `yaml:"constants"` // field to a fixed value, used if the columns are empty
+}
+
+// Check returns the problems of the source
+func (s Source) Check() (problems []string) {
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if s.Name == "" {
+		add("name is missing")
+	}
+	if s.Location == "" {
+		add("location is missing")
+	}
+	switch s.Format {
+	case FormatCSV, FormatTSV:
+		if len([]rune(s.Delimiter)) > 1 {
+			add("delimiter must be a single character, not %q", s.Delimiter)
+		}
+	case FormatXML:
+		if strings.Trim(s.Rows, "/") == "" {
+			add("rows must select the product elements of the xml, e.g. //item")
+		}
+	case FormatGoogle:
+		if len(s.Columns) > 0 {
+			add("columns are predefined for the %s format, use constants for the missing fields", FormatGoogle)
+		}
+	default:
+		add("format must be %s, %s, %s or %s, not %q", FormatCSV, FormatTSV, FormatXML, FormatGoogle, s.Format)
+	}
+
+	for field := range s.Columns {
+		if !contains(Fields, field) {
+			add("columns: unknown field %q, expected one of %s", field, strings.Join(Fields, ", "))
+		}
+	}
+	for field := range s.Constants {
+		if !contains(Fields, field) {
+			add("constants: unknown field %q, expected one of %s", field, strings.Join(Fields, ", "))
+		}
+	}
+	for _, field := range required {
+		if s.Format != FormatGoogle && s.Columns[field] == "" && s.Constants[field] == "" {
+			add("%s needs a column", field)
+		}
+	}
+
+	return problems
+}
+
+// open returns the uncompressed content of the source
+func (s Source) open() (io.ReadCloser, error) {
+	var (
+		body io.ReadCloser
+		err  error
+	)
+	if strings.HasPrefix(s.Location, "http://") || strings.HasPrefix(s.Location, "https://") {
+		body, err = download(s.Location)
+	} else {
+		body, err = os.Open(s.Location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(body)
+	magic, _ := r.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("Read gzip - %v", err)
+		}
+		return readCloser{gz, body}, nil
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		defer body.Close()
+		return unzip(r)
+	}
+	return readCloser{r, body}, nil
+}
+
+func download(url string) (io.ReadCloser, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Minute,
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Download failed with status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// unzip returns the first file of the archive
+func unzip(r io.Reader) (io.ReadCloser, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("Read zip - %v", err)
+	}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		return f.Open()
+	}
+	return nil, fmt.Errorf("Empty zip archive")
+}
+
+// readCloser closes the underlying body of a wrapping reader
+type readCloser struct {
+	io.Reader
+	body io.Closer
+}
+
+func (r readCloser) Close() error {
+	return r.body.Close()
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
@@ -72,15 +72,28 @@ func saveToken(path string, token *oauth2.Token) {
 	json.NewEncoder(f).Encode(token)
 }
 
+// Credentials replace config/credentials.json and config/token.json, empty fields fall back to the files
+type Credentials struct {
+	Client []byte
+	Token  []byte
+}
+
 // LoadFromGSheet reads a Google Sheet with a given ID and returns rows and columns as an interface array
 func LoadFromGSheet(spreadsheetID, readRange string) ([][]interface{}, error) {
-	credFile := helpers.FindFolderDir("gofeedyourself") + "/config/credentials.json"
-
-	//configPath := fmt.Sprintf("%s/cmd/feedservice/%s", filepath.Dir(ex), "config/credentials.json")
+	return LoadWithCredentials(Credentials{}, spreadsheetID, readRange)
+}
 
-	b, err := ioutil.ReadFile(credFile)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to read client secret file: %v", err)
+// LoadWithCredentials reads a Google Sheet like LoadFromGSheet with the given credentials
+func LoadWithCredentials(creds Credentials, spreadsheetID, readRange string) ([][]interface{}, error) {
+	b := creds.Client
+	if len(b) == 0 {
+		credFile := helpers.FindFolderDir("gofeedyourself") + "/config/credentials.json"
+
+		var err error
+		b, err = ioutil.ReadFile(credFile)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read client secret file: %v", err)
+		}
 	}
 
 	// If modifying these scopes, delete your previously saved token.json.
@@ -88,7 +101,18 @@ func LoadFromGSheet(spreadsheetID, readRange string) ([][]interface{}, error) {
 	if err != nil {
 		log.Fatalf("Unable to parse client secret file to config: %v", err)
 	}
-	client := getClient(config)
+
+	var client *http.Client
+	if len(creds.Token) > 0 {
+		tok := &oauth2.Token{}
+		err = json.Unmarshal(creds.Token, tok)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse token: %v", err)
+		}
+		client = config.Client(context.Background(), tok)
+	} else {
+		client = getClient(config)
+	}
 
 	srv, err := sheets.New(client)
 	if err != nil {
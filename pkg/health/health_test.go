@@ -0,0 +1,82 @@
+// +build unit
+// +build !integration
+
+package health
+
+import (
+	"testing"
+
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+func testProducts(n int, price float32, currency string) []feed.Product {
+	products := make([]feed.Product, n)
+	for i := range products {
+		products[i] = feed.Product{
+			Name:        "Shirt",
+			LowestPrice: price,
+			Categories:  []int32{int32(i % 10)},
+			ColorGroups: []string{"blue"},
+			Retailers: []feed.Retailer{
+				{
+					Currency:     currency,
+					Availability: "instock",
+				},
+			},
+		}
+	}
+	return products
+}
+
+func TestProfile(t *testing.T) {
+	products := testProducts(4, 100, "SEK")
+	products[0].LowestPrice = 10
+	products[1].Retailers[0].Availability = "outofstock"
+	products[2].ColorGroups = nil
+
+	p := NewProfile(products)
+	if p.Products != 4 || p.InStockShare != 0.75 || p.MedianPrice != 100 || p.Currency != "SEK" ||
+		p.Categories != 4 || p.MappingHitRate != 0.75 {
+		t.Fatalf("Wrong profile - %+v", p)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	m, err := NewMonitor(nil, ActionQuarantine, Thresholds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Inspect("Awin - SE", testProducts(1000, 300, "SEK")) {
+		t.Fatalf("First download must not be quarantined")
+	}
+	records := m.Records()
+	if records["Awin - SE"].Runs != 1 {
+		t.Fatalf("Baseline not stored - %+v", records)
+	}
+
+	// the merchant switched currency and half the products are gone
+	m, _ = NewMonitor(records, ActionQuarantine, Thresholds{})
+	if m.Inspect("Awin - SE", testProducts(480, 30, "EUR")) {
+		t.Fatalf("Drifted feed should be quarantined")
+	}
+	if q := m.Quarantined(); len(q) != 1 || len(m.Problems()) != 1 {
+		t.Fatalf("Wrong quarantine - %v %v", q, m.Problems())
+	}
+	anomalies := m.Records()["Awin - SE"].Anomalies
+	if len(anomalies) != 3 {
+		t.Fatalf("Expected count, price and currency anomalies - %+v", anomalies)
+	}
+	records = m.Records()
+	if records["Awin - SE"].Baseline.Products != 1000 || records["Awin - SE"].Runs != 1 {
+		t.Fatalf("Quarantined download changed the baseline - %+v", records["Awin - SE"])
+	}
+
+	err = Accept(records, "Awin - SE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ = NewMonitor(records, ActionFlag, Thresholds{})
+	if !m.Inspect("Awin - SE", testProducts(490, 31, "EUR")) || len(m.Problems()) != 0 {
+		t.Fatalf("Accepted profile should pass - %v", m.Problems())
+	}
+}
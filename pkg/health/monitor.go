@@ -0,0 +1,215 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/cache"
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
+)
+
+const (
+	// ActionFlag only reports anomalous feeds
+	ActionFlag = "flag"
+	// ActionQuarantine drops the products of anomalous feeds from the run
+	ActionQuarantine = "quarantine"
+
+	// recordsKey is the cache key under which the records are stored
+	recordsKey = "feed_health"
+	// minWeight of the latest download in the baseline, lower weights are used for the first runs
+	minWeight = 0.2
+)
+
+// Record is the health of one feed kept between runs
+type Record struct {
+	Baseline    Profile   `json:"baseline"`
+	Latest      Profile   `json:"latest"`
+	Runs        int       `json:"runs"` // downloads in the baseline
+	Anomalies   []Anomaly `json:"anomalies,omitempty"`
+	Quarantined bool      `json:"quarantined"`
+	Checked     time.Time `json:"checked"`
+}
+
+// Monitor compares the feeds of a run against their records, it is safe for concurrent use
+type Monitor struct {
+	mux        *sync.Mutex
+	action     string
+	thresholds Thresholds
+	records    map[string]Record
+	checked    map[string]struct{}
+}
+
+// NewMonitor returns a Monitor for the stored records, action is ActionFlag or ActionQuarantine
+func NewMonitor(records map[string]Record, action string, t Thresholds) (*Monitor, error) {
+	if action == "" {
+		action = ActionFlag
+	}
+	if action != ActionFlag && action != ActionQuarantine {
+		return nil, fmt.Errorf("Unknown health action %q, expected %s or %s", action, ActionFlag, ActionQuarantine)
+	}
+	if records == nil {
+		records = make(map[string]Record)
+	}
+	return &Monitor{
+		mux:        new(sync.Mutex),
+		action:     action,
+		thresholds: t,
+		records:    records,
+		checked:    make(map[string]struct{}),
+	}, nil
+}
+
+// Inspect implements feed.Inspector, it returns false for a quarantined feed
+func (m *Monitor) Inspect(name string, products []feed.Product) bool {
+	latest := NewProfile(products)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	r, known := m.records[name]
+	r.Latest = latest
+	r.Checked = time.Now()
+	r.Anomalies = nil
+	if known && r.Runs > 0 {
+		r.Anomalies = Compare(r.Baseline, latest, m.thresholds)
+	}
+	r.Quarantined = len(r.Anomalies) > 0 && m.action == ActionQuarantine
+	m.records[name] = r
+	m.checked[name] = struct{}{}
+
+	for _, a := range r.Anomalies {
+		metrics.FeedAnomalies.Inc(name, a.Metric)
+		log.WithFields(
+			log.Fields{
+				"Feed":    name,
+				"Anomaly": a.Message,
+				"Action":  m.action,
+			},
+		).Warnln("Feed drifted from its profile")
+	}
+	quarantined := 0.0
+	if r.Quarantined {
+		quarantined = 1
+	}
+	metrics.FeedQuarantined.Set(quarantined, name)
+
+	return !r.Quarantined
+}
+
+// Quarantined returns the names of the feeds held back in this run
+func (m *Monitor) Quarantined() (names []string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for name := range m.checked {
+		if m.records[name].Quarantined {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Problems describes the anomalies of this run, one line per feed
+func (m *Monitor) Problems() (problems []string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for name := range m.checked {
+		r := m.records[name]
+		if len(r.Anomalies) == 0 {
+			continue
+		}
+		messages := make([]string, len(r.Anomalies))
+		for i := range r.Anomalies {
+			messages[i] = r.Anomalies[i].Message
+		}
+		state := "flagged"
+		if r.Quarantined {
+			state = "quarantined"
+		}
+		problems = append(problems, fmt.Sprintf("%s %s: %s", name, state, strings.Join(messages, ", ")))
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// Records returns the records with the downloads of this run blended into the baselines,
+// quarantined downloads are left out so a broken feed can't become the norm
+func (m *Monitor) Records() map[string]Record {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	records := make(map[string]Record, len(m.records))
+	for name, r := range m.records {
+		if _, ok := m.checked[name]; ok && !r.Quarantined {
+			r = r.accept()
+		}
+		records[name] = r
+	}
+	return records
+}
+
+// accept blends the latest profile into the baseline
+func (r Record) accept() Record {
+	if r.Runs == 0 {
+		r.Baseline = r.Latest
+	} else {
+		weight := 1 / float64(r.Runs+1)
+		if weight < minWeight {
+			weight = minWeight
+		}
+		r.Baseline = r.Baseline.blend(r.Latest, weight)
+	}
+	r.Runs++
+	return r
+}
+
+// Accept makes the latest profile of a feed its new baseline, e.g. after an intended change of the feed
+func Accept(records map[string]Record, name string) error {
+	r, ok := records[name]
+	if !ok {
+		return fmt.Errorf("No health record for feed %s", name)
+	}
+	r.Baseline = r.Latest
+	r.Runs = 1
+	r.Anomalies = nil
+	r.Quarantined = false
+	records[name] = r
+	return nil
+}
+
+// LoadRecords reads the records from the cache
+func LoadRecords(c cache.Cache) (map[string]Record, error) {
+	records := make(map[string]Record)
+
+	stored, err := c.LoadAll()
+	if err != nil {
+		return records, fmt.Errorf("Load feed health - %v", err)
+	}
+	raw, exists := stored[recordsKey]
+	if !exists {
+		return records, nil
+	}
+	err = json.Unmarshal(raw, &records)
+	if err != nil {
+		return records, fmt.Errorf("Parse feed health - %v", err)
+	}
+	return records, nil
+}
+
+// StoreRecords writes the records to the cache
+func StoreRecords(c cache.Cache, records map[string]Record) error {
+	raw, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return c.Store(map[string][]byte{recordsKey: raw})
+}
@@ -0,0 +1,187 @@
+package health
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// Profile summarizes the products of one feed download
+type Profile struct {
+	Products       int     `json:"products"`
+	InStockShare   float64 `json:"in_stock_share"`
+	MedianPrice    float64 `json:"median_price"`
+	Currency       string  `json:"currency"`   // most common retailer currency
+	Categories     int     `json:"categories"` // distinct categories the products are sorted into
+	MappingHitRate float64 `json:"mapping_hit_rate"`
+}
+
+// NewProfile computes the Profile of a feed's products
+func NewProfile(products []feed.Product) Profile {
+	var (
+		p          = Profile{Products: len(products)}
+		inStock    int
+		mapped     int
+		prices     []float64
+		currencies = make(map[string]int)
+		categories = make(map[int32]struct{})
+	)
+	if len(products) == 0 {
+		return p
+	}
+
+	for i := range products {
+		if products[i].LowestPrice > 0 {
+			prices = append(prices, float64(products[i].LowestPrice))
+		}
+		for _, r := range products[i].Retailers {
+			if r.Availability == "instock" {
+				inStock++
+				break
+			}
+		}
+		for _, r := range products[i].Retailers {
+			if r.Currency != "" {
+				currencies[r.Currency]++
+				break
+			}
+		}
+		for _, c := range products[i].Categories {
+			categories[c] = struct{}{}
+		}
+		if len(products[i].Categories) > 0 && len(products[i].ColorGroups) > 0 {
+			mapped++
+		}
+	}
+
+	p.InStockShare = float64(inStock) / float64(len(products))
+	p.MappingHitRate = float64(mapped) / float64(len(products))
+	p.Categories = len(categories)
+	p.MedianPrice = median(prices)
+	for currency, n := range currencies {
+		if n > currencies[p.Currency] || (n == currencies[p.Currency] && currency < p.Currency) {
+			p.Currency = currency
+		}
+	}
+
+	return p
+}
+
+// blend moves the baseline towards the latest profile, weight is the share of the latest one
+func (p Profile) blend(latest Profile, weight float64) Profile {
+	mix := func(a, b float64) float64 {
+		return a*(1-weight) + b*weight
+	}
+	return Profile{
+		Products:       int(math.Round(mix(float64(p.Products), float64(latest.Products)))),
+		InStockShare:   mix(p.InStockShare, latest.InStockShare),
+		MedianPrice:    mix(p.MedianPrice, latest.MedianPrice),
+		Currency:       latest.Currency,
+		Categories:     int(math.Round(mix(float64(p.Categories), float64(latest.Categories)))),
+		MappingHitRate: mix(p.MappingHitRate, latest.MappingHitRate),
+	}
+}
+
+// Anomaly is a drift of one metric past its threshold
+type Anomaly struct {
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Latest   float64 `json:"latest"`
+	Message  string  `json:"message"`
+}
+
+// Thresholds for the drift between the baseline and the latest profile, zero values mean the defaults apply
+type Thresholds struct {
+	MaxCountDrop    float64 // relative drop of the product count
+	MaxInStockShift float64 // absolute change of the in stock share
+	MaxPriceShift   float64 // relative change of the median price
+	MaxCategoryDrop float64 // relative drop of the distinct categories
+	MaxMappingDrop  float64 // absolute drop of the mapping hit rate
+}
+
+// DefaultThresholds are used for unset thresholds
+var DefaultThresholds = Thresholds{
+	MaxCountDrop:    0.3,
+	MaxInStockShift: 0.25,
+	MaxPriceShift:   0.4,
+	MaxCategoryDrop: 0.3,
+	MaxMappingDrop:  0.15,
+}
+
+func (t Thresholds) withDefaults() Thresholds {
+	if t.MaxCountDrop <= 0 {
+		t.MaxCountDrop = DefaultThresholds.MaxCountDrop
+	}
+	if t.MaxInStockShift <= 0 {
+		t.MaxInStockShift = DefaultThresholds.MaxInStockShift
+	}
+	if t.MaxPriceShift <= 0 {
+		t.MaxPriceShift = DefaultThresholds.MaxPriceShift
+	}
+	if t.MaxCategoryDrop <= 0 {
+		t.MaxCategoryDrop = DefaultThresholds.MaxCategoryDrop
+	}
+	if t.MaxMappingDrop <= 0 {
+		t.MaxMappingDrop = DefaultThresholds.MaxMappingDrop
+	}
+	return t
+}
+
+// Compare returns the anomalies of the latest profile against the baseline
+func Compare(baseline, latest Profile, t Thresholds) (anomalies []Anomaly) {
+	t = t.withDefaults()
+	add := func(metric string, base, now float64, format string) {
+		anomalies = append(
+			anomalies,
+			Anomaly{
+				Metric:   metric,
+				Baseline: base,
+				Latest:   now,
+				Message:  fmt.Sprintf(format, base, now),
+			},
+		)
+	}
+
+	base, now := float64(baseline.Products), float64(latest.Products)
+	if base > 0 && (base-now)/base > t.MaxCountDrop {
+		add("products", base, now, "Product count dropped from %.0f to %.0f")
+	}
+	if math.Abs(latest.InStockShare-baseline.InStockShare) > t.MaxInStockShift {
+		add("in_stock_share", baseline.InStockShare, latest.InStockShare, "In stock share moved from %.2f to %.2f")
+	}
+	if baseline.MedianPrice > 0 && math.Abs(latest.MedianPrice-baseline.MedianPrice)/baseline.MedianPrice > t.MaxPriceShift {
+		add("median_price", baseline.MedianPrice, latest.MedianPrice, "Median price moved from %.2f to %.2f")
+	}
+	if baseline.Currency != "" && latest.Currency != "" && baseline.Currency != latest.Currency {
+		anomalies = append(
+			anomalies,
+			Anomaly{
+				Metric:  "currency",
+				Message: fmt.Sprintf("Currency switched from %s to %s", baseline.Currency, latest.Currency),
+			},
+		)
+	}
+	base, now = float64(baseline.Categories), float64(latest.Categories)
+	if base > 0 && (base-now)/base > t.MaxCategoryDrop {
+		add("categories", base, now, "Categories dropped from %.0f to %.0f")
+	}
+	if baseline.MappingHitRate-latest.MappingHitRate > t.MaxMappingDrop {
+		add("mapping_hit_rate", baseline.MappingHitRate, latest.MappingHitRate, "Mapping hit rate dropped from %.2f to %.2f")
+	}
+
+	return anomalies
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
@@ -0,0 +1,49 @@
+package metrics
+
+// Default holds the metrics of the feed service
+var Default = NewRegistry()
+
+var (
+	// FeedProducts - products downloaded per feed in the latest run
+	FeedProducts = Default.NewGauge("gfy_feed_products", "Products downloaded per feed in the latest run", "feed")
+	// FeedDownloadSeconds - duration of the latest download per feed
+	FeedDownloadSeconds = Default.NewGauge("gfy_feed_download_seconds", "Duration of the latest download per feed", "feed")
+	// FeedErrors - failed feed downloads
+	FeedErrors = Default.NewCounter("gfy_feed_errors_total", "Failed feed downloads", "feed")
+	// FeedAnomalies - drifts of a feed's profile past the health thresholds, by metric
+	FeedAnomalies = Default.NewCounter("gfy_feed_anomalies_total", "Drifts of a feed profile past the health thresholds", "feed", "metric")
+	// FeedQuarantined - 1 if the feed was held back in the latest run
+	FeedQuarantined = Default.NewGauge("gfy_feed_quarantined", "Feeds held back in the latest run", "feed")
+
+	// MappingLookups - lookups in the mapping tables, result is hit or miss
+	MappingLookups = Default.NewCounter("gfy_mapping_lookups_total", "Lookups in the mapping tables", "mapping", "result")
+
+	// SyncProducts - products per group of the latest prepared update: create, update or delete
+	SyncProducts = Default.NewGauge("gfy_sync_products", "Products per group of the latest prepared update", "group")
+
+	// WCRequestSeconds - latency of the requests to the WooCommerce API
+	WCRequestSeconds = Default.NewHistogram(
+		"gfy_wc_request_duration_seconds",
+		"Latency of the requests to the WooCommerce API",
+		[]float64{0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		"method",
+	)
+	// WCResponses - responses of the WooCommerce API by status code, error if there was no response
+	WCResponses = Default.NewCounter("gfy_wc_responses_total", "Responses of the WooCommerce API by status code", "method", "code")
+
+	// CacheLookups - cache reads, result is hit or miss
+	CacheLookups = Default.NewCounter("gfy_cache_lookups_total", "Cache reads", "cache", "result")
+
+	// PeakMemory - highest heap allocation seen during the run
+	PeakMemory = Default.NewGauge("gfy_memory_peak_bytes", "Highest heap allocation seen during the run")
+	// StageSeconds - duration of the latest execution of a pipeline stage
+	StageSeconds = Default.NewGauge("gfy_stage_duration_seconds", "Duration of the latest execution of a pipeline stage", "stage")
+)
+
+// Result returns the result label of a lookup
+func Result(hit bool) string {
+	if hit {
+		return "hit"
+	}
+	return "miss"
+}
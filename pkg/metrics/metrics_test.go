@@ -0,0 +1,83 @@
+// +build unit
+// +build !integration
+
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	products := r.NewGauge("test_products", "Products per feed", "feed")
+	errs := r.NewCounter("test_errors_total", "Errors")
+	latency := r.NewHistogram("test_latency_seconds", "Latency", []float64{1, 0.5}, "method")
+
+	products.Set(120, `Awin "SE"`)
+	products.Set(80, "Tradedoubler - SE")
+	errs.Inc()
+	errs.Add(2)
+	latency.Observe(0.2, "GET")
+	latency.Observe(0.7, "GET")
+	latency.Observe(3, "GET")
+	// wrong number of labels is dropped
+	products.Set(1)
+
+	var b bytes.Buffer
+	err := r.Write(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, line := range []string{
+		"# TYPE test_products gauge",
+		`test_products{feed="Awin \"SE\""} 120`,
+		`test_products{feed="Tradedoubler - SE"} 80`,
+		"test_errors_total 3",
+		`test_latency_seconds_bucket{method="GET",le="0.5"} 1`,
+		`test_latency_seconds_bucket{method="GET",le="1"} 2`,
+		`test_latency_seconds_bucket{method="GET",le="+Inf"} 3`,
+		`test_latency_seconds_count{method="GET"} 3`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("Missing %q in\n%s", line, out)
+		}
+	}
+	if strings.Count(out, "test_products{") != 2 {
+		t.Fatalf("Unexpected series in\n%s", out)
+	}
+}
+
+func TestPush(t *testing.T) {
+	var (
+		path string
+		body []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		path = req.URL.Path
+		body, _ = ioutil.ReadAll(req.Body)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	r := NewRegistry()
+	r.NewGauge("test_up", "Up").Set(1)
+
+	err := r.Push(srv.URL+"/", "feedservice_production")
+	if err != nil {
+		t.Fatalf("Push - %v", err)
+	}
+	if path != "/metrics/job/feedservice_production" || !strings.Contains(string(body), "test_up 1\n") {
+		t.Fatalf("Wrong push - %s: %s", path, body)
+	}
+}
@@ -0,0 +1,300 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+
+	// contentType of the Prometheus text exposition format
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// series is a single combination of label values of a metric
+type series struct {
+	values  []string
+	value   float64
+	buckets []uint64 // histograms only, not cumulative
+	sum     float64
+	count   uint64
+}
+
+type metric struct {
+	mux     *sync.Mutex
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+// get returns the series of the label values, nil if the number of values doesn't match the labels
+func (m *metric) get(values []string) *series {
+	if len(values) != len(m.labels) {
+		log.WithFields(
+			log.Fields{
+				"Metric": m.name,
+				"Labels": m.labels,
+				"Values": values,
+			},
+		).Warnln("Dropped metric with wrong labels")
+		return nil
+	}
+
+	key := strings.Join(values, "\xff")
+	s, exists := m.series[key]
+	if !exists {
+		s = &series{
+			values:  append([]string{}, values...),
+			buckets: make([]uint64, len(m.buckets)),
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Counter only goes up, e.g. the number of failed downloads
+type Counter struct {
+	m *metric
+}
+
+// Add adds v to the series of the label values
+func (c Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	c.m.mux.Lock()
+	defer c.m.mux.Unlock()
+
+	s := c.m.get(values)
+	if s != nil {
+		s.value += v
+	}
+}
+
+// Inc adds one to the series of the label values
+func (c Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Gauge is a value that can go up and down, e.g. the number of products in a feed
+type Gauge struct {
+	m *metric
+}
+
+// Set sets the series of the label values to v
+func (g Gauge) Set(v float64, values ...string) {
+	g.m.mux.Lock()
+	defer g.m.mux.Unlock()
+
+	s := g.m.get(values)
+	if s != nil {
+		s.value = v
+	}
+}
+
+// Histogram counts observations in buckets, e.g. request latencies
+type Histogram struct {
+	m *metric
+}
+
+// Observe adds v to the series of the label values
+func (h Histogram) Observe(v float64, values ...string) {
+	h.m.mux.Lock()
+	defer h.m.mux.Unlock()
+
+	s := h.m.get(values)
+	if s == nil {
+		return
+	}
+	for i := range h.m.buckets {
+		if v <= h.m.buckets[i] {
+			s.buckets[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// Registry holds metrics and writes them in the Prometheus text format
+type Registry struct {
+	mux     *sync.Mutex
+	metrics []*metric
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		mux: new(sync.Mutex),
+	}
+}
+
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *metric {
+	m := &metric{
+		mux:     new(sync.Mutex),
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+
+	r.mux.Lock()
+	r.metrics = append(r.metrics, m)
+	r.mux.Unlock()
+
+	return m
+}
+
+// NewCounter registers a Counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) Counter {
+	return Counter{r.register(name, help, kindCounter, nil, labels)}
+}
+
+// NewGauge registers a Gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) Gauge {
+	return Gauge{r.register(name, help, kindGauge, nil, labels)}
+}
+
+// NewHistogram registers a Histogram with the given upper bounds of the buckets and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) Histogram {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	return Histogram{r.register(name, help, kindHistogram, sorted, labels)}
+}
+
+// Write writes all metrics in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mux.Lock()
+	metrics := append([]*metric{}, r.metrics...)
+	r.mux.Unlock()
+
+	var b bytes.Buffer
+	for _, m := range metrics {
+		m.write(&b)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (m *metric) write(b *bytes.Buffer) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", m.name, m.kind)
+
+	keys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := m.series[k]
+		if m.kind != kindHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", m.name, formatLabels(m.labels, s.values), formatValue(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i := range m.buckets {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", m.name, bucketLabels(m.labels, s.values, formatValue(m.buckets[i])), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", m.name, bucketLabels(m.labels, s.values, "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", m.name, formatLabels(m.labels, s.values), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", m.name, formatLabels(m.labels, s.values), s.count)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i := range names {
+		pairs[i] = names[i] + `="` + labelEscaper.Replace(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// bucketLabels adds the upper bound of a histogram bucket to the labels
+func bucketLabels(names, values []string, le string) string {
+	return formatLabels(
+		append(append([]string{}, names...), "le"),
+		append(append([]string{}, values...), le),
+	)
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler serves the metrics, e.g. on /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", contentType)
+		err := r.Write(rw)
+		if err != nil {
+			log.WithField("Error", err).Warnln("Failed to write metrics")
+		}
+	})
+}
+
+// Push sends all metrics to a Prometheus push gateway, so one-off runs can be scraped after they exited.
+// The metrics of an earlier push of the same job are replaced.
+func (r *Registry) Push(gateway, job string) error {
+	var b bytes.Buffer
+	err := r.Write(&b)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPut,
+		strings.TrimRight(gateway, "/")+"/metrics/job/"+url.PathEscape(job),
+		&b,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Push gateway responded %s", resp.Status)
+	}
+	return nil
+}
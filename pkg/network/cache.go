@@ -0,0 +1,72 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/cache"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+)
+
+// OpenCache opens the product cache of a feed in the cache folder of the repository
+func OpenCache(name string, ttl time.Duration) (c cache.Cache, err error) {
+	path := helpers.FindFolderDir("gofeedyourself") + "/cache/"
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		os.Mkdir(path, os.ModePerm)
+	}
+	c, err = cache.NewBadgerCache(path+name, ttl)
+	if err != nil {
+		return c, fmt.Errorf("Initialize Cache - %v", err)
+	}
+	return c, nil
+}
+
+// StoreProducts adds a batch of products to the cache
+func StoreProducts(c cache.Cache, products []*feed.Product) error {
+	payload, err := json.Marshal(products)
+	if err != nil {
+		return fmt.Errorf("Failed to store products in cache - %v", err)
+	}
+	err = c.Store(
+		map[string][]byte{
+			fmt.Sprintf("%d", rand.Int63()): payload,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to store products in cache - %v", err)
+	}
+	return nil
+}
+
+// LoadProducts returns the cached products of all batches, inconsistent ones are skipped
+func LoadProducts(c cache.Cache, source string) (outProducts []feed.Product, err error) {
+	res, err := c.LoadAll()
+	if err != nil {
+		return outProducts, fmt.Errorf("Load cached products - %v", err)
+	}
+
+	var prod []feed.Product
+	for k := range res {
+		json.Unmarshal(res[k], &prod)
+
+		for j := range prod {
+			if prod[j].GetKey() == 0 {
+				return outProducts, fmt.Errorf("%s: Empty product in cache", source)
+			}
+			err = prod[j].Validate()
+			if err != nil {
+				log.WithField("Error", err).Debugf("%s: Inconsistent product in cache", source)
+				continue
+			}
+			outProducts = append(outProducts, prod[j])
+		}
+		prod = nil
+	}
+	return outProducts, nil
+}
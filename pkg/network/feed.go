@@ -0,0 +1,224 @@
+package network
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// Adapter is what an affiliate network implements to become a feed.
+// The Feed pages through the products, fetches the pages concurrently with retries,
+// converts and caches the products
+type Adapter interface {
+	// Name identifies the network, e.g. CJ
+	Name() string
+	// Page returns the request of the products from offset on, at most size of them
+	Page(offset, size int) (Request, error)
+	// Decode returns the products of a page response and the number of products of all pages
+	Decode(raw []byte) (products []Product, total int, err error)
+}
+
+// Product is a network product that converts to a feed product
+type Product interface {
+	ToFeedProduct() (*feed.Product, error)
+}
+
+// Options of a Feed, zero values are replaced by the defaults of NewFeed
+type Options struct {
+	PageSize        int
+	SampleSize      int           // products downloaded when not in production mode
+	ProductionLimit int           // products downloaded in production mode, 0 means no limit
+	Concurrency     int           // pages fetched at the same time
+	Retries         int           // retries of a failed page
+	CacheTTL        time.Duration // reuse the converted products in production mode, 0 disables the cache
+}
+
+// Feed implements feed.Feed for an Adapter
+type Feed struct {
+	adapter Adapter
+	locale  *feed.Locale
+	opts    Options
+}
+
+// NewFeed returns the feed of the adapter
+func NewFeed(adapter Adapter, locale *feed.Locale, opts Options) *Feed {
+	if opts.PageSize <= 0 {
+		opts.PageSize = 100
+	}
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = 5000
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = 2
+	}
+	return &Feed{
+		adapter: adapter,
+		locale:  locale,
+		opts:    opts,
+	}
+}
+
+// GetName identifies the feed source
+func (f Feed) GetName() string {
+	return fmt.Sprintf("%s - %s", f.adapter.Name(), f.locale.TwoLetterCode)
+}
+
+// GetLocale implements the feed interface
+func (f Feed) GetLocale() *feed.Locale {
+	return f.locale
+}
+
+// Get implements the feed interface
+func (f Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
+	if !productionFlag || f.opts.CacheTTL <= 0 {
+		outProducts, err = f.download(productionFlag)
+		if err != nil {
+			return outProducts, err
+		}
+		return outProducts, nil
+	}
+
+	c, err := OpenCache(f.GetName(), f.opts.CacheTTL)
+	if err != nil {
+		return outProducts, err
+	}
+	defer c.Close()
+
+	outProducts, err = LoadProducts(c, f.adapter.Name())
+	if err != nil {
+		return outProducts, err
+	}
+	if len(outProducts) > 0 {
+		return outProducts, nil
+	}
+
+	log.WithField("Feed", f.GetName()).Infoln("Cache empty, downloading products")
+	outProducts, err = f.download(productionFlag)
+	if err != nil {
+		return outProducts, err
+	}
+	batch := make([]*feed.Product, len(outProducts))
+	for i := range outProducts {
+		batch[i] = &outProducts[i]
+	}
+	err = StoreProducts(c, batch)
+	if err != nil {
+		log.WithFields(
+			log.Fields{
+				"Feed":  f.GetName(),
+				"Error": err,
+			},
+		).Warnln("Failed to write to cache")
+	}
+	return outProducts, nil
+}
+
+func (f Feed) download(productionFlag bool) (outProducts []feed.Product, err error) {
+	limit := f.opts.SampleSize
+	if productionFlag {
+		limit = f.opts.ProductionLimit
+	}
+	products, err := Fetch(f.adapter, f.opts.PageSize, limit, f.opts.Concurrency, f.opts.Retries)
+	if err != nil {
+		return outProducts, fmt.Errorf("Loading %s Products - %v", f.adapter.Name(), err)
+	}
+	if len(products) == 0 {
+		return outProducts, fmt.Errorf("No products returned")
+	}
+
+	outProducts = Convert(f.adapter.Name(), products)
+	if len(outProducts) == 0 {
+		return outProducts, fmt.Errorf("No valid products in the feed")
+	}
+	return outProducts, nil
+}
+
+// Fetch requests the first page for the total and the others concurrently, at most limit products if limit > 0.
+// It fails if any page can't be loaded
+func Fetch(a Adapter, pageSize, limit, concurrency, retries int) (products []Product, err error) {
+	if limit > 0 && limit < pageSize {
+		pageSize = limit
+	}
+	first, err := a.Page(0, pageSize)
+	if err != nil {
+		return products, fmt.Errorf("Prepare page - %v", err)
+	}
+	raw, err := Retry(first, retries, time.Second)
+	if err != nil {
+		return products, fmt.Errorf("Request first page - %v", err)
+	}
+	products, total, err := a.Decode(raw)
+	if err != nil {
+		return products, fmt.Errorf("Decode first page - %v", err)
+	}
+	if limit > 0 && total > limit {
+		total = limit
+	}
+
+	q := NewQueue(a.Name()+" Pages", concurrency, retries)
+	for offset := pageSize; offset < total; offset += pageSize {
+		r, err := a.Page(offset, pageSize)
+		if err != nil {
+			return products, fmt.Errorf("Prepare page - %v", err)
+		}
+		q.Add(r)
+	}
+	if q.Len() > 0 {
+		// a missing page would take its products out of the shop, so the feed fails instead
+		responses, err := q.Execute(true)
+		if err != nil {
+			return products, fmt.Errorf("Request page - %v", err)
+		}
+		for i := range responses {
+			page, _, err := a.Decode(responses[i])
+			if err != nil {
+				return products, fmt.Errorf("Decode page %d - %v", i+2, err)
+			}
+			products = append(products, page...)
+		}
+	}
+
+	if limit > 0 && len(products) > limit {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+// Convert returns the active feed products, the ones that fail to convert and duplicates are dropped
+func Convert(source string, products []Product) (outProducts []feed.Product) {
+	keys := make(map[uint64]struct{}, len(products))
+	for i := range products {
+		p, err := products[i].ToFeedProduct()
+		if err != nil {
+			log.WithFields(
+				log.Fields{
+					"Error":  err,
+					"Source": source,
+				},
+			).Debugln("Dropping Product")
+			continue
+		}
+		key := p.GetKey()
+		if key == 0 {
+			log.WithField("Source", source).Debugln("Dropping Product without key")
+			continue
+		}
+		if _, exists := keys[key]; exists || !p.Active {
+			continue
+		}
+		keys[key] = struct{}{}
+		outProducts = append(outProducts, *p)
+
+		if len(outProducts)%2000 == 0 {
+			log.WithField("Converted", len(outProducts)).Infof("Convert %s Products", source)
+		}
+	}
+	log.WithField("Converted", fmt.Sprintf("%d / %d", len(outProducts), len(products))).Infof("Converted %s Products", source)
+	return outProducts
+}
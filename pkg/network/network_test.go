@@ -0,0 +1,186 @@
+// +build unit
+// +build !integration
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+type testProduct struct {
+	SKU   string `json:"sku"`
+	Stock bool   `json:"stock"`
+}
+
+func (p *testProduct) ToFeedProduct() (*feed.Product, error) {
+	if p.SKU == "" {
+		return nil, fmt.Errorf("No SKU")
+	}
+	out := &feed.Product{
+		SKU:    p.SKU,
+		Color:  "blue",
+		Active: p.Stock,
+	}
+	return out, out.SetKey()
+}
+
+// testAdapter pages through 25 products, the server fails every page once
+type testAdapter struct {
+	url string
+}
+
+func (a testAdapter) Name() string {
+	return "Test"
+}
+
+func (a testAdapter) Page(offset, size int) (Request, error) {
+	return NewGet(fmt.Sprintf("%s/products?offset=%d&size=%d", a.url, offset, size), nil), nil
+}
+
+func (a testAdapter) Decode(raw []byte) (products []Product, total int, err error) {
+	var page struct {
+		Total    int           `json:"total"`
+		Products []testProduct `json:"products"`
+	}
+	err = json.Unmarshal(raw, &page)
+	for i := range page.Products {
+		products = append(products, &page.Products[i])
+	}
+	return products, page.Total, err
+}
+
+func testServer() *httptest.Server {
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+	)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		retry := seen[r.URL.RawQuery]
+		seen[r.URL.RawQuery] = true
+		mu.Unlock()
+		if !retry {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		page := map[string]interface{}{"total": 25}
+		var products []testProduct
+		for i := offset; i < offset+size && i < 25; i++ {
+			// the last product duplicates the first one, every fifth is sold out
+			products = append(products, testProduct{SKU: strconv.Itoa(i % 24), Stock: i%5 != 3})
+		}
+		page["products"] = products
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func TestQueue(t *testing.T) {
+	RateLimitWait = time.Millisecond
+	server := testServer()
+	defer server.Close()
+
+	q := NewQueue("Test", 3, 1)
+	q.Backoff = time.Millisecond
+	for _, offset := range []int{0, 10, 0, 20} {
+		q.Add(NewGet(fmt.Sprintf("%s/products?offset=%d&size=10", server.URL, offset), nil))
+	}
+	if q.Len() != 3 {
+		t.Fatalf("Expected the duplicate to be skipped - %d requests", q.Len())
+	}
+	responses, err := q.Execute(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, _, _ := testAdapter{}.Decode(responses[2])
+	if len(products) != 5 || products[0].(*testProduct).SKU != "20" {
+		t.Fatalf("Expected the responses in order - %s", responses[2])
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Expected an empty queue after the execution")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	RateLimitWait = time.Millisecond
+	server := testServer()
+	defer server.Close()
+	a := testAdapter{url: server.URL}
+
+	products, err := Fetch(a, 10, 0, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 25 {
+		t.Fatalf("Expected all pages - %d products", len(products))
+	}
+
+	// 25 products, 5 sold out, and the duplicate
+	converted := Convert(a.Name(), products)
+	if len(converted) != 19 {
+		t.Fatalf("Expected the active unique products - %d", len(converted))
+	}
+
+	products, err = Fetch(a, 10, 15, 2, 1)
+	if err != nil || len(products) != 15 {
+		t.Fatalf("Expected the limit - %d products, %v", len(products), err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	RateLimitWait = time.Millisecond
+	limited := 5
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limited > 0 {
+			limited--
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	// rate limits are waited out without using up the retries
+	_, err := Retry(NewGet(server.URL, nil), 0, time.Millisecond)
+	if err == nil || err == ErrRateLimited || limited != 0 {
+		t.Fatalf("Expected the server error after the rate limits - %v, %d left", err, limited)
+	}
+
+	limited = 1000
+	RateLimitTimeout = 20 * time.Millisecond
+	defer func() { RateLimitTimeout = time.Hour }()
+	_, err = Retry(NewGet(server.URL, nil), 2, time.Millisecond)
+	if err == nil || limited == 0 {
+		t.Fatalf("Expected to give up after the timeout - %v", err)
+	}
+}
+
+func TestFetchMissingPage(t *testing.T) {
+	RateLimitWait = time.Millisecond
+	server := testServer()
+	defer server.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "10" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer broken.Close()
+
+	products, err := Fetch(testAdapter{url: broken.URL}, 10, 0, 2, 1)
+	if err == nil {
+		t.Fatalf("Expected the missing page to fail the feed - %d products", len(products))
+	}
+}
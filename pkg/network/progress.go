@@ -1,4 +1,4 @@
-package awinclient
+package network
 
 import (
 	"fmt"
@@ -8,12 +8,13 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-var (
-	maxMemory uint64
-	mem       runtime.MemStats
-)
+const toMeg uint64 = 1048576
 
-func progressBar(title string, completed, total int) {
+// Progress logs a progress bar of the completed steps
+func Progress(title string, completed, total int) {
+	if total == 0 {
+		return
+	}
 	progress := float64(completed) / float64(total) * 100.0
 	s := ("[")
 	for pct := 0.0; pct <= 100.0; pct += 10.0 {
@@ -28,19 +29,17 @@ func progressBar(title string, completed, total int) {
 	log.WithField("Progress", s).Info(title)
 }
 
-func memLog(message string, mem runtime.MemStats, maxMemory *uint64) {
+// MemLog logs the memory use of the process at debug level
+func MemLog(source, message string) {
+	var mem runtime.MemStats
 	runtime.ReadMemStats(&mem)
 
 	log.WithFields(log.Fields{
-		"Source":        "Awin",
+		"Source":        source,
 		"Mem Allocated": mem.Alloc / toMeg,
 		"HeapAlloc":     mem.HeapAlloc / toMeg,
 		"System Memory": mem.Sys / toMeg,
 		"Go Routines":   runtime.NumGoroutine(),
 		"Num GC":        mem.NumGC,
 	}).Debugln(message)
-
-	if mem.Alloc/toMeg > *maxMemory {
-		*maxMemory = mem.Alloc / toMeg
-	}
 }
@@ -0,0 +1,130 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Queue sends its requests concurrently with retries, duplicates are only sent once
+type Queue struct {
+	Title       string // logged with the progress
+	Concurrency int
+	Retries     int
+	Backoff     time.Duration // wait before the first retry, multiplied by the attempt for the next ones
+
+	requests []Request
+	keys     map[string]struct{}
+}
+
+// NewQueue returns an empty queue, concurrency is at least 1
+func NewQueue(title string, concurrency, retries int) *Queue {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Queue{
+		Title:       title,
+		Concurrency: concurrency,
+		Retries:     retries,
+		Backoff:     time.Second,
+		keys:        make(map[string]struct{}),
+	}
+}
+
+// Add enqueues the request unless an identical one is queued already
+func (q *Queue) Add(r Request) (added bool) {
+	key := r.URL()
+	if k, ok := r.(keyer); ok {
+		key = k.Key()
+	}
+	if q.keys == nil {
+		q.keys = make(map[string]struct{})
+	}
+	if _, exists := q.keys[key]; exists {
+		return false
+	}
+	q.keys[key] = struct{}{}
+	q.requests = append(q.requests, r)
+	return true
+}
+
+// Len returns the number of queued requests
+func (q *Queue) Len() int {
+	return len(q.requests)
+}
+
+// Execute sends the requests and empties the queue. The responses are in the order the requests were added,
+// failed requests leave a nil response unless strict, which returns the first error
+func (q *Queue) Execute(strict bool) (responses [][]byte, err error) {
+	requests := q.requests
+	q.requests, q.keys = nil, nil
+	if len(requests) == 0 {
+		return responses, fmt.Errorf("Request Queue empty")
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		done   int
+		errs   = make([]error, len(requests))
+		input  = make(chan int, len(requests))
+		total  = len(requests)
+		title  = q.Title
+		report = total / 10
+	)
+	if report < 1 {
+		report = 1
+	}
+	if title == "" {
+		title = "Execute Queue"
+	}
+	responses = make([][]byte, total)
+
+	for i := range requests {
+		input <- i
+	}
+	close(input)
+
+	workers := q.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range input {
+				responses[i], errs[i] = Retry(requests[i], q.Retries, q.Backoff)
+				if errs[i] != nil {
+					responses[i] = nil
+					log.WithFields(
+						log.Fields{
+							"Target": requests[i].URL(),
+							"Error":  errs[i],
+						},
+					).Warnln("Request failed")
+				}
+
+				mu.Lock()
+				done++
+				if done%report == 0 || done == total {
+					Progress(title, done, total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	log.WithField("Requests", total).Debugln("Queue was scheduled")
+	wg.Wait()
+
+	if strict {
+		for i := range errs {
+			if errs[i] != nil {
+				return responses, fmt.Errorf("%s - %v", requests[i].URL(), errs[i])
+			}
+		}
+	}
+	return responses, nil
+}
@@ -0,0 +1,147 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrRateLimited is returned by Send when the network asks us to slow down
+	ErrRateLimited = errors.New("Request limit exceeded")
+	// RateLimitWait is how long Retry waits after ErrRateLimited
+	RateLimitWait = time.Minute
+	// RateLimitTimeout is how long Retry keeps waiting out rate limits before it gives up on a request
+	RateLimitTimeout = time.Hour
+	// Timeout of the HTTPRequests without a client, product downloads can take a while
+	Timeout = time.Hour
+)
+
+// Request is a single call to a network API or a feed download, the URL identifies it in a Queue
+type Request interface {
+	URL() string
+	Send() ([]byte, error)
+}
+
+// keyer is implemented by requests whose URL is not unique, e.g. POSTs of different pages
+type keyer interface {
+	Key() string
+}
+
+// HTTPRequest is a Request for the JSON and file endpoints of a network
+type HTTPRequest struct {
+	Method  string
+	Address string
+	Header  map[string]string
+	Body    []byte
+	Client  *http.Client // http.Client with the package Timeout if nil
+}
+
+// NewGet returns a GET request
+func NewGet(url string, header map[string]string) *HTTPRequest {
+	return &HTTPRequest{
+		Method:  http.MethodGet,
+		Address: url,
+		Header:  header,
+	}
+}
+
+// NewPost returns a POST request of the payload encoded as JSON
+func NewPost(url string, payload interface{}, header map[string]string) (*HTTPRequest, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("Encode payload - %v", err)
+	}
+	h := map[string]string{"Content-Type": "application/json"}
+	for k, v := range header {
+		h[k] = v
+	}
+	return &HTTPRequest{
+		Method:  http.MethodPost,
+		Address: url,
+		Header:  h,
+		Body:    body,
+	}, nil
+}
+
+// URL implements the Request interface
+func (r *HTTPRequest) URL() string {
+	return r.Address
+}
+
+// Key tells requests to the same URL apart by their body
+func (r *HTTPRequest) Key() string {
+	return r.Method + " " + r.Address + " " + string(r.Body)
+}
+
+// Send implements the Request interface, it sends the request once
+func (r *HTTPRequest) Send() (raw []byte, err error) {
+	req, err := http.NewRequest(r.Method, r.Address, bytes.NewReader(r.Body))
+	if err != nil {
+		return raw, err
+	}
+	req.Header.Set("User-Agent", "Feedservice")
+	for k, v := range r.Header {
+		req.Header.Set(k, v)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: Timeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return raw, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return raw, ErrRateLimited
+	}
+	if resp.StatusCode != http.StatusOK {
+		return raw, fmt.Errorf("Request failed: %s - %s", resp.Status, req.URL.Path)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Retry sends the request up to retries more times if it fails, waiting backoff times the attempt in between.
+// Rate limits don't count as retries, the request is sent every RateLimitWait until RateLimitTimeout is over
+func Retry(r Request, retries int, backoff time.Duration) (raw []byte, err error) {
+	var deadline time.Time
+	for try, attempt := 0, 1; ; attempt++ {
+		raw, err = r.Send()
+		if err == nil {
+			return raw, nil
+		}
+		log.WithFields(
+			log.Fields{
+				"Target": r.URL(),
+				"Try":    attempt,
+				"Error":  err,
+			},
+		).Debugln("Request error")
+
+		if err == ErrRateLimited {
+			if deadline.IsZero() {
+				deadline = time.Now().Add(RateLimitTimeout)
+			}
+			if time.Now().Add(RateLimitWait).After(deadline) {
+				return raw, fmt.Errorf("%v for %v", err, RateLimitTimeout)
+			}
+			time.Sleep(RateLimitWait)
+			continue
+		}
+		if try == retries {
+			return raw, err
+		}
+		try++
+		time.Sleep(time.Duration(try) * backoff)
+	}
+}
@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/email"
+)
+
+// Conditions for sending a summary
+const (
+	WhenAlways    = "always"
+	WhenFailure   = "failure"   // the run stopped on a critical error
+	WhenErrors    = "errors"    // the run logged errors or failed
+	WhenDrop      = "drop"      // the product count dropped by more than the configured share
+	WhenDeletions = "deletions" // products were deleted
+)
+
+// Summary describes a finished run
+type Summary struct {
+	Country   string    `json:"country"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	DryRun    bool      `json:"dry_run"`
+	Feeds     []string  `json:"feeds"`
+	Products  int       `json:"products"` // loaded from the feeds
+	Create    int       `json:"create"`
+	Update    int       `json:"update"`
+	Delete    int       `json:"delete"`
+	Unchanged int       `json:"unchanged"`
+	Errors    []string  `json:"errors,omitempty"`
+	Failed    bool      `json:"failed"`
+}
+
+// Duration of the run
+func (s Summary) Duration() time.Duration {
+	return s.Finished.Sub(s.Started)
+}
+
+// DropShare returns how much the product count in the backend shrinks with the update
+func (s Summary) DropShare() float64 {
+	before := s.Update + s.Unchanged + s.Delete
+	after := s.Create + s.Update + s.Unchanged
+	if before == 0 || after >= before {
+		return 0
+	}
+	return float64(before-after) / float64(before)
+}
+
+// Subject is a one line summary
+func (s Summary) Subject() string {
+	state := "finished"
+	if s.Failed {
+		state = "FAILED"
+	} else if len(s.Errors) > 0 {
+		state = "finished with errors"
+	}
+	mode := ""
+	if s.DryRun {
+		mode = " (dry run)"
+	}
+	return fmt.Sprintf("Feed service %s%s %s", s.Country, mode, state)
+}
+
+// Text is the summary as plain text
+func (s Summary) Text() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n\n", s.Subject())
+	fmt.Fprintf(&b, "Started: %s\nDuration: %s\n", s.Started.Format("2006-01-02 15:04"), s.Duration().Round(time.Second))
+	fmt.Fprintf(&b, "Feeds: %s\nProducts: %d\n", strings.Join(s.Feeds, ", "), s.Products)
+	fmt.Fprintf(
+		&b, "Create %d, Update %d, Delete %d, Unchanged %d (%.1f%% drop)\n",
+		s.Create, s.Update, s.Delete, s.Unchanged, s.DropShare()*100,
+	)
+	if len(s.Errors) > 0 {
+		fmt.Fprintf(&b, "\nErrors:\n")
+		for i := range s.Errors {
+			fmt.Fprintf(&b, "- %s\n", s.Errors[i])
+		}
+	}
+
+	return b.String()
+}
+
+// Valid reports whether condition is one of the known conditions
+func Valid(condition string) bool {
+	switch strings.ToLower(strings.TrimSpace(condition)) {
+	case WhenAlways, WhenFailure, WhenErrors, WhenDrop, WhenDeletions:
+		return true
+	}
+	return false
+}
+
+// Matches reports whether any of the conditions in when applies to the summary
+func Matches(when []string, s Summary, maxDropShare float64) bool {
+	for _, condition := range when {
+		switch strings.ToLower(strings.TrimSpace(condition)) {
+		case WhenAlways:
+			return true
+		case WhenFailure:
+			if s.Failed {
+				return true
+			}
+		case WhenErrors:
+			if s.Failed || len(s.Errors) > 0 {
+				return true
+			}
+		case WhenDrop:
+			if maxDropShare > 0 && s.DropShare() > maxDropShare {
+				return true
+			}
+		case WhenDeletions:
+			if s.Delete > 0 {
+				return true
+			}
+		default:
+			log.WithField("Condition", condition).Warnln("Unknown notification condition")
+		}
+	}
+	return false
+}
+
+// Notifier delivers a Summary
+type Notifier interface {
+	Notify(s Summary) error
+}
+
+// Target sends to a Notifier when one of the conditions applies
+type Target struct {
+	Notifier Notifier
+	When     []string
+}
+
+// Send delivers the summary to all matching targets, failures are only logged
+func Send(targets []Target, s Summary, maxDropShare float64) {
+	for _, t := range targets {
+		if !Matches(t.When, s, maxDropShare) {
+			continue
+		}
+		err := t.Notifier.Notify(s)
+		if err != nil {
+			log.WithFields(
+				log.Fields{
+					"Notifier": fmt.Sprintf("%T", t.Notifier),
+					"Error":    err,
+				},
+			).Warnln("Failed to send run summary")
+		}
+	}
+}
+
+// Email sends the summary by SMTP
+type Email struct {
+	mail *email.Mail
+	to   []string
+}
+
+// NewEmail returns an Email notifier
+func NewEmail(mail *email.Mail, to []string) Email {
+	return Email{
+		mail: mail,
+		to:   to,
+	}
+}
+
+// Notify implements Notifier
+func (e Email) Notify(s Summary) error {
+	if len(e.to) == 0 {
+		return fmt.Errorf("No recipients")
+	}
+	return e.mail.Send(s.Subject(), s.Text(), e.to)
+}
+
+// Webhook posts the summary as JSON, the text field makes it a valid Slack message
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns a Webhook notifier
+func NewWebhook(url string) Webhook {
+	return Webhook{
+		url: url,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Notify implements Notifier
+func (w Webhook) Notify(s Summary) error {
+	payload, err := json.Marshal(
+		struct {
+			Text    string  `json:"text"`
+			Summary Summary `json:"summary"`
+		}{
+			Text:    "```" + s.Text() + "```",
+			Summary: s,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// +build unit
+// +build !integration
+
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	ok := Summary{
+		Update:    90,
+		Unchanged: 10,
+	}
+	drop := Summary{
+		Update: 50,
+		Delete: 50,
+	}
+	failed := Summary{
+		Failed: true,
+	}
+
+	var cases = []struct {
+		when    []string
+		summary Summary
+		match   bool
+	}{
+		{[]string{WhenAlways}, ok, true},
+		{[]string{WhenFailure, WhenDrop}, ok, false},
+		{[]string{WhenFailure, WhenDrop}, drop, true},
+		{[]string{WhenFailure}, failed, true},
+		{[]string{WhenErrors}, Summary{Errors: []string{"x"}}, true},
+		{[]string{WhenDeletions}, drop, true},
+		{nil, failed, false},
+	}
+	for i, c := range cases {
+		if Matches(c.when, c.summary, 0.2) != c.match {
+			t.Fatalf("Case %d: %v on %+v should be %t", i, c.when, c.summary, c.match)
+		}
+	}
+
+	if share := drop.DropShare(); share != 0.5 {
+		t.Fatalf("Wrong drop share - %f", share)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var received struct {
+		Text    string  `json:"text"`
+		Summary Summary `json:"summary"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer srv.Close()
+
+	s := Summary{
+		Country:  "SE",
+		Started:  time.Now().Add(-time.Hour),
+		Finished: time.Now(),
+		Feeds:    []string{"Awin - SE"},
+		Create:   3,
+	}
+	Send(
+		[]Target{
+			{
+				Notifier: NewWebhook(srv.URL),
+				When:     []string{WhenAlways},
+			},
+		},
+		s,
+		0.2,
+	)
+
+	if received.Text == "" || received.Summary.Create != 3 || received.Summary.Country != "SE" {
+		t.Fatalf("Wrong payload - %+v", received)
+	}
+}
@@ -0,0 +1,265 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// Features the model can weigh
+const (
+	Conversions     = "conversions"      // sales of the last days
+	Leads           = "leads"            // leads of the last days
+	Commission      = "commission"       // commission of the last days, per unit of the currency
+	EPC             = "epc"              // earnings per click of the program
+	Discount        = "discount"         // percent off the highest price
+	Freshness       = "freshness"        // 1 for an offer updated now, halved every half life
+	Retailers       = "retailers"        // number of retailers
+	InStockShare    = "in_stock_share"   // share of the retailers with the product in stock
+	WebsiteFeatures = "website_features" // features on the website
+)
+
+// Features lists the known features in the order of the explanations
+var Features = []string{
+	WebsiteFeatures,
+	Conversions,
+	Leads,
+	Commission,
+	EPC,
+	Discount,
+	Freshness,
+	Retailers,
+	InStockShare,
+}
+
+// Weights maps features to the points per unit of the feature
+type Weights map[string]float64
+
+// Model is the formula products are ranked by, the score is the sum of the weighted features
+type Model struct {
+	Weights      Weights            `yaml:"weights"`
+	HalfLifeDays float64            `yaml:"half_life_days"` // age at which a transaction or update counts half, 0 disables the decay
+	Categories   map[string]Weights `yaml:"categories"`     // weights replaced for the products of a category
+}
+
+// Default returns the formula the ranking used before it was configurable
+func Default() Model {
+	return Model{
+		Weights: Weights{
+			WebsiteFeatures: 10,
+			Conversions:     5,
+			Leads:           2,
+			Commission:      0.1,
+		},
+	}
+}
+
+// IsZero is true if no weights are set, the Default is used then
+func (m Model) IsZero() bool {
+	return len(m.Weights) == 0 && len(m.Categories) == 0
+}
+
+// Check returns the problems of the model
+func (m Model) Check() (problems []string) {
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if m.HalfLifeDays < 0 {
+		add("half_life_days must not be negative")
+	}
+	for feature := range m.Weights {
+		if !contains(Features, feature) {
+			add("weights: unknown feature %q, expected one of %s", feature, strings.Join(Features, ", "))
+		}
+	}
+	for category, weights := range m.Categories {
+		for feature := range weights {
+			if !contains(Features, feature) {
+				add("categories.%s: unknown feature %q, expected one of %s", category, feature, strings.Join(Features, ", "))
+			}
+		}
+	}
+	return problems
+}
+
+// Term is the share of a feature in a score
+type Term struct {
+	Feature string
+	Value   float64
+	Weight  float64
+	Points  float64
+}
+
+// Explanation breaks a score down into the terms of its features
+type Explanation struct {
+	Key      uint64
+	Name     string
+	Category string // category whose weights were used, empty for the default ones
+	Terms    []Term
+	Score    int32
+}
+
+// String renders the explanation as a table
+func (e Explanation) String() string {
+	var b strings.Builder
+	category := e.Category
+	if category == "" {
+		category = "default"
+	}
+	fmt.Fprintf(&b, "%s (%d) - score %d, %s weights\n", e.Name, e.Key, e.Score, category)
+
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  FEATURE\tVALUE\tWEIGHT\tPOINTS")
+	for _, t := range e.Terms {
+		fmt.Fprintf(w, "  %s\t%.2f\t%g\t%.1f\n", t.Feature, t.Value, t.Weight, t.Points)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Score ranks the product at the time now
+func (m Model) Score(p *feed.Product, now time.Time) int32 {
+	return m.Explain(p, now).Score
+}
+
+// Explain scores the product and returns the terms of the features with a weight
+func (m Model) Explain(p *feed.Product, now time.Time) Explanation {
+	category, weights := m.weights(p)
+	e := Explanation{
+		Key:      p.Key,
+		Name:     p.Name,
+		Category: category,
+	}
+
+	var sum float64
+	for _, feature := range Features {
+		weight := weights[feature]
+		if weight == 0 {
+			continue
+		}
+		t := Term{
+			Feature: feature,
+			Value:   m.value(feature, p, now),
+			Weight:  weight,
+		}
+		t.Points = t.Value * t.Weight
+		sum += t.Points
+		e.Terms = append(e.Terms, t)
+	}
+	e.Score = int32(math.Round(math.Max(math.Min(sum, math.MaxInt32), math.MinInt32)))
+	return e
+}
+
+// weights returns the default weights replaced by the ones of the first category of the product with an override
+func (m Model) weights(p *feed.Product) (category string, weights Weights) {
+	weights = make(Weights, len(m.Weights))
+	for feature, weight := range m.Weights {
+		weights[feature] = weight
+	}
+
+	names := make([]string, 0, len(m.Categories))
+	for name := range m.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, c := range p.ProviderCategories {
+		for _, name := range names {
+			if !strings.EqualFold(c.Name, name) {
+				continue
+			}
+			for feature, weight := range m.Categories[name] {
+				weights[feature] = weight
+			}
+			return name, weights
+		}
+	}
+	return "", weights
+}
+
+func (m Model) value(feature string, p *feed.Product, now time.Time) float64 {
+	switch feature {
+	case WebsiteFeatures:
+		return float64(p.WebsiteFeatures)
+	case Conversions, Leads, Commission:
+		return m.transactions(feature, p, now)
+	case EPC:
+		return float64(p.EPC)
+	case Discount:
+		return float64(p.Discount)
+	case Freshness:
+		if p.Updated == 0 {
+			return 0
+		}
+		return m.decay(time.Unix(int64(p.Updated), 0), now)
+	case Retailers:
+		return float64(len(p.Retailers))
+	case InStockShare:
+		if len(p.Retailers) == 0 {
+			return 0
+		}
+		var inStock int
+		for i := range p.Retailers {
+			if p.Retailers[i].Availability == "instock" {
+				inStock++
+			}
+		}
+		return float64(inStock) / float64(len(p.Retailers))
+	}
+	return 0
+}
+
+// transactions returns the count or commission of the product with its transactions decayed by their age,
+// the counts of the feeds without transaction times count in full
+func (m Model) transactions(feature string, p *feed.Product, now time.Time) float64 {
+	var sum float64
+	switch feature {
+	case Conversions:
+		sum = float64(p.Conversions7d)
+	case Leads:
+		sum = float64(p.Leads7d)
+	case Commission:
+		sum = float64(p.Commision7d)
+	}
+
+	for _, t := range p.Transactions {
+		if t.Time == 0 {
+			continue
+		}
+		lost := 1 - m.decay(time.Unix(int64(t.Time), 0), now)
+		switch {
+		case feature == Commission:
+			sum -= float64(t.Commission) * lost
+		case t.Lead == (feature == Leads):
+			sum -= lost
+		}
+	}
+	return sum
+}
+
+// decay is 1 for now and halves every half life
+func (m Model) decay(at, now time.Time) float64 {
+	if m.HalfLifeDays <= 0 {
+		return 1
+	}
+	days := now.Sub(at).Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+	return math.Pow(0.5, days/m.HalfLifeDays)
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
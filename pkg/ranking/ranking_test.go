@@ -0,0 +1,83 @@
+// +build unit
+// +build !integration
+
+package ranking
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+func TestModel(t *testing.T) {
+	now := time.Date(2019, 5, 15, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) int32 {
+		return int32(now.AddDate(0, 0, -days).Unix())
+	}
+	p := &feed.Product{
+		Key:             1,
+		Name:            "Dress",
+		WebsiteFeatures: 1,
+		Conversions7d:   3,
+		Leads7d:         1,
+		Commision7d:     60,
+		Discount:        30,
+		Updated:         daysAgo(7),
+		Transactions: []feed.Transaction{
+			{Time: daysAgo(0), Commission: 20},
+			{Time: daysAgo(7), Commission: 40},
+			{Time: daysAgo(7), Lead: true},
+		},
+		Retailers: []feed.Retailer{
+			{Name: "shop", Availability: "instock"},
+			{Name: "other", Availability: "outofstock"},
+		},
+		ProviderCategories: []feed.ProviderCategory{
+			{Name: "Dresses", Gender: 'w'},
+		},
+	}
+
+	if score := Default().Score(p, now); score != 1*10+3*5+1*2+6 {
+		t.Fatalf("Expected the default model to keep the old formula - %d", score)
+	}
+
+	m := Model{
+		Weights: Weights{
+			Conversions:  10,
+			Leads:        4,
+			Commission:   0.5,
+			Freshness:    8,
+			InStockShare: 10,
+			Retailers:    1,
+		},
+		HalfLifeDays: 7,
+		Categories: map[string]Weights{
+			"dresses": {Discount: 1, Retailers: 0},
+		},
+	}
+	if problems := m.Check(); len(problems) != 0 {
+		t.Fatalf("Unexpected problems - %v", problems)
+	}
+
+	e := m.Explain(p, now)
+	// 2.5 conversions, half a lead, 40 of the commission, half fresh, half in stock and 30% off
+	if e.Category != "dresses" || e.Score != 25+2+20+4+5+30 {
+		t.Fatalf("Wrong score - %s", e)
+	}
+	for _, term := range e.Terms {
+		if term.Feature == Retailers {
+			t.Fatalf("Expected the category to switch the retailers off - %s", e)
+		}
+	}
+	if !strings.Contains(e.String(), "conversions") {
+		t.Fatalf("Expected the terms in the explanation - %s", e)
+	}
+
+	m.Weights["popularity"] = 1
+	m.HalfLifeDays = -1
+	if problems := m.Check(); len(problems) != 2 {
+		t.Fatalf("Expected the unknown feature and the negative half life - %v", problems)
+	}
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Job is a scheduled run, clean is set on clean days
+type Job func(clean bool)
+
+// Daemon runs a Job on a Schedule, guarded by a lock file so runs never overlap
+type Daemon struct {
+	schedule Schedule
+	lockPath string
+	job      Job
+}
+
+// NewDaemon returns a Daemon, lockPath is shared with one-off runs of the same job
+func NewDaemon(schedule Schedule, lockPath string, job Job) *Daemon {
+	return &Daemon{
+		schedule: schedule,
+		lockPath: lockPath,
+		job:      job,
+	}
+}
+
+// Serve blocks until a signal arrives on stop, a running job is finished first
+func (d *Daemon) Serve(stop <-chan os.Signal) {
+	for {
+		next := d.schedule.Next(time.Now())
+		log.WithFields(
+			log.Fields{
+				"Next Run":  next,
+				"Clean Day": d.schedule.IsCleanDay(next),
+			},
+		).Infoln("Waiting for next run")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case sig := <-stop:
+			timer.Stop()
+			log.WithField("Signal", sig).Infoln("Shutting down")
+			return
+		case <-timer.C:
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			d.RunOnce(d.schedule.IsCleanDay(next))
+		}()
+
+		select {
+		case <-done:
+		case sig := <-stop:
+			log.WithField("Signal", sig).Infoln("Shutting down after the running job")
+			<-done
+			return
+		}
+	}
+}
+
+// RunOnce runs the job unless another run holds the lock
+func (d *Daemon) RunOnce(clean bool) bool {
+	lock, err := AcquireLock(d.lockPath)
+	if err != nil {
+		log.WithField("Reason", err).Warnln("Skipping run")
+		return false
+	}
+	defer func() {
+		err := lock.Release()
+		if err != nil {
+			log.WithField("Error", err).Warnln("Failed to release lock")
+		}
+	}()
+
+	d.job(clean)
+	return true
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrLocked is returned while another process holds the lock
+var ErrLocked = errors.New("Lock is held by another run")
+
+// Lock is a lock file holding the PID of the process running a job. The file is locked with flock,
+// so the lock is gone with the process even if the file is left behind, e.g. by a killed container
+// whose successor runs with the same PID
+type Lock struct {
+	f *os.File
+}
+
+// AcquireLock locks the file at path, it is created if needed
+func AcquireLock(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Open lock file - %v", err)
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		f.Close()
+		if err != syscall.EWOULDBLOCK {
+			return nil, fmt.Errorf("Lock file - %v", err)
+		}
+		pid, err := readPID(path)
+		if err != nil {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("%v - PID %d", ErrLocked, pid)
+	}
+
+	err = f.Truncate(0)
+	if err == nil {
+		_, err = f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Write lock file - %v", err)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release unlocks the lock file, the file stays so a waiting process never locks a removed one
+func (l *Lock) Release() error {
+	err := l.f.Truncate(0)
+	if err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+func readPID(path string) (int, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(raw)))
+}
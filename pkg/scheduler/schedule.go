@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule runs a job once a day at a fixed time, on clean days the job cleans up as well
+type Schedule struct {
+	Hour      int
+	Minute    int
+	CleanDays map[time.Weekday]struct{}
+	Location  *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Parse returns the Schedule for a time of day like "03:30" and a list of weekdays like "sunday" or "sun"
+func Parse(at string, cleanDays []string) (s Schedule, err error) {
+	s = Schedule{
+		CleanDays: make(map[time.Weekday]struct{}, len(cleanDays)),
+		Location:  time.Local,
+	}
+
+	t, err := time.Parse("15:04", strings.TrimSpace(at))
+	if err != nil {
+		return s, fmt.Errorf("Parse time of day %q, expected HH:MM - %v", at, err)
+	}
+	s.Hour, s.Minute = t.Hour(), t.Minute()
+
+	for _, day := range cleanDays {
+		weekday, err := parseWeekday(day)
+		if err != nil {
+			return s, err
+		}
+		s.CleanDays[weekday] = struct{}{}
+	}
+
+	return s, nil
+}
+
+func parseWeekday(day string) (time.Weekday, error) {
+	day = strings.ToLower(strings.TrimSpace(day))
+	if len(day) >= 3 {
+		for name, weekday := range weekdays {
+			if strings.HasPrefix(name, day) {
+				return weekday, nil
+			}
+		}
+	}
+	return time.Sunday, fmt.Errorf("Unknown clean day %q", day)
+}
+
+// Next returns the first scheduled run after now
+func (s Schedule) Next(now time.Time) time.Time {
+	now = now.In(s.Location)
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, s.Minute, 0, 0, s.Location)
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, s.Hour, s.Minute, 0, 0, s.Location)
+	}
+	return next
+}
+
+// IsCleanDay reports whether the run at t should clean up
+func (s Schedule) IsCleanDay(t time.Time) bool {
+	_, exists := s.CleanDays[t.In(s.Location).Weekday()]
+	return exists
+}
@@ -0,0 +1,123 @@
+// +build unit
+// +build !integration
+
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	s, err := Parse("03:30", []string{"Sunday", "wed"})
+	if err != nil {
+		t.Fatalf("Parse schedule - %v", err)
+	}
+	s.Location = time.UTC
+
+	// Wednesday
+	now := time.Date(2019, 10, 2, 12, 0, 0, 0, time.UTC)
+	next := s.Next(now)
+	if !next.Equal(time.Date(2019, 10, 3, 3, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Wrong next run - %v", next)
+	}
+	if !s.IsCleanDay(now) || s.IsCleanDay(next) {
+		t.Fatal("Wrong clean days")
+	}
+
+	early := time.Date(2019, 10, 2, 1, 0, 0, 0, time.UTC)
+	if next := s.Next(early); !next.Equal(time.Date(2019, 10, 2, 3, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Wrong next run on the same day - %v", next)
+	}
+
+	if _, err := Parse("3 am", nil); err == nil {
+		t.Fatal("Invalid time accepted")
+	}
+	if _, err := Parse("03:00", []string{"t"}); err == nil {
+		t.Fatal("Ambiguous clean day accepted")
+	}
+}
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.lock")
+
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("Acquire lock - %v", err)
+	}
+	_, err = AcquireLock(path)
+	if err == nil || !strings.Contains(err.Error(), ErrLocked.Error()) {
+		t.Fatalf("Lock acquired twice - %v", err)
+	}
+	err = lock.Release()
+	if err != nil {
+		t.Fatalf("Release lock - %v", err)
+	}
+
+	// left behind by a process that is gone
+	err = ioutil.WriteFile(path, []byte("999999999"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("Stale lock not taken over - %v", err)
+	}
+	lock.Release()
+
+	// left behind by a killed container, its successor runs with the same PID
+	err = ioutil.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("Lock with our own PID not taken over - %v", err)
+	}
+	lock.Release()
+}
+
+func TestDaemonStops(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var runs int
+	d := NewDaemon(
+		Schedule{Location: time.UTC},
+		filepath.Join(dir, "test.lock"),
+		func(clean bool) {
+			runs++
+		},
+	)
+
+	if !d.RunOnce(false) || runs != 1 {
+		t.Fatal("Job didn't run")
+	}
+
+	stop := make(chan os.Signal, 1)
+	stop <- syscall.SIGTERM
+	done := make(chan struct{})
+	go func() {
+		d.Serve(stop)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Daemon didn't stop on SIGTERM")
+	}
+}
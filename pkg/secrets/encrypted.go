@@ -0,0 +1,109 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 24
+)
+
+// Encrypted holds the secrets of a local file sealed with a NaCl secretbox,
+// the file is the nonce followed by the sealed JSON object of names and values
+type Encrypted struct {
+	secrets map[string]string
+}
+
+// NewEncrypted opens the file at path with the base64 encoded key
+func NewEncrypted(path, key string) (Encrypted, error) {
+	e := Encrypted{}
+
+	k, err := decodeKey(key)
+	if err != nil {
+		return e, err
+	}
+
+	sealed, err := ioutil.ReadFile(path)
+	if err != nil {
+		return e, fmt.Errorf("Read secrets file - %v", err)
+	}
+	if len(sealed) < nonceSize+secretbox.Overhead {
+		return e, fmt.Errorf("Secrets file %s is too short", path)
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed[:nonceSize])
+	plain, ok := secretbox.Open(nil, sealed[nonceSize:], &nonce, k)
+	if !ok {
+		return e, fmt.Errorf("Couldn't decrypt %s, wrong key?", path)
+	}
+
+	err = json.Unmarshal(plain, &e.secrets)
+	if err != nil {
+		return e, fmt.Errorf("Parse secrets file - %v", err)
+	}
+
+	return e, nil
+}
+
+// Secret implements SecretProvider
+func (e Encrypted) Secret(name string) (string, error) {
+	v := e.secrets[name]
+	if v == "" {
+		return v, ErrNotFound
+	}
+	return v, nil
+}
+
+// Seal encrypts the secrets for NewEncrypted with the base64 encoded key
+func Seal(secrets map[string]string, key string) ([]byte, error) {
+	k, err := decodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [nonceSize]byte
+	_, err = io.ReadFull(rand.Reader, nonce[:])
+	if err != nil {
+		return nil, fmt.Errorf("Generate nonce - %v", err)
+	}
+
+	return secretbox.Seal(nonce[:], plain, &nonce, k), nil
+}
+
+// GenerateKey returns a new random key, base64 encoded
+func GenerateKey() (string, error) {
+	var k [keySize]byte
+	_, err := io.ReadFull(rand.Reader, k[:])
+	if err != nil {
+		return "", fmt.Errorf("Generate key - %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(k[:]), nil
+}
+
+func decodeKey(key string) (*[keySize]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("No key for the secrets file, set SECRETS_KEY")
+	}
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(b) != keySize {
+		return nil, fmt.Errorf("The key must be %d base64 encoded bytes", keySize)
+	}
+
+	var k [keySize]byte
+	copy(k[:], b)
+	return &k, nil
+}
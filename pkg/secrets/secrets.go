@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DockerSecretsDir is where Docker and Kubernetes mount secrets by default
+const DockerSecretsDir = "/run/secrets"
+
+// ErrNotFound is returned by a SecretProvider that doesn't hold the secret
+var ErrNotFound = errors.New("Secret not found")
+
+// SecretProvider resolves credentials by name, e.g. WOO_KEY
+type SecretProvider interface {
+	Secret(name string) (string, error)
+}
+
+// Env reads secrets from environment variables
+type Env struct{}
+
+// Secret implements SecretProvider
+func (Env) Secret(name string) (string, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return v, ErrNotFound
+	}
+	return v, nil
+}
+
+// File reads secrets from one file per secret, as mounted by Docker secrets
+type File struct {
+	dir string
+}
+
+// NewFile returns a File provider for dir, DockerSecretsDir if empty
+func NewFile(dir string) File {
+	if dir == "" {
+		dir = DockerSecretsDir
+	}
+	return File{
+		dir: dir,
+	}
+}
+
+// Secret implements SecretProvider, the file may be named like the secret or in lower case
+func (f File) Secret(name string) (string, error) {
+	for _, fname := range []string{name, strings.ToLower(name)} {
+		b, err := ioutil.ReadFile(filepath.Join(f.dir, fname))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("Read secret %s - %v", name, err)
+		}
+		v := strings.TrimSpace(string(b))
+		if v == "" {
+			return v, ErrNotFound
+		}
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+// Chain asks the providers in order, the first one holding the secret wins
+type Chain []SecretProvider
+
+// Secret implements SecretProvider
+func (c Chain) Secret(name string) (string, error) {
+	for _, p := range c {
+		v, err := p.Secret(name)
+		if err == ErrNotFound {
+			continue
+		}
+		return v, err
+	}
+	return "", ErrNotFound
+}
+
+// Default chains the environment, Docker secrets, and the encrypted file at SECRETS_FILE if set,
+// which is opened with the key in SECRETS_KEY
+func Default() (SecretProvider, error) {
+	chain := Chain{
+		Env{},
+		NewFile(os.Getenv("SECRETS_DIR")),
+	}
+
+	path := os.Getenv("SECRETS_FILE")
+	if path == "" {
+		return chain, nil
+	}
+	enc, err := NewEncrypted(path, os.Getenv("SECRETS_KEY"))
+	if err != nil {
+		return chain, err
+	}
+
+	return append(chain, enc), nil
+}
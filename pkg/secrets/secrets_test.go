@@ -0,0 +1,67 @@
+// +build unit
+// +build !integration
+
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "woo_key"), []byte("from-file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := Seal(map[string]string{"WOO_KEY": "from-box", "FTP_PASS": "pass"}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "secrets.enc")
+	err = ioutil.WriteFile(path, sealed, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := NewEncrypted(path, key)
+	if err != nil {
+		t.Fatalf("Open - %v", err)
+	}
+	other, _ := GenerateKey()
+	_, err = NewEncrypted(path, other)
+	if err == nil {
+		t.Fatalf("Opened with the wrong key")
+	}
+
+	os.Setenv("TEST_SECRET_ENV", "from-env")
+	defer os.Unsetenv("TEST_SECRET_ENV")
+
+	chain := Chain{Env{}, NewFile(dir), enc}
+	for name, want := range map[string]string{
+		"TEST_SECRET_ENV": "from-env",
+		"WOO_KEY":         "from-file",
+		"FTP_PASS":        "pass",
+	} {
+		v, err := chain.Secret(name)
+		if err != nil || v != want {
+			t.Fatalf("%s is %q, expected %q - %v", name, v, want, err)
+		}
+	}
+	_, err = chain.Secret("MISSING")
+	if err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound - %v", err)
+	}
+}
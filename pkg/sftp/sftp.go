@@ -2,6 +2,7 @@ package sftp
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"regexp"
@@ -90,6 +91,28 @@ func (s *SFTP) Walk(root, regex string, cb callback) (err error) {
 	return nil
 }
 
+// Upload copies the local file to the remote path, replacing an existing file
+func (s *SFTP) Upload(localPath, remotePath string) error {
+	if s.isOpen == false {
+		return fmt.Errorf("Failed to upload %s - Session not initialized", localPath)
+	}
+	local, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	remote, err := s.sftpClient.Create(remotePath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(remote, local)
+	if closeErr := remote.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
 // Remove removes the object specified in path
 func (s *SFTP) Remove(path string) error {
 	err := s.sftpClient.Remove(path)
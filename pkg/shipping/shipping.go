@@ -0,0 +1,215 @@
+package shipping
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+// Tier is the shipping price from an order value on
+type Tier struct {
+	From  float64 `yaml:"from"`
+	Price float64 `yaml:"price"`
+}
+
+// Rule defines the shipping of a retailer or feed, the price of a tier replaces the flat price
+type Rule struct {
+	Retailer     string  `yaml:"retailer"` // name of the retailer, case-insensitive
+	FeedID       int32   `yaml:"feed_id"`  // takes precedence over the retailer name
+	Currency     string  `yaml:"currency"` // all currencies if empty
+	Price        float64 `yaml:"price"`
+	FreeFrom     float64 `yaml:"free_from"` // order value from which shipping is free, 0 for never
+	Tiers        []Tier  `yaml:"tiers"`
+	DeliveryTime string  `yaml:"delivery_time"` // e.g. "2-4 days"
+}
+
+// Cost returns the shipping price of an order of the value
+func (r Rule) Cost(value float64) float64 {
+	if r.FreeFrom > 0 && value >= r.FreeFrom {
+		return 0
+	}
+	cost := r.Price
+	from := -1.0
+	for _, t := range r.Tiers {
+		if value >= t.From && t.From > from {
+			cost, from = t.Price, t.From
+		}
+	}
+	return cost
+}
+
+func (r Rule) name() string {
+	if r.FeedID != 0 {
+		return fmt.Sprintf("feed %d", r.FeedID)
+	}
+	return r.Retailer
+}
+
+// Rules is the rule set of all sources
+type Rules []Rule
+
+// Check returns the problems of the rules
+func (rs Rules) Check() (problems []string) {
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	seen := make(map[string]struct{})
+	for i, r := range rs {
+		if r.Retailer == "" && r.FeedID == 0 {
+			add("%d: retailer or feed_id must be set", i)
+			continue
+		}
+		if r.Price < 0 || r.FreeFrom < 0 {
+			add("%s: prices must not be negative", r.name())
+		}
+		for _, t := range r.Tiers {
+			if t.From < 0 || t.Price < 0 {
+				add("%s: tiers must not be negative", r.name())
+				break
+			}
+		}
+		if r.Currency != "" && len(r.Currency) != 3 {
+			add("%s: currency must be an ISO 4217 code, not %q", r.name(), r.Currency)
+		}
+		key := strings.ToLower(r.name() + "/" + r.Currency)
+		if _, exists := seen[key]; exists {
+			add("%s: more than one rule for currency %q", r.name(), r.Currency)
+		}
+		seen[key] = struct{}{}
+	}
+	return problems
+}
+
+// Find returns the rule of the retailer, the ones of its feed first and the ones of its currency before the others
+func (rs Rules) Find(r *feed.Retailer) (rule Rule, found bool) {
+	best := 0
+	for _, candidate := range rs {
+		var score int
+		switch {
+		case candidate.FeedID != 0 && candidate.FeedID == r.FeedID:
+			score = 4
+		case candidate.FeedID == 0 && strings.EqualFold(candidate.Retailer, strings.TrimSpace(r.Name)):
+			score = 2
+		default:
+			continue
+		}
+		switch {
+		case strings.EqualFold(candidate.Currency, r.Currency):
+			score++
+		case candidate.Currency != "":
+			continue
+		}
+		if score > best {
+			rule, best = candidate, score
+		}
+	}
+	return rule, best > 0
+}
+
+// Apply fills in the shipping cost and delivery time the retailers of the products don't state themselves
+func (rs Rules) Apply(products []feed.Product) {
+	if len(rs) == 0 {
+		return
+	}
+	for i := range products {
+		for j := range products[i].Retailers {
+			rs.apply(&products[i].Retailers[j])
+		}
+	}
+}
+
+func (rs Rules) apply(r *feed.Retailer) {
+	rule, found := rs.Find(r)
+	if !found {
+		return
+	}
+	if r.ShippingCost == "" {
+		if price, err := strconv.ParseFloat(r.Price, 64); err == nil {
+			r.ShippingCost = strconv.FormatFloat(rule.Cost(price), 'f', -1, 64)
+		}
+	}
+	if r.DeliveryTime == "" {
+		r.DeliveryTime = rule.DeliveryTime
+	}
+}
+
+// FromSheet reads rules from the rows of a mapping sheet with the columns
+// retailer, feed id, currency, price, free from, tiers and delivery time, tiers are written as from:price pairs, e.g. 200:49;500:29
+func FromSheet(rows [][]interface{}) (rs Rules, err error) {
+	for n, row := range rows {
+		cells := make([]string, 7)
+		for i := range row {
+			if i < len(cells) {
+				cells[i] = strings.TrimSpace(fmt.Sprint(row[i]))
+			}
+		}
+		if cells[0] == "" && cells[1] == "" {
+			continue
+		}
+
+		r := Rule{
+			Retailer:     cells[0],
+			Currency:     strings.ToUpper(cells[2]),
+			DeliveryTime: cells[6],
+		}
+		if cells[1] != "" {
+			id, err := strconv.ParseInt(cells[1], 10, 32)
+			if err != nil {
+				return rs, fmt.Errorf("Row %d - Feed ID - %v", n+1, err)
+			}
+			r.FeedID = int32(id)
+		}
+		r.Price, err = parseAmount(cells[3])
+		if err != nil {
+			return rs, fmt.Errorf("Row %d - Price - %v", n+1, err)
+		}
+		r.FreeFrom, err = parseAmount(cells[4])
+		if err != nil {
+			return rs, fmt.Errorf("Row %d - Free From - %v", n+1, err)
+		}
+		r.Tiers, err = parseTiers(cells[5])
+		if err != nil {
+			return rs, fmt.Errorf("Row %d - Tiers - %v", n+1, err)
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// parseTiers reads from:price pairs separated by semicolons
+func parseTiers(s string) (tiers []Tier, err error) {
+	for _, pair := range strings.Split(s, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 {
+			return tiers, fmt.Errorf("Expected from:price, not %q", pair)
+		}
+		var t Tier
+		t.From, err = parseAmount(parts[0])
+		if err != nil {
+			return tiers, err
+		}
+		t.Price, err = parseAmount(parts[1])
+		if err != nil {
+			return tiers, err
+		}
+		tiers = append(tiers, t)
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].From < tiers[j].From })
+	return tiers, nil
+}
+
+// parseAmount reads an amount with a decimal point or comma, 0 if empty
+func parseAmount(s string) (float64, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,59 @@
+// +build unit
+// +build !integration
+
+package shipping
+
+import (
+	"testing"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+)
+
+func TestRules(t *testing.T) {
+	rs, err := FromSheet([][]interface{}{
+		{},
+		{"", "25437", "sek", "39,90", "400", "", "1-3 days"},
+		{"Shop", "", "SEK", "59", "", "200:49;500:29", "2-5 days"},
+		{"shop", "", "", "5", "", "", ""},
+	})
+	if err != nil {
+		t.Fatalf("Failed to read the sheet - %v", err)
+	}
+	if problems := rs.Check(); len(problems) != 0 {
+		t.Fatalf("Unexpected problems - %v", problems)
+	}
+
+	products := []feed.Product{
+		{Retailers: []feed.Retailer{
+			{Name: "Shop", FeedID: 25437, Price: "399", Currency: "SEK"},
+			{Name: "Shop", FeedID: 25437, Price: "400", Currency: "SEK"},
+			{Name: "shop ", Price: "100", Currency: "SEK"},
+			{Name: "Shop", Price: "250", Currency: "SEK", ShippingCost: "19", DeliveryTime: "tomorrow"},
+			{Name: "Shop", Price: "600", Currency: "SEK"},
+			{Name: "Shop", Price: "20", Currency: "EUR"},
+			{Name: "Crawled", Price: "20", Currency: "SEK", IsCrawler: true},
+		}},
+	}
+	rs.Apply(products)
+
+	expected := []struct{ cost, delivery string }{
+		{"39.9", "1-3 days"},
+		{"0", "1-3 days"},
+		{"59", "2-5 days"},
+		{"19", "tomorrow"},
+		{"29", "2-5 days"},
+		{"5", ""},
+		{"", ""},
+	}
+	for i, e := range expected {
+		r := products[0].Retailers[i]
+		if r.ShippingCost != e.cost || r.DeliveryTime != e.delivery {
+			t.Fatalf("Retailer %d - expected %q and %q, got %q and %q", i, e.cost, e.delivery, r.ShippingCost, r.DeliveryTime)
+		}
+	}
+
+	rs = append(rs, Rule{FeedID: 25437, Currency: "SEK"}, Rule{Price: -1}, Rule{Retailer: "x", Currency: "KR"})
+	if problems := rs.Check(); len(problems) != 3 {
+		t.Fatalf("Expected the duplicate, the missing retailer and the currency - %v", problems)
+	}
+}
@@ -11,6 +11,7 @@ type Destination struct {
 	Name         string
 	Link         string
 	Sizes        []string
+	Currency     string
 	SalesPrice   float64
 	RegularPrice float64
 }
@@ -48,6 +49,7 @@ func GetDestination(p *feed.Product) (dest *Destination, err error) {
 		Name:         p.Retailers[activeID].Name,
 		Link:         p.Retailers[activeID].Link,
 		Sizes:        p.Retailers[activeID].Sizes,
+		Currency:     p.Retailers[activeID].Currency,
 		SalesPrice:   lowest,
 		RegularPrice: highest,
 	}
@@ -73,7 +73,7 @@ func (s *FeedTestSuite) SetupTest() {
 	s.PatternMap = maps[2]
 	s.GenderMap = maps[3]
 
-	s.categoryMap, s.CatNameMap, err = cfg.GetCategoryMaps()
+	s.CatNameMap, err = cfg.GetCategoryNames()
 	assert.Nil(s.T(), err)
 
 	s.gwc, err = gwc.NewWooConnection(
@@ -98,6 +98,10 @@ func (s *FeedTestSuite) SetupTest() {
 	q := f.NewQueueFromFeeds([]f.Feed{s.testFeed}, false)
 	s.testPM, err = q.GetPM(true)
 	assert.Nil(s.T(), err)
+
+	mappings, err := s.gwc.PrepareMappings(s.testPM, false)
+	assert.Nil(s.T(), err)
+	s.categoryMap = mappings.GetCategoryMap()
 }
 
 // TestSetup checks whether the setup has been completed successfully
@@ -154,12 +158,12 @@ func (s *FeedTestSuite) TestFeed() {
 	}
 
 	log.Infoln("Prepare Update")
-	err = s.gwc.PrepareUpdate(s.testPM, s.categoryMap, false, false)
+	err = s.gwc.PrepareUpdate(s.testPM, false, false)
 	assert.Nil(s.T(), err)
 }
 
 func (s *FeedTestSuite) TestUpdate() {
-	mappings, err := s.gwc.PrepareMappings(s.testPM, s.categoryMap, false)
+	mappings, err := s.gwc.PrepareMappings(s.testPM, false)
 	assert.Nil(s.T(), err)
 
 	testPM, err := gwc.PMFromPM(s.testPM, &mappings)
@@ -5,12 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/network"
+)
+
+const (
+	// ConcurrentRequests is the number of pages requested at the same time, to not flood the endpoint
+	ConcurrentRequests = 16
 )
 
 type productFactory struct {
 	it          uint64
 	initialized bool
-	queue       []requestQueue
+	queue       []*network.Queue
 	queueLength uint64
 	nProducts   uint64
 }
@@ -18,7 +26,7 @@ type productFactory struct {
 //Connection is the object that carries the credentials and deals with the request queue
 type Connection struct {
 	token   string
-	queue   requestQueue
+	queue   *network.Queue
 	URL     string
 	factory productFactory
 }
@@ -32,11 +40,29 @@ func NewConnection(token string) (c *Connection, err error) {
 	c.URL = "http://api.tradedoubler.com/1.0/"
 
 	c.token = token
-	c.queue.connection = c
+	c.queue = c.newQueue()
 
 	return c, nil
 }
 
+// newQueue returns a queue for the pages of the product endpoints
+func (c *Connection) newQueue() *network.Queue {
+	return network.NewQueue("Download TD Pages", ConcurrentRequests, MaxRetries-1)
+}
+
+// get returns the request of an endpoint
+func (c *Connection) get(endpoint string) getRequest {
+	return getRequest{
+		Connection: c,
+		Endpoint:   endpoint,
+	}
+}
+
+// send sends a single request with the retries of the queue
+func (c *Connection) send(r getRequest) ([]byte, error) {
+	return network.Retry(r, MaxRetries-1, time.Second)
+}
+
 // QueryProductsByFeed returns a list of all the products for a feed given the options from the query string
 // http://dev.tradedoubler.com/products/publisher/#Matrix_syntax
 func (c *Connection) QueryProductsByFeed(feedID uint64, queryString string) ([]Product, error) {
@@ -47,34 +73,56 @@ func (c *Connection) QueryProductsByFeed(feedID uint64, queryString string) ([]P
 		return products, err
 	}
 
-	var np uint64
 	for i := range feedInfo {
 		if feedInfo[i].FeedID == feedID {
-			np = feedInfo[i].NumberOfProducts
+			return c.QueryFeedProducts(feedInfo[i], queryString, 0)
 		}
 	}
-	pages := np / 100
-	for nPage := uint64(1); nPage <= pages; nPage++ {
-		endpoint := fmt.Sprintf("productsUnlimited;fid=%d;", feedID) + queryString
-		endpoint += fmt.Sprintf(";page=%d", nPage)
+	return products, fmt.Errorf("Feed %d not found", feedID)
+}
 
-		c.queue.pushGetRequest(endpoint)
+// QueryFeedProducts returns the products of the feed that match the query string, at most limit of them if limit > 0.
+// The pages are counted from the products of the feed, the ones beyond the matching products come back empty
+func (c *Connection) QueryFeedProducts(info FeedInfo, queryString string, limit uint64) (products []Product, err error) {
+	n := info.NumberOfProducts
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	queryString = strings.Trim(queryString, ";")
+	if queryString != "" {
+		queryString = ";" + queryString
 	}
 
-	data, _ := c.queue.execute()
+	pageSize := uint64(100)
+	for nPage := uint64(1); (nPage-1)*pageSize < n; nPage++ {
+		c.queue.Add(c.get(
+			fmt.Sprintf("productsUnlimited;fid=%d;pageSize=%d;page=%d", info.FeedID, pageSize, nPage) + queryString,
+		))
+	}
+
+	data, err := c.queue.Execute(true)
+	if err != nil {
+		return products, fmt.Errorf("Query feed %d - %v", info.FeedID, err)
+	}
 
-	var response Feed
 	for j := range data {
+		if data[j] == nil {
+			continue
+		}
+		var response Feed
 		err := json.Unmarshal(data[j], &response)
 		if err != nil {
 			return products, err
 		}
 		for idx := range response.Products {
-			response.Products[idx].FeedID = int32(feedID)
+			response.Products[idx].FeedID = int32(info.FeedID)
 			products = append(products, response.Products[idx])
 		}
 	}
 
+	if limit > 0 && uint64(len(products)) > limit {
+		products = products[:limit]
+	}
 	return products, nil
 }
 
@@ -84,14 +132,17 @@ func (c *Connection) SampleProductsByFeed(languageCode string, feedID uint64, qu
 	endpoint := fmt.Sprintf("productsUnlimited;fid=%d;", feedID) + queryString
 	endpoint += ";page=1"
 
-	c.queue.pushGetRequest(endpoint)
-	data, err := c.queue.execute()
+	c.queue.Add(c.get(endpoint))
+	data, err := c.queue.Execute(true)
 	if err != nil {
 		return products, fmt.Errorf("Sample from feed - %v", err)
 	}
 
 	var response Feed
 	for j := range data {
+		if data[j] == nil {
+			continue
+		}
 		err := json.Unmarshal(data[j], &response)
 		if err != nil {
 			return products, err
@@ -120,15 +171,18 @@ func (c *Connection) QueryAllProducts(queryString, languageCode string) ([]Produ
 		for nPage := 1; nPage <= pages; nPage++ {
 			endpoint := fmt.Sprintf("products;fid=%d;pageSize=100;page=%d", feedInfo[ix].FeedID, nPage) + queryString
 
-			c.queue.pushGetRequest(endpoint)
+			c.queue.Add(c.get(endpoint))
 		}
 
-		data, err := c.queue.execute()
+		data, err := c.queue.Execute(true)
 		if err != nil {
 			return products, err
 		}
 
 		for i := range data {
+			if data[i] == nil {
+				continue
+			}
 			response := new(Feed)
 			err := json.Unmarshal(data[i], &response)
 			if err != nil {
@@ -175,9 +229,7 @@ func (c *Connection) InitProductFactory(productsPerBatch uint64, language string
 		queryString = fmt.Sprintf(";%s", queryString)
 	}*/
 
-	var q = requestQueue{
-		connection: c,
-	}
+	q := c.newQueue()
 
 	for ix := range feedInfo {
 		vars.pages = feedInfo[ix].NumberOfProducts / vars.pageSize
@@ -188,14 +240,12 @@ func (c *Connection) InitProductFactory(productsPerBatch uint64, language string
 		for nPage := uint64(1); nPage <= vars.pages; nPage++ {
 			if vars.batchCounter == vars.batchSize || nPage == vars.pages {
 				c.factory.queue = append(c.factory.queue, q)
-				q = requestQueue{
-					connection: c,
-				}
+				q = c.newQueue()
 				vars.batchCounter = 0
 			}
-			q.pushGetRequest(
+			q.Add(c.get(
 				fmt.Sprintf("productsUnlimited;fid=%d;pageSize=%d;page=%d", feedInfo[ix].FeedID, vars.pageSize, nPage),
-			)
+			))
 
 			vars.it++
 			vars.batchCounter++
@@ -219,12 +269,15 @@ func (c *Connection) ProductFactoryNext() (products []Product, done bool, err er
 		return products, true, nil
 	}
 
-	data, err := c.factory.queue[c.factory.it].execute()
+	data, err := c.factory.queue[c.factory.it].Execute(true)
 	if err != nil {
 		return products, false, err
 	}
 
 	for i := range data {
+		if data[i] == nil {
+			continue
+		}
 		response := new(Feed)
 		err := json.Unmarshal(data[i], response)
 		if err != nil {
@@ -256,12 +309,7 @@ func (c *Connection) QueryCategories(languageCode string) (ProductCategories, er
 		queryString = ";language=" + strings.ToLower(languageCode)
 	}
 
-	var r = getRequest{
-		Connection: c,
-		Endpoint:   "productCategories" + queryString,
-	}
-
-	data, err := r.Send()
+	data, err := c.send(c.get("productCategories" + queryString))
 	if err != nil {
 		return categoryTree, err
 	}
@@ -283,14 +331,9 @@ func (c *Connection) QueryFeeds(languageCode string) (feeds []FeedInfo, err erro
 		return feeds, fmt.Errorf("Not a proper ISO language code - %s", languageCode)
 	}
 
-	var r = getRequest{
-		Connection: c,
-		Endpoint:   "productFeeds",
-	}
-
-	data, err := r.Send()
+	data, err := c.send(c.get("productFeeds"))
 	if err != nil {
-		return feeds, fmt.Errorf("Failed to query feed info - %s", string(data))
+		return feeds, fmt.Errorf("Failed to query feed info - %v", err)
 	}
 
 	err = json.Unmarshal(data, &response)
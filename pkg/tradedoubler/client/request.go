@@ -6,22 +6,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+
+	"stillgrove.com/gofeedyourself/pkg/network"
 )
 
 const (
 	MaxRetries = 3
 )
 
-// Request is an interface implemented by postRequest and getRequest
-// It is used for the requestQueue to allow for async execution of generic requests
-type Request interface {
-	Send() ([]byte, error)
-	getResponseError(rawResponse []byte) (string, error)
-}
-
-/* -----------------------------------------------
--- getRequest implements the Request interface  --
-------------------------------------------------*/
+/* -------------------------------------------------------
+-- getRequest implements the network.Request interface  --
+--------------------------------------------------------*/
 type getRequest struct {
 	Connection *Connection
 	Endpoint   string
@@ -42,38 +37,35 @@ func (g getRequest) getResponseError(rawResponse []byte) (errorMessage string, e
 	return errorMessage, nil
 }
 
-// Send implements the Request Interface, returns raw bytes to be marshalled on a higher level
+// URL implements the network.Request interface, the token is left out
+func (g getRequest) URL() string {
+	return g.Connection.URL + g.Endpoint
+}
+
+// Send implements the network.Request interface, returns raw bytes to be marshalled on a higher level
 func (g getRequest) Send() ([]byte, error) {
 	var rawResponse []byte
-	url := "http://api.tradedoubler.com/1.0/" + g.Endpoint + fmt.Sprintf("?token=%s", g.Connection.token)
-
-	// resend request up to 5 times if we don't get a 200 response
-	// return on any other error
-	var statusCode int
-	for i := 0; i < MaxRetries; i++ {
-		resp, err := http.Get(url)
-		if err != nil {
-			return rawResponse, err
-		}
-		defer resp.Body.Close()
+	url := g.Connection.URL + g.Endpoint + fmt.Sprintf("?token=%s", g.Connection.token)
 
-		rawResponse, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return rawResponse, err
-		}
+	resp, err := http.Get(url)
+	if err != nil {
+		return rawResponse, err
+	}
+	defer resp.Body.Close()
 
-		statusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return rawResponse, network.ErrRateLimited
+	}
 
-		// break the loop if the request was successful
-		if statusCode == http.StatusOK {
-			break
-		}
+	rawResponse, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return rawResponse, err
 	}
 
-	if statusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK {
 		msg, err := g.getResponseError(rawResponse)
 		if err != nil {
-			msg = fmt.Sprintf("Failed to unmarshal response error message: /n %d: %s", statusCode, err)
+			msg = fmt.Sprintf("Failed to unmarshal response error message: /n %d: %s", resp.StatusCode, err)
 		}
 		return rawResponse, errors.New(msg)
 	}
@@ -0,0 +1,154 @@
+package tradedoublerclient
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Filter narrows down the products of a feed at query time
+// http://dev.tradedoubler.com/products/publisher/#Matrix_syntax
+type Filter struct {
+	Categories []int   `yaml:"categories"` // Tradedoubler category ids
+	Language   string  `yaml:"language"`   // ISO 639-1 code of the product texts
+	MinPrice   float64 `yaml:"min_price"`
+	MaxPrice   float64 `yaml:"max_price"`
+}
+
+// QueryString returns the matrix parameters of the filter, e.g. tdCategoryId=1,2;minPrice=100
+func (f Filter) QueryString() string {
+	var params []string
+	if len(f.Categories) > 0 {
+		ids := make([]string, len(f.Categories))
+		for i := range f.Categories {
+			ids[i] = strconv.Itoa(f.Categories[i])
+		}
+		params = append(params, "tdCategoryId="+strings.Join(ids, ","))
+	}
+	if f.Language != "" {
+		params = append(params, "language="+strings.ToLower(f.Language))
+	}
+	if f.MinPrice > 0 {
+		params = append(params, "minPrice="+strconv.FormatFloat(f.MinPrice, 'f', -1, 64))
+	}
+	if f.MaxPrice > 0 {
+		params = append(params, "maxPrice="+strconv.FormatFloat(f.MaxPrice, 'f', -1, 64))
+	}
+	return strings.Join(params, ";")
+}
+
+func (f Filter) check() (problems []string) {
+	if f.MinPrice < 0 || f.MaxPrice < 0 {
+		problems = append(problems, "prices must not be negative")
+	}
+	if f.MaxPrice > 0 && f.MaxPrice < f.MinPrice {
+		problems = append(problems, "max_price must not be below min_price")
+	}
+	if f.Language != "" && len(f.Language) != 2 {
+		problems = append(problems, fmt.Sprintf("language must be an ISO 639-1 code, not %q", f.Language))
+	}
+	return problems
+}
+
+// FeedOptions configures an included feed
+type FeedOptions struct {
+	Name       string  `yaml:"name"`
+	SampleSize int     `yaml:"sample_size"` // products downloaded outside production, the default if 0
+	Filter     *Filter `yaml:"filter"`      // replaces the filter of the selection
+}
+
+// Selection picks the feeds of the website to download and filters their products
+type Selection struct {
+	Feeds           map[int]FeedOptions `yaml:"feeds"` // included feeds by id, all feeds if empty
+	ExcludeFeeds    []int               `yaml:"exclude_feeds"`
+	Programs        []int               `yaml:"programs"` // included programs by id, all programs if empty
+	ExcludePrograms []int               `yaml:"exclude_programs"`
+	Filter          Filter              `yaml:"filter"` // for the products of all feeds
+}
+
+// Check returns the problems of the selection
+func (s Selection) Check() (problems []string) {
+	for _, problem := range s.Filter.check() {
+		problems = append(problems, "filter: "+problem)
+	}
+
+	ids := make([]int, 0, len(s.Feeds))
+	for id := range s.Feeds {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		options := s.Feeds[id]
+		if containsInt(s.ExcludeFeeds, id) {
+			problems = append(problems, fmt.Sprintf("feeds.%d is excluded as well", id))
+		}
+		if options.SampleSize < 0 {
+			problems = append(problems, fmt.Sprintf("feeds.%d.sample_size must not be negative", id))
+		}
+		if options.Filter == nil {
+			continue
+		}
+		for _, problem := range options.Filter.check() {
+			problems = append(problems, fmt.Sprintf("feeds.%d.filter: %s", id, problem))
+		}
+	}
+	for _, id := range s.Programs {
+		if containsInt(s.ExcludePrograms, id) {
+			problems = append(problems, fmt.Sprintf("program %d is included and excluded", id))
+		}
+	}
+	return problems
+}
+
+// Query is a selected feed and the filter of its products
+type Query struct {
+	Feed        FeedInfo
+	QueryString string
+	SampleSize  int // 0 for the default
+}
+
+// Select returns the queries of the feeds that pass the selection
+func (s Selection) Select(feeds []FeedInfo) (queries []Query) {
+	for _, info := range feeds {
+		id := int(info.FeedID)
+		options, listed := s.Feeds[id]
+		if len(s.Feeds) > 0 && !listed || containsInt(s.ExcludeFeeds, id) || !s.selectsPrograms(info.Programs) {
+			continue
+		}
+
+		filter := s.Filter
+		if options.Filter != nil {
+			filter = *options.Filter
+		}
+		queries = append(queries, Query{
+			Feed:        info,
+			QueryString: filter.QueryString(),
+			SampleSize:  options.SampleSize,
+		})
+	}
+	return queries
+}
+
+// selectsPrograms is false if a program is excluded or none of them is included
+func (s Selection) selectsPrograms(programs []Program) bool {
+	included := len(s.Programs) == 0
+	for _, p := range programs {
+		if containsInt(s.ExcludePrograms, int(p.ProgramID)) {
+			return false
+		}
+		if containsInt(s.Programs, int(p.ProgramID)) {
+			included = true
+		}
+	}
+	return included
+}
+
+func containsInt(list []int, i int) bool {
+	for j := range list {
+		if list[j] == i {
+			return true
+		}
+	}
+	return false
+}
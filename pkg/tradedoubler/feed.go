@@ -1,10 +1,7 @@
 package tradedoubler
 
 import (
-	"encoding/json"
 	"fmt"
-	"math/rand"
-	"os"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -19,13 +16,15 @@ import (
 	"stillgrove.com/gofeedyourself/pkg/cache"
 	dyn "stillgrove.com/gofeedyourself/pkg/dynamoConnection"
 	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
-	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+	"stillgrove.com/gofeedyourself/pkg/network"
 	gtd "stillgrove.com/gofeedyourself/pkg/tradedoubler/client"
 )
 
 const (
 	// SampleSize describes the limit of products to download when not in production mode
 	SampleSize = uint64(5000)
+	// FeedSampleSize is the limit per feed when not in production mode, unless the feed sets a sample size
+	FeedSampleSize = uint64(1000)
 	// ProductionLimit is the arbitrary hard limit I am temporarily enforcing to avoid memory issues
 	// 0 means: no limit
 	ProductionLimit = uint64(0)
@@ -59,6 +58,7 @@ type Feed struct {
 	dynamoTableName     string
 	language            string
 	locale              *feed.Locale
+	selection           gtd.Selection
 }
 
 // GetName identifies the feed source
@@ -96,16 +96,16 @@ func NewFeed(locale *feed.Locale, tdToken, dynamoID, dynamoSecret, conversionTab
 	return &td, nil
 }
 
+// SetSelection sets the feeds to download and the filters of their products, all feeds are downloaded by default
+func (td *Feed) SetSelection(selection gtd.Selection) {
+	td.selection = selection
+}
+
 // Get implements the feed interface
 func (td Feed) Get(productionFlag bool) (outProducts []feed.Product, err error) {
-	path := helpers.FindFolderDir("gofeedyourself") + "/cache/"
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		os.Mkdir(path, os.ModePerm)
-	}
-
-	cache, err := cache.NewBadgerCache(path+td.GetName(), CacheTTL)
+	cache, err := network.OpenCache(td.GetName(), CacheTTL)
 	if err != nil {
-		return outProducts, fmt.Errorf("Initialize Cache -%v", err)
+		return outProducts, err
 	}
 	defer cache.Close()
 
@@ -119,25 +119,10 @@ func (td Feed) Get(productionFlag bool) (outProducts []feed.Product, err error)
 		return outProducts, nil
 	}
 
-	res, err := cache.LoadAll()
+	outProducts, err = network.LoadProducts(cache, "Tradedoubler")
 	if err != nil {
 		return outProducts, fmt.Errorf("Load from cache -%v", err)
 	}
-
-	var p []feed.Product
-	for i := range res {
-		json.Unmarshal(res[i], &p)
-
-		for j := range p {
-			err = p[j].Validate()
-			if err != nil {
-				log.WithField("Error", err).Warningf("Tradedoubler - Invalid product in cache")
-				continue
-			}
-			outProducts = append(outProducts, p[j])
-		}
-		p = nil
-	}
 	if len(outProducts) == 0 {
 		log.Infoln("TD: Cache empty, downloading feeds")
 		outProducts, err = td.downloadFeeds(cache, productionFlag)
@@ -168,51 +153,52 @@ func (td *Feed) downloadFeeds(cache cache.Cache, productionFlag bool) (outProduc
 		return outProducts, fmt.Errorf("Failed to initialize td connection - %v", err)
 	}
 
-	if td.batchSize > SampleSize && !productionFlag {
-		td.batchSize = SampleSize
-	}
-
-	nProducts, err := c.InitProductFactory(td.batchSize, td.language)
-	if !productionFlag {
-		nProducts = SampleSize
-	} else {
-		if ProductionLimit > 0 {
-			nProducts = ProductionLimit
-		}
-	}
+	feeds, err := c.QueryFeeds(td.language)
 	if err != nil {
-		return outProducts, fmt.Errorf("Initialize Product Factory - %v", err)
+		return outProducts, fmt.Errorf("Query feeds - %v", err)
+	}
+	queries := td.selection.Select(feeds)
+	if len(queries) == 0 {
+		return outProducts, fmt.Errorf("None of the %d feeds is selected", len(feeds))
 	}
 
-	log.WithField("Product Count", nProducts).Infoln("Download prepared")
+	var nProducts uint64
+	for _, q := range queries {
+		nProducts += q.Feed.NumberOfProducts
+	}
+	log.WithFields(
+		log.Fields{
+			"Feeds":         len(queries),
+			"Product Count": nProducts,
+		},
+	).Infoln("Download prepared")
 
+	// the products are only cached once every feed is loaded, a cache missing a feed would delete its products
 	var (
-		products     []gtd.Product
-		feedProducts []*feed.Product
-		counter      uint64
-		done         bool
+		counter uint64
+		batches [][]*feed.Product
 	)
-
-	p := new(feed.Product)
-	tp := new(Product)
-
-	for !done {
-		products, done, err = c.ProductFactoryNext()
-
-		if done {
-			break
+	for _, q := range queries {
+		limit := ProductionLimit
+		if !productionFlag {
+			limit = FeedSampleSize
+			if q.SampleSize > 0 {
+				limit = uint64(q.SampleSize)
+			}
 		}
+
+		products, err := c.QueryFeedProducts(q.Feed, q.QueryString, limit)
 		if err != nil {
-			return outProducts, err
+			return outProducts, fmt.Errorf("Download feed %s - %v", q.Feed.Name, err)
 		}
 
-		feedProducts = make([]*feed.Product, 0)
+		feedProducts := make([]*feed.Product, 0, len(products))
 		for j := range products {
-			tp = &Product{
+			tp := &Product{
 				products[j],
 				td.mapping,
 			}
-			p, err = tp.ToFeedProduct()
+			p, err := tp.ToFeedProduct()
 			if err != nil {
 				log.WithFields(
 					log.Fields{
@@ -233,17 +219,27 @@ func (td *Feed) downloadFeeds(cache cache.Cache, productionFlag bool) (outProduc
 			)
 		}
 
-		writeToCache(cache, feedProducts)
+		batches = append(batches, feedProducts)
 
 		counter += uint64(len(products))
-		if counter >= nProducts {
-			done = true
+		log.WithFields(
+			log.Fields{
+				"Feed":       q.Feed.Name,
+				"Query":      q.QueryString,
+				"Downloaded": fmt.Sprintf("%d / %d", counter, nProducts),
+			},
+		).Infoln("Download TD Products")
+
+		if !productionFlag && counter >= SampleSize {
+			break
 		}
+	}
 
-		log.WithField("Downloaded", fmt.Sprintf("%d / %d", counter, nProducts)).Infoln("Download TD Products")
+	for i := range batches {
+		network.StoreProducts(cache, batches[i])
 	}
 
-	outProducts, err = loadFromCache(cache)
+	outProducts, err = network.LoadProducts(cache, "Tradedoubler")
 	if err != nil {
 		return outProducts, err
 	}
@@ -258,49 +254,6 @@ func (td *Feed) downloadFeeds(cache cache.Cache, productionFlag bool) (outProduc
 	return outProducts, nil
 }
 
-func loadFromCache(cache cache.Cache) (outProducts []feed.Product, err error) {
-	res, err := cache.LoadAll()
-	if err != nil {
-		return outProducts, fmt.Errorf("Load cached products - %v", err)
-	}
-
-	var prod []feed.Product
-	for k := range res {
-		json.Unmarshal(res[k], &prod)
-
-		for j := range prod {
-			if prod[j].GetKey() == 0 {
-				return outProducts, fmt.Errorf("Tradedoubler: Empty product in cache")
-			}
-			err = prod[j].Validate()
-			if err != nil {
-				log.WithField("Error", err).Debugln("Tradedoubler: Inconsistent product in cache")
-				continue
-			}
-			outProducts = append(outProducts, prod[j])
-		}
-		prod = nil
-	}
-	return outProducts, nil
-}
-
-func writeToCache(cache cache.Cache, feedProducts []*feed.Product) (err error) {
-	payload, err := json.Marshal(feedProducts)
-	if err != nil {
-		return fmt.Errorf("Failed to store products in cache - %v", err)
-	}
-	err = cache.Store(
-		map[string][]byte{
-			fmt.Sprintf("%d", rand.Int63()): payload,
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("Failed to store products in cache - %v", err)
-	}
-
-	return nil
-}
-
 // ------------------------------------------------------------
 // -- Get Conversion Data from DynamoDB -----------------------
 //-------------------------------------------------------------
@@ -9,6 +9,7 @@ import (
 	c "stillgrove.com/gofeedyourself/pkg/collection"
 	f "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
 	feed "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
 	gtd "stillgrove.com/gofeedyourself/pkg/tradedoubler/client"
 )
 
@@ -160,6 +161,7 @@ func (p *Product) ToFeedProduct() (productOut *f.Product, err error) {
 	if err != nil {
 		return productOut, err
 	}
+	metrics.MappingLookups.Inc("colors", metrics.Result(len(productOut.ColorGroups) > 0))
 
 	v.multipattern, err = c.MapAttributes(v.multicolors, p.m.PatternMap, "Various", true)
 	if err != nil {
@@ -188,9 +190,7 @@ func (p *Product) ToFeedProduct() (productOut *f.Product, err error) {
 
 	productOut.Patterns = c.UniqueNames(v.multipattern)
 
-	shippingCostRules := NewShippingCostRuleSet()
-	shippingCostRules.Add(25437, "SEK", 400, 39.90)
-	productOut.Retailers, productOut.RetailerMap, err = processOffers(p.Offers, v.multisizes, shippingCostRules)
+	productOut.Retailers, productOut.RetailerMap, err = processOffers(p.Offers, v.multisizes)
 	if err != nil {
 		return productOut, fmt.Errorf("Failed to process offers for %s - %v", productOut.Name, err)
 	}
@@ -388,6 +388,7 @@ func (p *Product) processCategories(candidates []string, gender string) (outCats
 	if err != nil {
 		return outCats, err
 	}
+	metrics.MappingLookups.Inc("categories", metrics.Result(len(categories) > 0))
 
 	var longestMatch int
 	var name string
@@ -427,7 +428,7 @@ func (p *Product) processCategories(candidates []string, gender string) (outCats
 	return outCats, nil
 }
 
-func processOffers(offers []gtd.Offer, allSizes []string, rs ShippingCostRuleSet) (retailers []f.Retailer, retailerMap map[uint64]struct{}, err error) {
+func processOffers(offers []gtd.Offer, allSizes []string) (retailers []f.Retailer, retailerMap map[uint64]struct{}, err error) {
 	if len(allSizes) == 0 {
 		return retailers, retailerMap, fmt.Errorf("No sizes")
 	}
@@ -441,6 +442,7 @@ func processOffers(offers []gtd.Offer, allSizes []string, rs ShippingCostRuleSet
 			Name:         c.Sanitize(strings.Replace(offers[i].ProgramName, ".com", "", -1)),
 			DeliveryTime: offers[i].DeliveryTime,
 			ShippingCost: offers[i].ShippingCost,
+			FeedID:       offers[i].FeedID,
 			Sizes:        allSizes,
 		}
 
@@ -479,14 +481,6 @@ func processOffers(offers []gtd.Offer, allSizes []string, rs ShippingCostRuleSet
 			}
 		}
 
-		// Apply shipping cost rules
-		if r.ShippingCost == "" {
-			p, err := strconv.ParseFloat(r.Price, 32)
-			if err == nil {
-				r.ShippingCost, _ = rs.GetPrice(r.Currency, offers[i].FeedID, float32(p))
-			}
-		}
-
 		r.HighestPrice = price.highest
 
 		retailers = append(retailers, r)
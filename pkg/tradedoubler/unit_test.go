@@ -146,3 +146,46 @@ func TestProducts(t *testing.T) {
 		t.Fatalf("Sizes not extracted correctly - %v", fp.Retailers[0].Sizes)
 	}
 }
+
+func TestSelection(t *testing.T) {
+	feeds := []gtd.FeedInfo{
+		{FeedID: 1, Name: "Dresses", Programs: []gtd.Program{{ProgramID: 10}}},
+		{FeedID: 2, Name: "Shoes", Programs: []gtd.Program{{ProgramID: 10}}},
+		{FeedID: 3, Name: "Outlet", Programs: []gtd.Program{{ProgramID: 20}}},
+		{FeedID: 4, Name: "Bags", Programs: []gtd.Program{{ProgramID: 30}}},
+	}
+
+	s := gtd.Selection{
+		ExcludeFeeds:    []int{2},
+		ExcludePrograms: []int{20},
+		Filter:          gtd.Filter{Categories: []int{8, 9}, MinPrice: 100, MaxPrice: 999.5},
+	}
+	queries := s.Select(feeds)
+	if len(queries) != 2 || queries[0].Feed.FeedID != 1 || queries[1].Feed.FeedID != 4 {
+		t.Fatalf("Expected all feeds but the excluded ones - %+v", queries)
+	}
+	if queries[0].QueryString != "tdCategoryId=8,9;minPrice=100;maxPrice=999.5" {
+		t.Fatalf("Wrong query string - %s", queries[0].QueryString)
+	}
+
+	s = gtd.Selection{
+		Feeds: map[int]gtd.FeedOptions{
+			1: {Name: "Dresses", SampleSize: 50, Filter: &gtd.Filter{Language: "SV"}},
+			3: {Name: "Outlet"},
+		},
+		Programs: []int{10},
+	}
+	queries = s.Select(feeds)
+	if len(queries) != 1 || queries[0].SampleSize != 50 || queries[0].QueryString != "language=sv" {
+		t.Fatalf("Expected the listed feed of the included program - %+v", queries)
+	}
+	if problems := s.Check(); len(problems) != 0 {
+		t.Fatalf("Unexpected problems - %v", problems)
+	}
+
+	s.ExcludeFeeds = []int{3}
+	s.Filter.MinPrice, s.Filter.MaxPrice = 500, 100
+	if problems := s.Check(); len(problems) != 2 {
+		t.Fatalf("Expected the excluded feed and the price range - %v", problems)
+	}
+}
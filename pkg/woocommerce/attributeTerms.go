@@ -0,0 +1,288 @@
+package woocommerce
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	gwc "stillgrove.com/gofeedyourself/pkg/woocommerce/client"
+)
+
+// termPageSize - number of terms per page when loading the terms of an attribute
+const termPageSize = 100
+
+// generateTermMap registers the options found in the feed as terms of the global attributes
+// and returns the mapping table of the structure {attribute: {term name: term}}.
+// The terms missing from the feed are returned as orphans per attribute id, pruneTerms removes the ones no product keeps
+func (w *WooConnection) generateTermMap(newAttributeMap map[string]map[string]struct{}, attributeMap map[string]*int32, applyUpdate bool) (termMap map[string]map[string]*gwc.AttributeTerm, orphans map[int32][]*gwc.AttributeTerm, err error) {
+	termMap = make(map[string]map[string]*gwc.AttributeTerm, len(newAttributeMap))
+	orphans = make(map[int32][]*gwc.AttributeTerm)
+
+	var created int
+	for attribute := range newAttributeMap {
+		id, exists := attributeMap[attribute]
+		if !exists {
+			continue
+		}
+
+		currentTerms, err := w.fetchAttributeTerms(*id)
+		if err != nil {
+			return termMap, orphans, fmt.Errorf("Load terms of %s - %v", attribute, err)
+		}
+
+		var create []gwc.Item
+		wanted := make(map[string]struct{}, len(newAttributeMap[attribute]))
+		for name := range newAttributeMap[attribute] {
+			key := termKey(name)
+			if key == "" {
+				continue
+			}
+			// options that only differ in case or whitespace share a term
+			_, exists = wanted[key]
+			if exists {
+				continue
+			}
+			wanted[key] = struct{}{}
+
+			_, exists = currentTerms[key]
+			if exists {
+				continue
+			}
+			create = append(
+				create,
+				gwc.AttributeTerm{
+					Name: strings.TrimSpace(name),
+				},
+			)
+		}
+
+		created += len(create)
+
+		if applyUpdate && len(create) > 0 {
+			err = w.updateAttributeTerms(*id, create, nil)
+			if err != nil {
+				return termMap, orphans, fmt.Errorf("Update terms of %s - %v", attribute, err)
+			}
+			currentTerms, err = w.fetchAttributeTerms(*id)
+			if err != nil {
+				return termMap, orphans, fmt.Errorf("Reload terms of %s - %v", attribute, err)
+			}
+		}
+
+		for key := range currentTerms {
+			_, exists = wanted[key]
+			// published products still use their terms
+			if exists || currentTerms[key].Count > 0 {
+				continue
+			}
+			orphans[*id] = append(orphans[*id], currentTerms[key])
+		}
+
+		termMap[attribute] = currentTerms
+	}
+
+	log.WithFields(
+		log.Fields{
+			"Created": created,
+			"Applied": applyUpdate,
+		},
+	).Infoln("Synchronized Attribute Terms")
+
+	return termMap, orphans, nil
+}
+
+// pruneTerms deletes the orphaned terms no product in the backend uses after the update
+func (w *WooConnection) pruneTerms(orphans map[int32][]*gwc.AttributeTerm, oldProducts map[uint64]*productSnapshot, deleted []int, applyUpdate bool) error {
+	unused := unusedTerms(orphans, oldProducts, deleted)
+
+	var pruned int
+	for attribute, ids := range unused {
+		pruned += len(ids)
+		if applyUpdate {
+			err := w.updateAttributeTerms(attribute, nil, ids)
+			if err != nil {
+				return fmt.Errorf("Delete terms of attribute %d - %v", attribute, err)
+			}
+		}
+	}
+
+	log.WithFields(
+		log.Fields{
+			"Deleted": pruned,
+			"Applied": applyUpdate,
+		},
+	).Infoln("Pruned Attribute Terms")
+
+	return nil
+}
+
+// unusedTerms returns the ids of the orphans per attribute id that none of the products keeps. WC only counts published products,
+// so the terms of hidden, out of stock and held back products are looked up in their snapshots, only deleted products give theirs up
+func unusedTerms(orphans map[int32][]*gwc.AttributeTerm, oldProducts map[uint64]*productSnapshot, deleted []int) map[int32][]int {
+	gone := make(map[uint64]struct{}, len(deleted))
+	for _, id := range deleted {
+		gone[uint64(id)] = struct{}{}
+	}
+	used := make(map[int32]map[string]struct{})
+	for _, s := range oldProducts {
+		if _, exists := gone[s.ID]; exists {
+			continue
+		}
+		for attribute, keys := range s.Terms {
+			if used[attribute] == nil {
+				used[attribute] = make(map[string]struct{})
+			}
+			for _, key := range keys {
+				used[attribute][key] = struct{}{}
+			}
+		}
+	}
+
+	unused := make(map[int32][]int)
+	for attribute, terms := range orphans {
+		for _, term := range terms {
+			if _, exists := used[attribute][termKey(term.Name)]; exists {
+				continue
+			}
+			unused[attribute] = append(unused[attribute], int(term.ID))
+		}
+	}
+	return unused
+}
+
+// updateAttributeTerms creates and deletes terms of an attribute in batches
+func (w *WooConnection) updateAttributeTerms(attributeID int32, create []gwc.Item, orphans []int) error {
+	endpoint := fmt.Sprintf("products/attributes/%d/terms/batch", attributeID)
+
+	for start := 0; start < len(create) || start < len(orphans); start += BatchStrideSize {
+		req := gwc.BatchPostRequest{
+			Endpoint: endpoint,
+			Locale:   w.Locale,
+		}
+		if start < len(create) {
+			req.Create = create[start:minInt(start+BatchStrideSize, len(create))]
+		}
+		if start < len(orphans) {
+			req.Delete = orphans[start:minInt(start+BatchStrideSize, len(orphans))]
+		}
+		w.Connection.PushToQueue("terms", req)
+	}
+
+	_, err := w.Connection.ExecuteRequestQueue("terms", false, false)
+	if err != nil {
+		return fmt.Errorf("Send term batches - %v", err)
+	}
+
+	// back off a little bit not to run into capacity issues
+	time.Sleep(2 * time.Second)
+
+	return nil
+}
+
+// fetchAttributeTerms loads the registered terms of an attribute, indexed by termKey
+func (w *WooConnection) fetchAttributeTerms(attributeID int32) (terms map[string]*gwc.AttributeTerm, err error) {
+	endpoint := fmt.Sprintf("products/attributes/%d/terms", attributeID)
+
+	terms = make(map[string]*gwc.AttributeTerm)
+
+	total, err := w.Connection.GetNumItems(endpoint, w.Locale)
+	if err != nil {
+		return terms, fmt.Errorf("Get number of terms - %v", err)
+	}
+	if total == 0 {
+		return terms, nil
+	}
+
+	for offset := 0; offset < total; offset += termPageSize {
+		w.Connection.PushToQueue(
+			"terms",
+			gwc.GetRequest{
+				Endpoint: endpoint,
+				Params: url.Values{
+					"lang": []string{
+						w.Locale,
+					},
+					"offset": []string{
+						strconv.Itoa(offset),
+					},
+					"per_page": []string{
+						strconv.Itoa(termPageSize),
+					},
+				},
+			},
+		)
+	}
+
+	rawResponse, err := w.Connection.ExecuteRequestQueue("terms", true, false)
+	if err != nil {
+		return terms, fmt.Errorf("Load terms - %v", err)
+	}
+
+	for i := range rawResponse {
+		if len(rawResponse[i]) < 1 {
+			continue
+		}
+		var page []gwc.AttributeTerm
+		err = json.Unmarshal(rawResponse[i], &page)
+		if err != nil {
+			return terms, fmt.Errorf("Unmarshal terms - %v", err)
+		}
+		for j := range page {
+			if page[j].ID == 0 {
+				return terms, fmt.Errorf("Term returned without ID - %s", page[j].Name)
+			}
+			terms[termKey(page[j].Name)] = &page[j]
+		}
+	}
+
+	return terms, nil
+}
+
+// resolveTerms replaces the options of a global attribute with the names of the registered terms,
+// WC only links a product to a term if the option matches the term exactly
+func (m *ProductMapping) resolveTerms(attribute string, options []string) []string {
+	resolved := make([]string, len(options))
+	for i := range options {
+		term, exists := m.termMap[attribute][termKey(options[i])]
+		if !exists {
+			// not registered (yet), e.g. in a dry run
+			resolved[i] = options[i]
+			continue
+		}
+		resolved[i] = html.UnescapeString(term.Name)
+	}
+
+	return resolved
+}
+
+// termKey normalizes term names, WC returns them html escaped
+func termKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(html.UnescapeString(name)))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// extractTermMap returns the options per attribute that are handled as global terms
+func extractTermMap(productMap *feed.ProductMap) (map[string]map[string]struct{}, error) {
+	attributeMap, err := extractAttributeMap(productMap)
+	if err != nil {
+		return attributeMap, err
+	}
+
+	// Color is only stored as free text, products are filtered by Color Group
+	delete(attributeMap, "Color")
+
+	return attributeMap, nil
+}
@@ -0,0 +1,257 @@
+package woocommerce
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	c "stillgrove.com/gofeedyourself/pkg/collection"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	gwc "stillgrove.com/gofeedyourself/pkg/woocommerce/client"
+)
+
+var (
+	// GenderCategories names the top level categories that every product category is sorted under
+	GenderCategories = map[string]string{
+		"w": "Women",
+		"m": "Men",
+		"u": "Unisex",
+	}
+)
+
+// categoryPageSize - number of categories per page when loading the category tree
+const categoryPageSize = 100
+
+// generateCategoryMap loads the WC category tree, creates missing gender and product categories (matched by slug)
+// and returns the mapping table of the structure {gender: {name: [id, parent id]}}
+func (w *WooConnection) generateCategoryMap(newProductMap *feed.ProductMap, applyUpdate bool) (categoryMap map[string]map[string][]*int32, err error) {
+	if w.initialized == false {
+		return categoryMap, fmt.Errorf("Please initialize with your credentials first. WooConnection.Init()")
+	}
+
+	newCategories := extractCategories(newProductMap)
+
+	tree, err := w.fetchCategoryTree()
+	if err != nil {
+		return categoryMap, fmt.Errorf("Fetch existing category tree - %v", err)
+	}
+
+	// parents have to exist before their children can be attached to them
+	var parents []gwc.Item
+	for gender := range newCategories {
+		slug := categorySlug(gender, "")
+		_, exists := tree[slug]
+		if exists {
+			continue
+		}
+		parents = append(
+			parents,
+			gwc.Category{
+				Name: GenderCategories[gender],
+				Slug: slug,
+			},
+		)
+	}
+
+	if len(parents) > 0 && applyUpdate {
+		tree, err = w.createCategories(parents)
+		if err != nil {
+			return categoryMap, fmt.Errorf("Create parent categories - %v", err)
+		}
+	}
+
+	var children []gwc.Item
+	for gender := range newCategories {
+		parent, exists := tree[categorySlug(gender, "")]
+		if !exists {
+			continue
+		}
+		for name := range newCategories[gender] {
+			slug := categorySlug(gender, name)
+			_, exists = tree[slug]
+			if exists {
+				continue
+			}
+			children = append(
+				children,
+				gwc.Category{
+					Name:   strings.Title(name),
+					Slug:   slug,
+					Parent: parent.ID,
+				},
+			)
+		}
+	}
+
+	if len(children) > 0 && applyUpdate {
+		tree, err = w.createCategories(children)
+		if err != nil {
+			return categoryMap, fmt.Errorf("Create categories - %v", err)
+		}
+	}
+
+	var missing int
+	categoryMap = make(map[string]map[string][]*int32, len(GenderCategories))
+	for gender := range newCategories {
+		categoryMap[gender] = make(map[string][]*int32, len(newCategories[gender]))
+
+		parent, exists := tree[categorySlug(gender, "")]
+		if !exists {
+			missing += len(newCategories[gender])
+			continue
+		}
+		for name := range newCategories[gender] {
+			child, exists := tree[categorySlug(gender, name)]
+			if !exists {
+				missing++
+				continue
+			}
+			// most specific category first, GetWCCategories relies on that order
+			categoryMap[gender][name] = []*int32{
+				&child.ID,
+				&parent.ID,
+			}
+		}
+	}
+
+	log.WithFields(
+		log.Fields{
+			"Created": len(parents) + len(children),
+			"Missing": missing,
+			"Applied": applyUpdate,
+		},
+	).Infoln("Synchronized Categories")
+
+	return categoryMap, nil
+}
+
+// createCategories sends the new categories in batches and returns the reloaded category tree
+func (w *WooConnection) createCategories(categories []gwc.Item) (tree map[string]*gwc.Category, err error) {
+	const endpoint = "products/categories/batch"
+
+	for start := 0; start < len(categories); start += BatchStrideSize {
+		end := start + BatchStrideSize
+		if end > len(categories) {
+			end = len(categories)
+		}
+		w.Connection.PushToQueue(
+			"categories",
+			gwc.BatchPostRequest{
+				Endpoint: endpoint,
+				Locale:   w.Locale,
+				Create:   categories[start:end],
+			},
+		)
+	}
+
+	_, err = w.Connection.ExecuteRequestQueue("categories", true, false)
+	if err != nil {
+		return tree, fmt.Errorf("Send category batches - %v", err)
+	}
+
+	// back off a little bit not to run into capacity issues
+	time.Sleep(2 * time.Second)
+
+	return w.fetchCategoryTree()
+}
+
+// fetchCategoryTree loads all product categories from the WC backend, indexed by slug
+func (w *WooConnection) fetchCategoryTree() (tree map[string]*gwc.Category, err error) {
+	const endpoint = "products/categories"
+
+	tree = make(map[string]*gwc.Category)
+
+	total, err := w.Connection.GetNumItems(endpoint, w.Locale)
+	if err != nil {
+		return tree, fmt.Errorf("Get number of categories - %v", err)
+	}
+	if total == 0 {
+		return tree, nil
+	}
+
+	for offset := 0; offset < total; offset += categoryPageSize {
+		w.Connection.PushToQueue(
+			"categories",
+			gwc.GetRequest{
+				Endpoint: endpoint,
+				Params: url.Values{
+					"lang": []string{
+						w.Locale,
+					},
+					"offset": []string{
+						strconv.Itoa(offset),
+					},
+					"per_page": []string{
+						strconv.Itoa(categoryPageSize),
+					},
+				},
+			},
+		)
+	}
+
+	rawResponse, err := w.Connection.ExecuteRequestQueue("categories", true, false)
+	if err != nil {
+		return tree, fmt.Errorf("Load categories - %v", err)
+	}
+
+	for i := range rawResponse {
+		if len(rawResponse[i]) < 1 {
+			continue
+		}
+		var categories []gwc.Category
+		err = json.Unmarshal(rawResponse[i], &categories)
+		if err != nil {
+			return tree, fmt.Errorf("Unmarshal categories - %v", err)
+		}
+		for j := range categories {
+			if categories[j].ID == 0 {
+				return tree, fmt.Errorf("Category returned without ID - %s", categories[j].Name)
+			}
+			tree[categories[j].Slug] = &categories[j]
+		}
+	}
+
+	return tree, nil
+}
+
+// extractCategories returns the unique category names per gender from the product feed
+func extractCategories(productMap *feed.ProductMap) map[string]map[string]struct{} {
+	categories := make(map[string]map[string]struct{})
+
+	pm, _, _, _ := productMap.Get()
+	for _, v := range pm {
+		for i := range v.ProviderCategories {
+			gender := string(v.ProviderCategories[i].Gender)
+			_, exists := GenderCategories[gender]
+			if !exists {
+				continue
+			}
+			name := strings.ToLower(strings.TrimSpace(v.ProviderCategories[i].Name))
+			if name == "" {
+				continue
+			}
+
+			_, exists = categories[gender]
+			if !exists {
+				categories[gender] = make(map[string]struct{})
+			}
+			categories[gender][name] = struct{}{}
+		}
+	}
+
+	return categories
+}
+
+// categorySlug builds the slug under which a category is stored in WC,
+// e.g. ("w", "") -> "women", ("w", "jeans") -> "women-jeans"
+func categorySlug(gender, name string) string {
+	if name == "" {
+		return c.Slugify(GenderCategories[gender])
+	}
+	return c.Slugify(GenderCategories[gender] + " " + name)
+}
@@ -16,3 +16,18 @@ type Attribute struct {
 func (a Attribute) GetID() int32 {
 	return a.ID
 }
+
+// AttributeTerm is a registered option of a global attribute
+type AttributeTerm struct {
+	ID          int32  `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug,omitempty"`
+	Description string `json:"description,omitempty"`
+	MenuOrder   int32  `json:"menu_order,omitempty"`
+	Count       int32  `json:"count,omitempty"` // read-only
+}
+
+// GetID implements Item for AttributeTerms
+func (t AttributeTerm) GetID() int32 {
+	return t.ID
+}
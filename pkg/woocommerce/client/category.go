@@ -22,7 +22,7 @@ type Category struct {
 	Slug        string        `json:"slug,omitempty"`
 	Parent      int32         `json:"parent,omitempty"`
 	Description string        `json:"description,omitempty"`
-	Image       Image         `json:"image,omitempty"`
+	Image       *Image        `json:"image,omitempty"`
 	MenuOrder   int32         `json:"menu_order,omitempty"`
 	Count       int32         `json:"count,omitempty"`
 	Links       CategoryLinks `json:"_links,omitempty"` // read-only
@@ -28,7 +28,9 @@ const (
 	Prefix         = "/wp-json/wc/"
 	ErrorLimit     = 10
 	RequestRetries = 2
-	requestTimeout = 10 * time.Minute
+	// ThrottleRetries - retries of a single request that was throttled by the backend
+	ThrottleRetries = 5
+	requestTimeout  = 10 * time.Minute
 )
 
 var (
@@ -43,6 +45,10 @@ type Client struct {
 	batchStrideSize       int // defines the size of one chunk for the batch upload
 	maxConcurrentRequests int // defines how many requests can be sent concurrently
 	requestQueue          map[string][]Request
+	journal               *Journal
+	limiter               *limiter
+	reportMux             *sync.Mutex
+	reports               map[string]QueueReport
 	domain, key, secret   string
 	rawClient             *http.Client
 	Timeout               time.Duration
@@ -71,6 +77,9 @@ func NewClient(domain, key, secret, version string, verifySSL bool, productsPerB
 		key:                   key,
 		secret:                secret,
 		rawClient:             rawClient,
+		limiter:               newLimiter(maxConcurrentRequests),
+		reportMux:             new(sync.Mutex),
+		reports:               make(map[string]QueueReport),
 	}
 
 	storeURL, err := url.Parse(domain)
@@ -212,9 +221,66 @@ func (w *Client) PushToQueue(name string, r Request) {
 	if !exist {
 		w.requestQueue[name] = make([]Request, 0)
 	}
+
+	// only batch requests change the backend and are worth resuming
+	b, isBatch := r.(BatchPostRequest)
+	if w.journal != nil && isBatch {
+		entry, err := w.journal.add(name, b)
+		if err != nil {
+			log.WithFields(
+				log.Fields{
+					"Queue": name,
+					"Error": err,
+				},
+			).Warnln("Failed to write journal")
+		} else {
+			r = journaledRequest{
+				journal: w.journal,
+				entry:   entry,
+				request: b,
+			}
+		}
+	}
+
 	w.requestQueue[name] = append(w.requestQueue[name], r)
 }
 
+// SetJournal records all batch requests pushed from now on in j
+func (w *Client) SetJournal(j *Journal) {
+	w.journal = j
+}
+
+// RequeueUnfinished pushes the journaled requests that haven't succeeded back onto their queues
+// and returns the names of the affected queues in the order they were first used
+func (w *Client) RequeueUnfinished() (queues []string, err error) {
+	if w.journal == nil {
+		return queues, fmt.Errorf("No journal set")
+	}
+	if w.requestQueue == nil {
+		w.requestQueue = make(map[string][]Request)
+	}
+
+	seen := make(map[string]struct{})
+	for _, entry := range w.journal.Unfinished() {
+		_, exist := seen[entry.Queue]
+		if !exist {
+			seen[entry.Queue] = struct{}{}
+			queues = append(queues, entry.Queue)
+		}
+
+		w.requestQueue[entry.Queue] = append(
+			w.requestQueue[entry.Queue],
+			journaledRequest{
+				journal: w.journal,
+				entry:   entry,
+				request: entry.ToRequest(),
+			},
+		)
+	}
+
+	return queues, nil
+}
+
 // ExecuteRequestQueue executes all the request that were pushed before and returns an array of the raw responses as bytes
 // if strict: returns on any error; else: finishes regardless of errors
 func (w *Client) ExecuteRequestQueue(name string, strict, verbose bool) (rawResponse [][]byte, err error) {
@@ -230,6 +296,7 @@ func (w *Client) ExecuteRequestQueue(name string, strict, verbose bool) (rawResp
 		return rawResponse, nil
 	}
 	var wg sync.WaitGroup
+	started := time.Now()
 
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
@@ -320,11 +387,72 @@ func (w *Client) ExecuteRequestQueue(name string, strict, verbose bool) (rawResp
 
 	wg.Wait()
 
+	report := QueueReport{
+		Queue:    name,
+		Requests: len(w.requestQueue[name]),
+		Failed:   atomic.LoadUint64(&errs),
+		Duration: time.Since(started),
+		Limits:   w.GetLimiterStats(),
+	}
+	w.setQueueReport(report)
+	log.WithFields(
+		log.Fields{
+			"Queue":       report.Queue,
+			"Requests":    report.Requests,
+			"Failed":      report.Failed,
+			"Duration":    report.Duration,
+			"Concurrency": fmt.Sprintf("%d/%d", report.Limits.Concurrency, report.Limits.MaxConcurrency),
+			"Throttled":   report.Limits.Throttled,
+			"Retries":     report.Limits.Retries,
+			"Avg Latency": report.Limits.AvgLatency,
+		},
+	).Infoln("Queue finished")
+
 	w.requestQueue[name] = nil
 
 	return rawResponse, nil
 }
 
+// QueueReport summarizes the latest execution of a request queue
+type QueueReport struct {
+	Queue    string        `json:"queue"`
+	Requests int           `json:"requests"`
+	Failed   uint64        `json:"failed"`
+	Duration time.Duration `json:"duration"`
+	Limits   LimiterStats  `json:"limits"`
+}
+
+// GetQueueReport returns the report of the latest execution of the named queue
+func (w *Client) GetQueueReport(name string) (report QueueReport, exists bool) {
+	if w.reportMux == nil {
+		return report, false
+	}
+	w.reportMux.Lock()
+	defer w.reportMux.Unlock()
+
+	report, exists = w.reports[name]
+	return report, exists
+}
+
+func (w *Client) setQueueReport(report QueueReport) {
+	if w.reportMux == nil {
+		w.reportMux = new(sync.Mutex)
+		w.reports = make(map[string]QueueReport)
+	}
+	w.reportMux.Lock()
+	defer w.reportMux.Unlock()
+
+	w.reports[report.Queue] = report
+}
+
+// GetLimiterStats returns the current request limits and how often the backend throttled the client
+func (w *Client) GetLimiterStats() LimiterStats {
+	if w.limiter == nil {
+		return LimiterStats{}
+	}
+	return w.limiter.getStats()
+}
+
 // ViewRequestQueue returns the marshalled requests as they will be sent by ExecuteRequestQueue
 func (w *Client) ViewRequestQueue() (output [][]byte, err error) {
 	var (
@@ -0,0 +1,317 @@
+package wooclient
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// JournalPending - request was queued but not sent yet
+	JournalPending = "pending"
+	// JournalSent - request is in flight
+	JournalSent = "sent"
+	// JournalSucceeded - request was accepted by the WC backend
+	JournalSucceeded = "succeeded"
+	// JournalFailed - request was rejected or timed out
+	JournalFailed = "failed"
+)
+
+// JournalEntry records a batch request and its latest status
+type JournalEntry struct {
+	ID          int               `json:"id"`
+	Queue       string            `json:"queue,omitempty"`
+	Status      string            `json:"status"`
+	Request     *journaledPayload `json:"request,omitempty"`
+	ResponseIDs []int32           `json:"response_ids,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Updated     time.Time         `json:"updated"`
+}
+
+type journaledPayload struct {
+	Endpoint string            `json:"endpoint"`
+	Locale   string            `json:"lang,omitempty"`
+	Create   []json.RawMessage `json:"create,omitempty"`
+	Update   []json.RawMessage `json:"update,omitempty"`
+	Delete   []int             `json:"delete,omitempty"`
+}
+
+// Journal persists the batch requests of a sync run, so an interrupted run can be resumed.
+// Every status change is appended as one JSON line, the last line of an entry wins when loading.
+type Journal struct {
+	mux     *sync.Mutex
+	file    *os.File
+	entries map[int]*JournalEntry
+	order   []int
+	nextID  int
+}
+
+// NewJournal opens the journal at path, resume keeps and loads the existing entries, otherwise the file is truncated
+func NewJournal(path string, resume bool) (j *Journal, err error) {
+	j = &Journal{
+		mux:     new(sync.Mutex),
+		entries: make(map[int]*JournalEntry),
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		err = j.load(path)
+		if err != nil {
+			return j, fmt.Errorf("Load journal - %v", err)
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	j.file, err = os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return j, fmt.Errorf("Open journal - %v", err)
+	}
+
+	return j, nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	return j.file.Close()
+}
+
+// Unfinished returns all entries that haven't succeeded, in the order they were queued
+func (j *Journal) Unfinished() (entries []*JournalEntry) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	for _, id := range j.order {
+		if j.entries[id].Status != JournalSucceeded {
+			entries = append(entries, j.entries[id])
+		}
+	}
+	return entries
+}
+
+// Stats returns the number of entries per status
+func (j *Journal) Stats() map[string]int {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	stats := make(map[string]int, 4)
+	for id := range j.entries {
+		stats[j.entries[id].Status]++
+	}
+	return stats
+}
+
+func (j *Journal) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 256*1024*1024)
+	for scanner.Scan() {
+		var e JournalEntry
+		err = json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			// the last line might be incomplete if the process was killed while writing
+			log.WithField("Error", err).Warnln("Skipping corrupt journal line")
+			continue
+		}
+
+		current, exists := j.entries[e.ID]
+		if !exists {
+			if e.Request == nil {
+				continue
+			}
+			j.entries[e.ID] = &e
+			j.order = append(j.order, e.ID)
+		} else {
+			current.Status = e.Status
+			current.ResponseIDs = e.ResponseIDs
+			current.Error = e.Error
+			current.Updated = e.Updated
+		}
+
+		if e.ID >= j.nextID {
+			j.nextID = e.ID + 1
+		}
+	}
+
+	return scanner.Err()
+}
+
+// add records a new batch request as pending
+func (j *Journal) add(queue string, b BatchPostRequest) (e *JournalEntry, err error) {
+	payload := &journaledPayload{
+		Endpoint: b.Endpoint,
+		Locale:   b.Locale,
+		Delete:   b.Delete,
+	}
+	payload.Create, err = marshalItems(b.Create)
+	if err != nil {
+		return e, err
+	}
+	payload.Update, err = marshalItems(b.Update)
+	if err != nil {
+		return e, err
+	}
+
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	e = &JournalEntry{
+		ID:      j.nextID,
+		Queue:   queue,
+		Status:  JournalPending,
+		Request: payload,
+		Updated: time.Now(),
+	}
+	j.nextID++
+	j.entries[e.ID] = e
+	j.order = append(j.order, e.ID)
+
+	return e, j.write(e)
+}
+
+// setStatus records a status change of an entry, the request payload is only written once
+func (j *Journal) setStatus(e *JournalEntry, status string, responseIDs []int32, reqErr error) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	e.Status = status
+	e.ResponseIDs = responseIDs
+	e.Error = ""
+	if reqErr != nil {
+		e.Error = reqErr.Error()
+	}
+	e.Updated = time.Now()
+
+	return j.write(
+		&JournalEntry{
+			ID:          e.ID,
+			Status:      e.Status,
+			ResponseIDs: e.ResponseIDs,
+			Error:       e.Error,
+			Updated:     e.Updated,
+		},
+	)
+}
+
+func (j *Journal) write(e *JournalEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = j.file.Write(append(line, '\n'))
+	return err
+}
+
+// journaledRequest wraps a batch request and tracks its status in the journal
+type journaledRequest struct {
+	journal *Journal
+	entry   *JournalEntry
+	request BatchPostRequest
+}
+
+// Send implements the Request interface
+func (r journaledRequest) Send(w *Client) ([]byte, error) {
+	err := r.journal.setStatus(r.entry, JournalSent, nil, nil)
+	if err != nil {
+		log.WithField("Error", err).Warnln("Failed to write journal")
+	}
+
+	body, err := r.request.Send(w)
+	if err != nil {
+		err2 := r.journal.setStatus(r.entry, JournalFailed, nil, err)
+		if err2 != nil {
+			log.WithField("Error", err2).Warnln("Failed to write journal")
+		}
+		return body, err
+	}
+
+	err = r.journal.setStatus(r.entry, JournalSucceeded, extractResponseIDs(body), nil)
+	if err != nil {
+		log.WithField("Error", err).Warnln("Failed to write journal")
+	}
+
+	return body, nil
+}
+
+// MarshalJSON marshals the wrapped request, so the queue can still be inspected
+func (r journaledRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.request)
+}
+
+// ToRequest rebuilds the batch request of a journal entry
+func (e *JournalEntry) ToRequest() BatchPostRequest {
+	b := BatchPostRequest{
+		Endpoint: e.Request.Endpoint,
+		Locale:   e.Request.Locale,
+		Delete:   e.Request.Delete,
+	}
+	for i := range e.Request.Create {
+		b.Create = append(b.Create, rawItem(e.Request.Create[i]))
+	}
+	for i := range e.Request.Update {
+		b.Update = append(b.Update, rawItem(e.Request.Update[i]))
+	}
+	return b
+}
+
+// rawItem is an already marshalled Item as stored in the journal
+type rawItem json.RawMessage
+
+// GetID implements Item, the ID is only needed when building the payload
+func (r rawItem) GetID() int32 {
+	var item struct {
+		ID int32 `json:"id"`
+	}
+	_ = json.Unmarshal(r, &item)
+	return item.ID
+}
+
+// MarshalJSON returns the stored payload
+func (r rawItem) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(r).MarshalJSON()
+}
+
+func marshalItems(items []Item) (raw []json.RawMessage, err error) {
+	raw = make([]json.RawMessage, len(items))
+	for i := range items {
+		raw[i], err = json.Marshal(items[i])
+		if err != nil {
+			return raw, err
+		}
+	}
+	return raw, nil
+}
+
+// extractResponseIDs collects the IDs of all items in a batch response
+func extractResponseIDs(body []byte) (ids []int32) {
+	var resp map[string][]struct {
+		ID int32 `json:"id"`
+	}
+	err := json.Unmarshal(body, &resp)
+	if err != nil {
+		return ids
+	}
+	for _, key := range []string{"create", "update", "delete"} {
+		for i := range resp[key] {
+			if resp[key][i].ID != 0 {
+				ids = append(ids, resp[key][i].ID)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,87 @@
+// +build unit
+// +build !integration
+
+package wooclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	j, err := NewJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Client{}
+	w.SetJournal(j)
+	for i := 0; i < 3; i++ {
+		w.PushToQueue(
+			"createupdate",
+			BatchPostRequest{
+				Endpoint: "products/batch",
+				Create: []Item{
+					Category{
+						Name: fmt.Sprintf("Category %d", i),
+					},
+				},
+			},
+		)
+	}
+
+	// the first request went through, the second one failed, the third one was never sent
+	entries := j.Unfinished()
+	j.setStatus(entries[0], JournalSucceeded, extractResponseIDs([]byte(`{"create":[{"id":42}]}`)), nil)
+	j.setStatus(entries[1], JournalFailed, nil, fmt.Errorf("timeout"))
+	j.Close()
+
+	j, err = NewJournal(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	stats := j.Stats()
+	if stats[JournalSucceeded] != 1 || stats[JournalFailed] != 1 || stats[JournalPending] != 1 {
+		t.Fatalf("Wrong statuses after reload - %v", stats)
+	}
+
+	w = &Client{}
+	w.SetJournal(j)
+	queues, err := w.RequeueUnfinished()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queues) != 1 || len(w.requestQueue["createupdate"]) != 2 {
+		t.Fatalf("Failed to requeue unfinished requests - %v", queues)
+	}
+
+	raw, err := json.Marshal(w.requestQueue["createupdate"][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := json.Marshal(
+		BatchPostRequest{
+			Create: []Item{
+				Category{
+					Name: "Category 1",
+				},
+			},
+		},
+	)
+	if string(raw) != string(expected) {
+		t.Fatalf("Payload changed while resuming - %s", raw)
+	}
+}
@@ -0,0 +1,183 @@
+package wooclient
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// backoffBase is the first wait after a throttled or failed request, it doubles with every attempt
+	backoffBase = 2 * time.Second
+	// backoffMax caps the wait between two attempts
+	backoffMax = 2 * time.Minute
+	// targetLatency - slower responses mean the PHP workers are saturated and concurrency is reduced
+	targetLatency = 30 * time.Second
+)
+
+// LimiterStats shows how the client adapted to the backend
+type LimiterStats struct {
+	Concurrency    int           `json:"concurrency"`
+	MaxConcurrency int           `json:"max_concurrency"`
+	Requests       uint64        `json:"requests"`
+	Throttled      uint64        `json:"throttled"`
+	Retries        uint64        `json:"retries"`
+	AvgLatency     time.Duration `json:"avg_latency"`
+}
+
+// limiter caps the number of requests in flight and adjusts the cap to the backend:
+// it halves on throttling, errors or slow responses and grows by one after a streak of fast successes
+type limiter struct {
+	mux          *sync.Mutex
+	cond         *sync.Cond
+	limit        int
+	maxLimit     int
+	inFlight     int
+	streak       int
+	blockedUntil time.Time
+	avgLatency   time.Duration
+	stats        LimiterStats
+}
+
+func newLimiter(maxConcurrency int) *limiter {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	l := &limiter{
+		mux:      new(sync.Mutex),
+		limit:    maxConcurrency,
+		maxLimit: maxConcurrency,
+	}
+	l.cond = sync.NewCond(l.mux)
+	return l
+}
+
+// acquire blocks until a request may be sent
+func (l *limiter) acquire() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	for {
+		wait := time.Until(l.blockedUntil)
+		if wait > 0 {
+			l.mux.Unlock()
+			time.Sleep(wait)
+			l.mux.Lock()
+			continue
+		}
+		if l.inFlight < l.limit {
+			break
+		}
+		l.cond.Wait()
+	}
+	l.inFlight++
+	l.stats.Requests++
+}
+
+// release frees the slot of a finished request and adapts the limit to its outcome,
+// retryAfter > 0 pauses all requests for that duration
+func (l *limiter) release(latency time.Duration, throttled, failed bool, retryAfter time.Duration) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.inFlight--
+	defer l.cond.Broadcast()
+
+	if l.avgLatency == 0 {
+		l.avgLatency = latency
+	} else {
+		// exponentially weighted, recent requests matter most
+		l.avgLatency = (4*l.avgLatency + latency) / 5
+	}
+
+	if throttled {
+		l.stats.Throttled++
+	}
+	if retryAfter > 0 {
+		until := time.Now().Add(retryAfter)
+		if until.After(l.blockedUntil) {
+			l.blockedUntil = until
+		}
+	}
+
+	if throttled || failed || latency > targetLatency {
+		l.streak = 0
+		if l.limit > 1 {
+			l.limit = l.limit / 2
+			log.WithFields(
+				log.Fields{
+					"Concurrency": l.limit,
+					"Latency":     latency,
+					"Throttled":   throttled,
+				},
+			).Warnln("Reduced request concurrency")
+		}
+		return
+	}
+
+	l.streak++
+	if l.streak >= l.limit && l.limit < l.maxLimit {
+		l.streak = 0
+		l.limit++
+		log.WithField("Concurrency", l.limit).Infoln("Increased request concurrency")
+	}
+}
+
+func (l *limiter) retried() {
+	l.mux.Lock()
+	l.stats.Retries++
+	l.mux.Unlock()
+}
+
+func (l *limiter) getStats() LimiterStats {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	stats := l.stats
+	stats.Concurrency = l.limit
+	stats.MaxConcurrency = l.maxLimit
+	stats.AvgLatency = l.avgLatency
+	return stats
+}
+
+// isThrottled reports responses that ask us to slow down
+func isThrottled(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// isRetryable reports responses that might succeed when sent again
+func isRetryable(statusCode int) bool {
+	return isThrottled(statusCode) ||
+		statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusGatewayTimeout
+}
+
+// parseRetryAfter reads the Retry-After header, either in seconds or as a HTTP date
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(header)
+	if err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	date, err := http.ParseTime(header)
+	if err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// backoff returns the exponential wait before the given attempt with up to 50% jitter,
+// so concurrent workers don't hit the backend at the same time again
+func backoff(attempt int) time.Duration {
+	wait := backoffBase << uint(attempt)
+	if wait > backoffMax || wait <= 0 {
+		wait = backoffMax
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
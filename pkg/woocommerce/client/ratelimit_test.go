@@ -0,0 +1,70 @@
+// +build unit
+// +build !integration
+
+package wooclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(4)
+
+	l.acquire()
+	l.release(time.Second, true, true, 0)
+	if stats := l.getStats(); stats.Concurrency != 2 || stats.Throttled != 1 {
+		t.Fatalf("Failed to back off after throttling - %v", stats)
+	}
+
+	for i := 0; i < 2; i++ {
+		l.acquire()
+		l.release(time.Second, false, false, 0)
+	}
+	if stats := l.getStats(); stats.Concurrency != 3 {
+		t.Fatalf("Failed to recover after successes - %v", stats)
+	}
+
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Fatalf("Failed to parse Retry-After - %v", d)
+	}
+	for i := 0; i < 10; i++ {
+		if d := backoff(i); d <= 0 || d > backoffMax {
+			t.Fatalf("Backoff out of bounds - %v", d)
+		}
+	}
+}
+
+func TestRequestRetriesThrottled(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rw.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	w, err := NewClient(server.URL, "key", "secret", "v3", true, 4, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := w.request("GET", "products", nil, nil)
+	if err != nil {
+		t.Fatalf("Throttled request wasn't retried - %v", err)
+	}
+	body, _ := ioutil.ReadAll(rc)
+	rc.Close()
+
+	stats := w.GetLimiterStats()
+	if string(body) != "[]" || calls != 2 || stats.Retries != 1 || stats.Throttled != 1 {
+		t.Fatalf("Unexpected retry behaviour - %d calls, %v", calls, stats)
+	}
+}
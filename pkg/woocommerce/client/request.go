@@ -19,6 +19,10 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/metrics"
 )
 
 // Request is implemented for Batch/Post and Get
@@ -170,35 +174,75 @@ func (w *Client) request(method, endpoint string, params url.Values, data interf
 	if err := encoder.Encode(data); err != nil {
 		return rc, err
 	}
+	payload := body.Bytes()
 
-	req, err := http.NewRequest(method, urlstr, body)
-	if err != nil {
-		return rc, err
+	if w.limiter == nil {
+		w.limiter = newLimiter(w.maxConcurrentRequests)
 	}
-	req.SetBasicAuth(w.key, w.secret)
-	req.Header.Set("Content-Type", "application/json")
-
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
-
-	req = req.WithContext(ctx)
-
-	url := req.URL.String()
 
-	resp, err := w.rawClient.Do(req)
-	if err != nil {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, urlstr, bytes.NewReader(payload))
+		if err != nil {
+			return rc, err
+		}
+		req.SetBasicAuth(w.key, w.secret)
+		req.Header.Set("Content-Type", "application/json")
+
+		ctx := context.Background()
+		ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+
+		req = req.WithContext(ctx)
+
+		url := req.URL.String()
+
+		w.limiter.acquire()
+		start := time.Now()
+		resp, err := w.rawClient.Do(req)
+		latency := time.Since(start)
+		metrics.WCRequestSeconds.Observe(latency.Seconds(), method)
+		if err != nil {
+			metrics.WCResponses.Inc(method, "error")
+			cancel()
+			w.limiter.release(latency, false, true, 0)
+			return rc, err
+		}
+		metrics.WCResponses.Inc(method, strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode == http.StatusOK ||
+			resp.StatusCode == http.StatusAccepted ||
+			resp.StatusCode == http.StatusCreated {
+
+			w.limiter.release(latency, false, false, 0)
+			return resp.Body, nil
+		}
+
+		resp.Body.Close()
 		cancel()
-		return rc, err
-	}
-	if resp.StatusCode != http.StatusOK &&
-		resp.StatusCode != http.StatusAccepted &&
-		resp.StatusCode != http.StatusCreated {
 
-		cancel()
-		return rc, fmt.Errorf("Request failed: %s - %s", resp.Status, url)
-	}
+		throttled := isThrottled(resp.StatusCode)
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		w.limiter.release(latency, throttled, true, retryAfter)
+
+		// gateway errors on writes might have been processed anyway, only retry those for reads
+		retry := throttled || (method == http.MethodGet && isRetryable(resp.StatusCode))
+		if !retry || attempt >= ThrottleRetries {
+			return rc, fmt.Errorf("Request failed: %s - %s", resp.Status, url)
+		}
+
+		wait := backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		log.WithFields(
+			log.Fields{
+				"Status":  resp.Status,
+				"Attempt": attempt + 1,
+				"Wait":    wait,
+			},
+		).Warnln("Backing off")
 
-	return resp.Body, nil
+		w.limiter.retried()
+		time.Sleep(wait)
+	}
 }
 
 func (w *Client) basicAuth(params url.Values) string {
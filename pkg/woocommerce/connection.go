@@ -15,6 +15,8 @@ import (
 	c "stillgrove.com/gofeedyourself/pkg/collection"
 	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
 	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+	"stillgrove.com/gofeedyourself/pkg/metrics"
+	"stillgrove.com/gofeedyourself/pkg/ranking"
 	gwc "stillgrove.com/gofeedyourself/pkg/woocommerce/client"
 )
 
@@ -43,6 +45,12 @@ type WooConnection struct {
 	attributeMap map[string]int32
 	brandMap     map[uint64]int32
 	categoryMap  *map[string]map[string]int32
+	journal      *gwc.Journal
+	diff         *DiffReport
+	guard        *DeletionGuard
+	ranking      *ranking.Model
+	deletions    *deletionState // state of the prepared update, stored once the delete queue was applied
+	partial      bool           // only some of the feeds are in the update
 	//mappings     ProductMapping
 	Locale string
 }
@@ -111,6 +119,115 @@ func (w *WooConnection) ApplyUpdate(name string, output string) (err error) {
 		return err
 	}
 
+	if name == "delete" {
+		err = w.commitDeletionState()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetPartialUpdate marks the next update as covering only some of the feeds,
+// products and attribute terms missing from it are kept
+func (w *WooConnection) SetPartialUpdate(partial bool) {
+	w.partial = partial
+}
+
+// GetDiffReport returns the diff of the latest prepared update
+func (w *WooConnection) GetDiffReport() *DiffReport {
+	return w.diff
+}
+
+// saveDiffReport prints the diff summary and writes the full report as JSON and HTML to the logs folder for review
+func (w *WooConnection) saveDiffReport() error {
+	if w.diff == nil {
+		return fmt.Errorf("No update prepared")
+	}
+
+	fmt.Print(w.diff.Summary(10))
+
+	fname := fmt.Sprintf(helpers.FindFolderDir("gofeedyourself")+"/logs/diff_%s", time.Now().Format("2006-01-02"))
+	err := w.diff.WriteJSON(fname + ".json")
+	if err != nil {
+		return fmt.Errorf("Write JSON - %v", err)
+	}
+	err = w.diff.WriteHTML(fname + ".html")
+	if err != nil {
+		return fmt.Errorf("Write HTML - %v", err)
+	}
+
+	log.WithField("File", fname+".html").Infoln("Saved diff report")
+
+	return nil
+}
+
+// JournalFile is where the batch requests of the latest sync run are journaled
+func JournalFile() string {
+	return helpers.FindFolderDir("gofeedyourself") + "/logs/sync_journal.jsonl"
+}
+
+// StartJournal records all batch requests queued from now on in a fresh journal, replacing the one of the previous run
+func (w *WooConnection) StartJournal() error {
+	if w.journal != nil {
+		w.journal.Close()
+	}
+
+	journal, err := gwc.NewJournal(JournalFile(), false)
+	if err != nil {
+		return fmt.Errorf("Start request journal - %v", err)
+	}
+	w.journal = journal
+	w.Connection.SetJournal(journal)
+
+	return nil
+}
+
+// ResumeUpdate replays the batch requests of the latest journaled run that never succeeded
+func (w *WooConnection) ResumeUpdate() error {
+	if w.initialized == false {
+		return fmt.Errorf("Please initialize with your credentials first. WooConnection.Init()")
+	}
+
+	journal, err := gwc.NewJournal(JournalFile(), true)
+	if err != nil {
+		return fmt.Errorf("Load request journal - %v", err)
+	}
+	defer journal.Close()
+
+	w.journal = journal
+	w.Connection.SetJournal(journal)
+
+	queues, err := w.Connection.RequeueUnfinished()
+	if err != nil {
+		return fmt.Errorf("Requeue unfinished requests - %v", err)
+	}
+	if len(queues) == 0 {
+		log.Infoln("Nothing to resume, all journaled requests succeeded")
+		return nil
+	}
+
+	for _, name := range queues {
+		err = w.ApplyUpdate(name, "api")
+		if err != nil {
+			return fmt.Errorf("Resume queue %s - %v", name, err)
+		}
+	}
+
+	stats := journal.Stats()
+	log.WithFields(
+		log.Fields{
+			"Succeeded": stats[gwc.JournalSucceeded],
+			"Failed":    stats[gwc.JournalFailed],
+			"Pending":   stats[gwc.JournalPending] + stats[gwc.JournalSent],
+		},
+	).Infoln("Resumed update")
+
+	if stats[gwc.JournalFailed] > 0 {
+		return fmt.Errorf("%d requests still failed, run resume again", stats[gwc.JournalFailed])
+	}
+
 	return nil
 }
 
@@ -145,8 +262,10 @@ func (w *WooConnection) SaveUpdateToFile(filename string, filetype string) error
 -- Request Queues --------------------------------------
 -------------------------------------------------------*/
 
-// PrepareUpdate merges existing products in the WooCommerce backend with the suggested update
-func (w *WooConnection) PrepareUpdate(products *feed.ProductMap, categoryMap map[string]map[string][]*int32, productionFlag, purgeFlag bool) (err error) {
+// PrepareUpdate merges existing products in the WooCommerce backend with the suggested update.
+// Categories and attribute terms are only written and deletions only queued if applyUpdate is set,
+// otherwise the diff report is saved for review
+func (w *WooConnection) PrepareUpdate(products *feed.ProductMap, applyUpdate, purgeFlag bool) (err error) {
 	if w.initialized == false {
 		err = errors.New("Please initialize with your credentials first. WooConnection.Init()")
 		return fmt.Errorf("Update products in WC backend - %v", err)
@@ -160,7 +279,7 @@ func (w *WooConnection) PrepareUpdate(products *feed.ProductMap, categoryMap map
 	}
 	log.Printf("Preparing %d Products from %d feeds with %d categories\n", inProducts, inFeeds, inCategories)
 
-	mappings, err := w.PrepareMappings(products, categoryMap, productionFlag)
+	mappings, err := w.PrepareMappings(products, applyUpdate)
 	if err != nil {
 		return fmt.Errorf("Prepare Updates - %v", err)
 	}
@@ -170,23 +289,63 @@ func (w *WooConnection) PrepareUpdate(products *feed.ProductMap, categoryMap map
 		return fmt.Errorf("Convert products to WC - %v", err)
 	}
 
-	oldProductMap, err := w.GetOldProductMap(productionFlag)
+	// the diff report needs the whole catalogue to be accurate, dev runs included
+	oldProducts, err := w.getOldProducts(true)
 	if err != nil {
 		return fmt.Errorf("Fetch current products from the WC backend - %v", err)
 	}
+	oldProductMap := make(map[uint64]uint64, len(oldProducts))
+	for key := range oldProducts {
+		oldProductMap[key] = oldProducts[key].ID
+	}
 
 	create, update, delete, err := newProducts.GetGroups(oldProductMap)
 	if err != nil {
 		return fmt.Errorf("Prepare create/update/delete groups - %v", err)
 	}
-	newProducts.Flush()
 
-	if productionFlag == true {
-		err = w.BuildDeleteProductQueue(delete)
+	if w.partial {
+		delete = nil
+	}
+	metrics.SyncProducts.Set(float64(len(create)), "create")
+	metrics.SyncProducts.Set(float64(len(update)), "update")
+	metrics.SyncProducts.Set(float64(len(delete)), "delete")
+
+	// a partial update says nothing about the products of the other feeds
+	var plan DeletionPlan
+	switch {
+	case w.partial:
+	case applyUpdate:
+		plan, err = w.BuildGuardedDeleteQueue(delete, update, len(oldProductMap), len(create)+len(update))
 		if err != nil {
 			return fmt.Errorf("Build product delete queue- %v", err)
 		}
+	default:
+		plan, _, err = w.planDeletions(delete, update, len(oldProductMap), len(create)+len(update))
+		if err != nil {
+			log.WithField("Error", err).Warnln("Diff report lists the deletions without the deletion guard")
+			plan = DeletionPlan{Deleted: delete}
+		}
 	}
+
+	w.diff = NewDiffReport(create, update, plan, oldProducts)
+
+	// the terms might still be used by the products of the other feeds
+	if !w.partial {
+		err = w.pruneTerms(mappings.orphanTerms, oldProducts, plan.Deleted, applyUpdate)
+		if err != nil {
+			return fmt.Errorf("Prune attribute terms - %v", err)
+		}
+	}
+	oldProducts = nil
+	if !applyUpdate {
+		err = w.saveDiffReport()
+		if err != nil {
+			log.WithField("Error", err).Warnln("Failed to save diff report")
+		}
+	}
+	newProducts.Flush()
+
 	err = w.BuildCreateUpdateProductQueue(create, update, purgeFlag)
 	if err != nil {
 		return fmt.Errorf("Build product update queue- %v", err)
@@ -376,17 +535,29 @@ func (w *WooConnection) BuildCreateUpdateProductQueue(create map[uint64]*Product
 }
 
 // PrepareMappings returns mappings object to be used for product conversion
-func (w *WooConnection) PrepareMappings(newProductMap *feed.ProductMap, categoryMap map[string]map[string][]*int32, productionFlag bool) (mappings ProductMapping, err error) {
-	mappings.categoryMap = categoryMap
-	mappings.brandMap, err = w.generateBrandMap(newProductMap)
+func (w *WooConnection) PrepareMappings(newProductMap *feed.ProductMap, applyUpdate bool) (mappings ProductMapping, err error) {
+	mappings.categoryMap, err = w.generateCategoryMap(newProductMap, applyUpdate)
+	if err != nil {
+		return mappings, fmt.Errorf("Synchronize Categories - %v", err)
+	}
+	mappings.brandMap, err = w.generateBrandMap(newProductMap, applyUpdate)
 	if err != nil {
 		return mappings, fmt.Errorf("Synchronize Brands - %v", err)
 	}
-	mappings.attributeMap, err = w.prepareAttributes(newProductMap, productionFlag)
+	mappings.attributeMap, err = w.prepareAttributes(newProductMap, applyUpdate)
 	if err != nil {
 		return mappings, fmt.Errorf("Check/update attributes in WC backend - %v", err)
 	}
+	newTermMap, err := extractTermMap(newProductMap)
+	if err != nil {
+		return mappings, fmt.Errorf("Extracting attribute terms from new product feed - %v", err)
+	}
+	mappings.termMap, mappings.orphanTerms, err = w.generateTermMap(newTermMap, mappings.attributeMap, applyUpdate)
+	if err != nil {
+		return mappings, fmt.Errorf("Synchronize attribute terms - %v", err)
+	}
 	mappings.discountBinSize = 10
+	mappings.ranking = w.getRanking()
 
 	return mappings, nil
 }
@@ -554,12 +725,15 @@ func (w *WooConnection) fetchCurrentAttributeMap() (currentAttributeMap map[stri
 	return currentAttributeMap, nil
 }
 
-// GetBrandMap generates a name <-> id map for PerfectWooCommerce Brands
-func (w *WooConnection) generateBrandMap(newProductMap *feed.ProductMap) (brandMap map[uint64]*int32, err error) {
+// GetBrandMap generates a name <-> id map for PerfectWooCommerce Brands, missing brands are only created if applyUpdate is set
+func (w *WooConnection) generateBrandMap(newProductMap *feed.ProductMap, applyUpdate bool) (brandMap map[uint64]*int32, err error) {
 	brandMap, err = w.fetchBrandMap()
 	if err != nil {
 		return brandMap, fmt.Errorf("Fetch existing brand map - %v", err)
 	}
+	if !applyUpdate {
+		return brandMap, nil
+	}
 
 	newBrands := newProductMap.GetBrands()
 
@@ -630,7 +804,23 @@ func (w *WooConnection) fetchBrandMap() (brandMap map[uint64]*int32, err error)
 	return brandMap, nil
 }
 
+// GetOldProductMap returns the products currently in the WC backend as a map of key -> WC id
 func (w *WooConnection) GetOldProductMap(productionFlag bool) (productMap map[uint64]uint64, err error) {
+	oldProducts, err := w.getOldProducts(productionFlag)
+	if err != nil {
+		return productMap, err
+	}
+
+	productMap = make(map[uint64]uint64, len(oldProducts))
+	for key := range oldProducts {
+		productMap[key] = oldProducts[key].ID
+	}
+
+	return productMap, nil
+}
+
+// getOldProducts loads the products currently in the WC backend, a dev run only loads the first 500
+func (w *WooConnection) getOldProducts(productionFlag bool) (productMap map[uint64]*productSnapshot, err error) {
 	file := helpers.FindFolderDir("gofeedyourself") + "/cache/" + time.Now().Format("2006-01-02") + "_wc"
 	cache, err := cache.NewBadgerCache(file, 4*time.Hour)
 	if err != nil {
@@ -696,7 +886,7 @@ func (w *WooConnection) GetOldProductMap(productionFlag bool) (productMap map[ui
 		}
 	}
 
-	productMap = make(map[uint64]uint64, totalNumProducts)
+	productMap = make(map[uint64]*productSnapshot, totalNumProducts)
 	stored, err := cache.LoadAll()
 	if err != nil {
 		return productMap, fmt.Errorf("Retrieve Old Products From Cache - %v", err)
@@ -713,7 +903,7 @@ func (w *WooConnection) GetOldProductMap(productionFlag bool) (productMap map[ui
 
 		for i := range oldProducts {
 			key = oldProducts[i].GetKey()
-			productMap[uint64(key)] = oldProducts[i].ID
+			productMap[uint64(key)] = snapshotFromProduct(&oldProducts[i])
 		}
 	}
 
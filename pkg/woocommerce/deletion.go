@@ -0,0 +1,292 @@
+package woocommerce
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"stillgrove.com/gofeedyourself/pkg/cache"
+	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
+	gwc "stillgrove.com/gofeedyourself/pkg/woocommerce/client"
+)
+
+const (
+	// deletionStateKey is the cache key under which the deletion state is stored
+	deletionStateKey = "deletion_state"
+	// deletionStateTTL - the state is dropped if there was no production run for this long
+	deletionStateTTL = 30 * 24 * time.Hour
+)
+
+// DeletionGuard holds back mass deletions, e.g. after a partial feed outage
+type DeletionGuard struct {
+	MaxDeleteShare float64 // maximum share of the products in the backend that may be deleted in one run
+	MaxDropShare   float64 // maximum drop of the product count compared to the last run
+	SoftDeleteRuns int     // runs a product has to be missing before it is deleted, it's hidden before
+	Override       bool    // apply the deletions even if a limit is hit
+
+	HideAfter   time.Duration // missing products are only set out of stock until then, afterwards they are hidden
+	DeleteAfter time.Duration // missing products are deleted after this long, given they missed SoftDeleteRuns
+}
+
+// DefaultDeletionGuard is used unless the config says otherwise
+var DefaultDeletionGuard = DeletionGuard{
+	MaxDeleteShare: 0.2,
+	MaxDropShare:   0.3,
+	SoftDeleteRuns: 3,
+	HideAfter:      3 * 24 * time.Hour,
+	DeleteAfter:    28 * 24 * time.Hour,
+}
+
+// deletionState is persisted between production runs
+type deletionState struct {
+	LastCount int           `json:"last_count"`
+	Missed    map[int]int   `json:"missed"`    // WC id -> number of consecutive runs the product was missing
+	LastSeen  map[int]int64 `json:"last_seen"` // WC id -> unix time the product was last in a feed
+}
+
+// softDeletion hides a product without touching any of its other fields
+type softDeletion struct {
+	ID          int    `json:"id"`
+	Status      string `json:"status"`
+	StockStatus string `json:"stock_status"`
+}
+
+// GetID implements gwc.Item
+func (s softDeletion) GetID() int32 {
+	return int32(s.ID)
+}
+
+// SetDeletionGuard replaces the DefaultDeletionGuard
+func (w *WooConnection) SetDeletionGuard(guard DeletionGuard) {
+	w.guard = &guard
+}
+
+func (w *WooConnection) getDeletionGuard() DeletionGuard {
+	if w.guard == nil {
+		return DefaultDeletionGuard
+	}
+	return *w.guard
+}
+
+// checkDeletions compares the planned deletions with the limits of the guard
+func checkDeletions(guard DeletionGuard, nDelete, nOld, nNew, lastCount int) error {
+	if nOld > 0 && guard.MaxDeleteShare > 0 {
+		share := float64(nDelete) / float64(nOld)
+		if share > guard.MaxDeleteShare {
+			return fmt.Errorf("%d of %d products (%.0f%%) would be deleted, the limit is %.0f%%", nDelete, nOld, share*100, guard.MaxDeleteShare*100)
+		}
+	}
+	if lastCount > 0 && guard.MaxDropShare > 0 {
+		drop := float64(lastCount-nNew) / float64(lastCount)
+		if drop > guard.MaxDropShare {
+			return fmt.Errorf("Product count dropped from %d to %d (%.0f%%), the limit is %.0f%%", lastCount, nNew, drop*100, guard.MaxDropShare*100)
+		}
+	}
+	return nil
+}
+
+// splitDeletions counts another missed run for every product to delete, records when the products in the update
+// were last seen and sorts the missing products into their lifecycle stage
+func splitDeletions(state *deletionState, guard DeletionGuard, delete []int, seen map[int]int64, now time.Time) (outOfStock, hidden, hard []int) {
+	missed := make(map[int]int, len(delete))
+	lastSeen := make(map[int]int64, len(seen)+len(delete))
+	for id := range seen {
+		lastSeen[id] = seen[id]
+	}
+
+	for _, id := range delete {
+		// products that came back are not in delete and start over
+		missed[id] = state.Missed[id] + 1
+
+		ts, exists := state.LastSeen[id]
+		if !exists {
+			// missing since before the lifecycle was tracked, the grace period starts now
+			ts = now.Unix()
+		}
+		lastSeen[id] = ts
+
+		switch lifecycleStage(guard, time.Unix(ts, 0), now, missed[id]) {
+		case stageDeleted:
+			hard = append(hard, id)
+		case stageHidden:
+			hidden = append(hidden, id)
+		default:
+			outOfStock = append(outOfStock, id)
+		}
+	}
+	state.Missed = missed
+	state.LastSeen = lastSeen
+
+	return outOfStock, hidden, hard
+}
+
+// DeletionPlan sorts the products missing from the feeds by what the DeletionGuard does with them
+type DeletionPlan struct {
+	OutOfStock []int // WC ids set out of stock
+	Hidden     []int // WC ids set to draft
+	Deleted    []int // WC ids deleted
+	HeldBack   []int // WC ids left alone because a limit of the guard was hit
+}
+
+// planDeletions checks the deletions against the DeletionGuard and sorts them into their lifecycle stage,
+// the state is nil if the deletions are held back
+func (w *WooConnection) planDeletions(delete []int, update map[uint64]*Product, nOld, nNew int) (plan DeletionPlan, state *deletionState, err error) {
+	guard := w.getDeletionGuard()
+
+	c, err := w.openDeletionCache()
+	if err != nil {
+		return plan, nil, err
+	}
+	loaded, err := loadDeletionState(c)
+	c.Close()
+	if err != nil {
+		return plan, nil, fmt.Errorf("Load deletion state - %v", err)
+	}
+
+	// products that are already hidden don't count again against the limit
+	var newlyMissing int
+	for _, id := range delete {
+		_, exists := loaded.Missed[id]
+		if !exists {
+			newlyMissing++
+		}
+	}
+
+	err = checkDeletions(guard, newlyMissing, nOld, nNew, loaded.LastCount)
+	if err != nil {
+		if !guard.Override {
+			// the state is left alone, the run that hit the limit doesn't count
+			log.WithFields(
+				log.Fields{
+					"Reason": err,
+				},
+			).Errorln("Holding back deletions, override the deletion guard to apply them")
+			plan.HeldBack = delete
+			return plan, nil, nil
+		}
+		log.WithField("Reason", err).Warnln("Deletion guard overridden")
+	}
+
+	now := time.Now()
+	plan.OutOfStock, plan.Hidden, plan.Deleted = splitDeletions(&loaded, guard, delete, collectLastSeen(update, now), now)
+	loaded.LastCount = nNew
+
+	return plan, &loaded, nil
+}
+
+// BuildGuardedDeleteQueue checks the deletions against the DeletionGuard and phases out products missing from the feed:
+// they are set out of stock, hidden after HideAfter and only deleted after DeleteAfter
+func (w *WooConnection) BuildGuardedDeleteQueue(delete []int, update map[uint64]*Product, nOld, nNew int) (plan DeletionPlan, err error) {
+	w.deletions = nil
+
+	plan, state, err := w.planDeletions(delete, update, nOld, nNew)
+	if err != nil || state == nil {
+		return plan, err
+	}
+
+	err = w.BuildDeleteProductQueue(plan.Deleted)
+	if err != nil {
+		return plan, err
+	}
+	w.buildSoftDeleteQueue(plan.OutOfStock, "publish")
+	w.buildSoftDeleteQueue(plan.Hidden, "draft")
+
+	log.WithFields(
+		log.Fields{
+			"Out Of Stock": len(plan.OutOfStock),
+			"Hidden":       len(plan.Hidden),
+			"Deleted":      len(plan.Deleted),
+		},
+	).Infoln("Prepared deletions")
+
+	// only stored once the delete queue went through, so retries don't count as missed runs
+	w.deletions = state
+
+	return plan, nil
+}
+
+// commitDeletionState stores the state of the latest BuildGuardedDeleteQueue
+func (w *WooConnection) commitDeletionState() error {
+	if w.deletions == nil {
+		return nil
+	}
+
+	c, err := w.openDeletionCache()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	err = storeDeletionState(c, *w.deletions)
+	if err != nil {
+		return fmt.Errorf("Store deletion state - %v", err)
+	}
+	w.deletions = nil
+
+	return nil
+}
+
+func (w *WooConnection) openDeletionCache() (cache.Cache, error) {
+	c, err := cache.NewBadgerCache(
+		fmt.Sprintf("%s/cache/%s_deletions", helpers.FindFolderDir("gofeedyourself"), w.Locale),
+		deletionStateTTL,
+	)
+	if err != nil {
+		return c, fmt.Errorf("Init deletion state cache - %v", err)
+	}
+	return c, nil
+}
+
+// buildSoftDeleteQueue sets products out of stock, with status draft they disappear from the shop but keep their page
+func (w *WooConnection) buildSoftDeleteQueue(ids []int, status string) {
+	for start := 0; start < len(ids); start += BatchStrideSize {
+		r := gwc.BatchPostRequest{
+			Endpoint: "products/batch",
+			Locale:   w.Locale,
+		}
+		for _, id := range ids[start:minInt(start+BatchStrideSize, len(ids))] {
+			r.Update = append(
+				r.Update,
+				softDeletion{
+					ID:          id,
+					Status:      status,
+					StockStatus: "outofstock",
+				},
+			)
+		}
+		w.Connection.PushToQueue("delete", r)
+	}
+}
+
+func loadDeletionState(c cache.Cache) (state deletionState, err error) {
+	stored, err := c.LoadAll()
+	if err != nil {
+		return state, err
+	}
+
+	raw, exists := stored[deletionStateKey]
+	if exists {
+		err = json.Unmarshal(raw, &state)
+	}
+	if state.Missed == nil {
+		state.Missed = make(map[int]int)
+	}
+	if state.LastSeen == nil {
+		state.LastSeen = make(map[int]int64)
+	}
+	return state, err
+}
+
+func storeDeletionState(c cache.Cache, state deletionState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return c.Store(
+		map[string][]byte{
+			deletionStateKey: raw,
+		},
+	)
+}
@@ -0,0 +1,311 @@
+package woocommerce
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	gwc "stillgrove.com/gofeedyourself/pkg/woocommerce/client"
+)
+
+// productSnapshot keeps the fields of a product in the WC backend that are compared in a DiffReport
+type productSnapshot struct {
+	ID           uint64
+	SKU          string
+	Name         string
+	RegularPrice string
+	SalePrice    string
+	StockStatus  string
+	Store        string
+	ExternalURL  string
+	Categories   []int32
+	Terms        map[int32][]string // term keys of the options per global attribute id
+}
+
+func snapshotFromProduct(p *gwc.Product) *productSnapshot {
+	s := &productSnapshot{
+		ID:           p.ID,
+		SKU:          p.SKU,
+		Name:         p.Name,
+		RegularPrice: p.RegularPrice,
+		SalePrice:    p.SalePrice,
+		StockStatus:  p.StockStatus,
+		Store:        p.ButtonText,
+		ExternalURL:  p.ExternalURL,
+	}
+	for i := range p.Categories {
+		s.Categories = append(s.Categories, p.Categories[i].ID)
+	}
+	for i := range p.Attributes {
+		if p.Attributes[i].ID == 0 {
+			continue
+		}
+		if s.Terms == nil {
+			s.Terms = make(map[int32][]string)
+		}
+		for _, option := range p.Attributes[i].Options {
+			s.Terms[p.Attributes[i].ID] = append(s.Terms[p.Attributes[i].ID], termKey(option))
+		}
+	}
+	return s
+}
+
+// FieldChange is the before/after value of a single field
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ProductDiff lists what would happen to a single product
+type ProductDiff struct {
+	ID      uint64        `json:"id,omitempty"`
+	SKU     string        `json:"sku"`
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// DiffReport is a human readable summary of an update, built from the create/update groups and the deletion plan
+type DiffReport struct {
+	Created    time.Time     `json:"created"`
+	Create     []ProductDiff `json:"create"`
+	Update     []ProductDiff `json:"update"`
+	Delete     []ProductDiff `json:"delete"`
+	OutOfStock []ProductDiff `json:"out_of_stock"` // missing products set out of stock
+	Hide       []ProductDiff `json:"hide"`         // missing products set to draft
+	HeldBack   []ProductDiff `json:"held_back"`    // missing products the deletion guard leaves alone
+	Unchanged  int           `json:"unchanged"`
+}
+
+// NewDiffReport compares the groups returned by GetGroups and the deletion plan with the products currently in the WC backend
+func NewDiffReport(create, update map[uint64]*Product, plan DeletionPlan, oldProducts map[uint64]*productSnapshot) *DiffReport {
+	d := &DiffReport{
+		Created: time.Now(),
+	}
+
+	for k := range create {
+		d.Create = append(
+			d.Create,
+			ProductDiff{
+				SKU:  create[k].SKU,
+				Name: create[k].Name,
+			},
+		)
+	}
+
+	for k := range update {
+		before, exists := oldProducts[k]
+		if !exists {
+			continue
+		}
+		after := snapshotFromProduct(&update[k].Product)
+		changes := compareSnapshots(before, after)
+		if len(changes) == 0 {
+			d.Unchanged++
+			continue
+		}
+		d.Update = append(
+			d.Update,
+			ProductDiff{
+				ID:      before.ID,
+				SKU:     before.SKU,
+				Name:    before.Name,
+				Changes: changes,
+			},
+		)
+	}
+
+	d.Delete = missingProducts(plan.Deleted, oldProducts)
+	d.OutOfStock = missingProducts(plan.OutOfStock, oldProducts)
+	d.Hide = missingProducts(plan.Hidden, oldProducts)
+	d.HeldBack = missingProducts(plan.HeldBack, oldProducts)
+
+	for _, group := range [][]ProductDiff{d.Create, d.Update, d.Delete, d.OutOfStock, d.Hide, d.HeldBack} {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Name < group[j].Name
+		})
+	}
+
+	return d
+}
+
+// missingProducts returns the products in the WC backend with the ids
+func missingProducts(ids []int, oldProducts map[uint64]*productSnapshot) (diffs []ProductDiff) {
+	wanted := make(map[uint64]struct{}, len(ids))
+	for i := range ids {
+		wanted[uint64(ids[i])] = struct{}{}
+	}
+	for k := range oldProducts {
+		_, exists := wanted[oldProducts[k].ID]
+		if !exists {
+			continue
+		}
+		diffs = append(
+			diffs,
+			ProductDiff{
+				ID:   oldProducts[k].ID,
+				SKU:  oldProducts[k].SKU,
+				Name: oldProducts[k].Name,
+			},
+		)
+	}
+	return diffs
+}
+
+func compareSnapshots(before, after *productSnapshot) (changes []FieldChange) {
+	var fields = []struct {
+		name          string
+		before, after string
+	}{
+		{"Regular Price", before.RegularPrice, after.RegularPrice},
+		{"Sale Price", before.SalePrice, after.SalePrice},
+		{"Stock", before.StockStatus, after.StockStatus},
+		{"Retailer", before.Store, after.Store},
+		{"Link", before.ExternalURL, after.ExternalURL},
+		{"Categories", formatIDs(before.Categories), formatIDs(after.Categories)},
+	}
+	for _, f := range fields {
+		// empty fields are omitted in the update payload and stay as they are
+		if f.after == "" || f.before == f.after {
+			continue
+		}
+		changes = append(
+			changes,
+			FieldChange{
+				Field:  f.name,
+				Before: f.before,
+				After:  f.after,
+			},
+		)
+	}
+	return changes
+}
+
+func formatIDs(ids []int32) string {
+	sorted := make([]int, len(ids))
+	for i := range ids {
+		sorted[i] = int(ids[i])
+	}
+	sort.Ints(sorted)
+
+	s := make([]string, len(sorted))
+	for i := range sorted {
+		s[i] = fmt.Sprint(sorted[i])
+	}
+	return strings.Join(s, ", ")
+}
+
+// Summary returns the counts and a sample of every group for the terminal
+func (d *DiffReport) Summary(sample int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(
+		&b,
+		"Create %d, Update %d, Delete %d, Out Of Stock %d, Hide %d, Held Back %d, Unchanged %d\n",
+		len(d.Create), len(d.Update), len(d.Delete), len(d.OutOfStock), len(d.Hide), len(d.HeldBack), d.Unchanged,
+	)
+
+	var groups = []struct {
+		name     string
+		products []ProductDiff
+	}{
+		{"Create", d.Create},
+		{"Update", d.Update},
+		{"Delete", d.Delete},
+		{"Out Of Stock", d.OutOfStock},
+		{"Hide", d.Hide},
+		{"Hold Back", d.HeldBack},
+	}
+	for _, g := range groups {
+		for i := range g.products {
+			if i >= sample {
+				fmt.Fprintf(&b, "  ... %d more\n", len(g.products)-sample)
+				break
+			}
+			fmt.Fprintf(&b, "%s %s (%s)\n", g.name, g.products[i].Name, g.products[i].SKU)
+			for _, c := range g.products[i].Changes {
+				fmt.Fprintf(&b, "    %s: %s -> %s\n", c.Field, c.Before, c.After)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// WriteJSON dumps the full report to filename
+func (d *DiffReport) WriteJSON(filename string) error {
+	raw, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, raw, 0644)
+}
+
+// WriteHTML renders the full report as a single page to filename
+func (d *DiffReport) WriteHTML(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return diffTemplate.Execute(f, d)
+}
+
+var diffTemplate = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>WooCommerce Update {{.Created.Format "2006-01-02 15:04"}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.before { color: #a00; }
+.after { color: #070; }
+</style>
+</head>
+<body>
+<h1>WooCommerce Update {{.Created.Format "2006-01-02 15:04"}}</h1>
+<p>Create {{len .Create}}, Update {{len .Update}}, Delete {{len .Delete}}, Out Of Stock {{len .OutOfStock}}, Hide {{len .Hide}}, Held Back {{len .HeldBack}}, Unchanged {{.Unchanged}}</p>
+
+<h2>Update</h2>
+<table>
+<tr><th>ID</th><th>SKU</th><th>Name</th><th>Field</th><th>Before</th><th>After</th></tr>
+{{range .Update}}{{$p := .}}{{range .Changes}}
+<tr><td>{{$p.ID}}</td><td>{{$p.SKU}}</td><td>{{$p.Name}}</td><td>{{.Field}}</td><td class="before">{{.Before}}</td><td class="after">{{.After}}</td></tr>
+{{end}}{{end}}
+</table>
+
+<h2>Create</h2>
+<table>
+<tr><th>SKU</th><th>Name</th></tr>
+{{range .Create}}<tr><td>{{.SKU}}</td><td>{{.Name}}</td></tr>
+{{end}}
+</table>
+
+{{define "missing"}}<table>
+<tr><th>ID</th><th>SKU</th><th>Name</th></tr>
+{{range .}}<tr><td>{{.ID}}</td><td>{{.SKU}}</td><td>{{.Name}}</td></tr>
+{{end}}
+</table>{{end}}
+<h2>Delete</h2>
+{{template "missing" .Delete}}
+
+<h2>Out Of Stock</h2>
+{{template "missing" .OutOfStock}}
+
+<h2>Hide</h2>
+{{template "missing" .Hide}}
+
+<h2>Held Back</h2>
+<p>Missing products the deletion guard leaves alone this run</p>
+{{template "missing" .HeldBack}}
+</body>
+</html>
+`))
@@ -10,6 +10,7 @@ import (
 	c "stillgrove.com/gofeedyourself/pkg/collection"
 	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
 	f "stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/ranking"
 	gwc "stillgrove.com/gofeedyourself/pkg/woocommerce/client"
 )
 
@@ -30,7 +31,15 @@ type ProductMapping struct {
 	attributeMap    map[string]*int32
 	brandMap        map[uint64]*int32
 	categoryMap     map[string]map[string][]*int32
+	termMap         map[string]map[string]*gwc.AttributeTerm
+	orphanTerms     map[int32][]*gwc.AttributeTerm // terms missing from the feed per attribute id
 	discountBinSize int
+	ranking         ranking.Model
+}
+
+// GetCategoryMap returns the synchronized WC category ids of the structure {gender: {name: [id, parent id]}}
+func (m *ProductMapping) GetCategoryMap() map[string]map[string][]*int32 {
+	return m.categoryMap
 }
 
 // ToWooProduct takes in a feed product and returns a woocommerce connection product to be uploaded
@@ -42,7 +51,8 @@ func (p *FeedProduct) ToWooProduct(mappings *ProductMapping) (wp *Product, err e
 			Description:      c.CollateString(p.Description, p.ShortDescription),
 			ShortDescription: c.CollateString(p.ShortDescription, p.Description),
 			Type:             "external",
-			MenuOrder:        p.CalculateRanking(),
+			Status:           "publish", // brings back products that were hidden while missing from the feed
+			MenuOrder:        mappings.rank(&p.Product),
 			//Language:         p.Language,
 			//Lang:             "sv_se",
 			CustomPrices: make(map[string]gwc.WpmlPrice),
@@ -58,6 +68,7 @@ func (p *FeedProduct) ToWooProduct(mappings *ProductMapping) (wp *Product, err e
 	}
 	wp.Language = p.Language
 	wp.Lang = p.Language
+	wp.setLastSeen(p.LastSeen)
 
 	categories, err := GetWCCategories(p.ProviderCategories, mappings.categoryMap, AllowMultiCats)
 	if err != nil {
@@ -218,6 +229,7 @@ func processAttributes(in *FeedProduct, out *Product, mappings *ProductMapping)
 			log.WithField("Name", k).Warningln("Not found in attribute map")
 			continue
 		}
+		v = mappings.resolveTerms(k, v)
 
 		out.Attributes = append(
 			out.Attributes,
@@ -248,14 +260,15 @@ func processAttributes(in *FeedProduct, out *Product, mappings *ProductMapping)
 			continue
 		}
 
-		options = []string{option}
-
 		_, exist = mappings.attributeMap[k]
 		if !exist {
 			log.Printf("Not found in attribute map - %s", k)
 			continue
 		}
 
+		options = mappings.resolveTerms(k, []string{option})
+		option = options[0]
+
 		out.Attributes = append(
 			out.Attributes,
 			gwc.Attribute{
@@ -283,12 +296,12 @@ func processAttributes(in *FeedProduct, out *Product, mappings *ProductMapping)
 	return attributes, nil
 }
 
-// GetWCCategories uses a mapping table of the structure {gender: {name: id}} to translate names into Woocommerce IDs
+// GetWCCategories uses a mapping table of the structure {gender: {name: [id, parent id]}} to translate names into Woocommerce IDs,
+// single category mode returns the most specific ID of the first matching category
 func GetWCCategories(providerCategories []feed.ProviderCategory, categoryMap map[string]map[string][]*int32, allowMultiCats bool) (wcCategories []int32, err error) {
 	var (
 		exist, matched bool
 		name, gender   string
-		added, failed  int
 	)
 
 	unique := make(map[int32]struct{})
@@ -298,14 +311,13 @@ func GetWCCategories(providerCategories []feed.ProviderCategory, categoryMap map
 
 		_, exist = categoryMap[gender][name]
 		if !exist {
-			CatNameMap := make(map[string]*string, len(categoryMap))
-			for k := range categoryMap {
+			CatNameMap := make(map[string]*string, len(categoryMap[gender]))
+			for k := range categoryMap[gender] {
 				key1 := k
 				CatNameMap[key1] = &key1
 			}
 			name, matched = c.FuzzyFindReplace(name, CatNameMap)
 			if !matched {
-				failed++
 				continue
 			}
 		}
@@ -313,7 +325,6 @@ func GetWCCategories(providerCategories []feed.ProviderCategory, categoryMap map
 		for j := range categoryMap[gender][name] {
 			key2 := *categoryMap[gender][name][j]
 			if key2 == 0 {
-				failed++
 				continue
 			}
 			_, exist = unique[key2]
@@ -321,32 +332,16 @@ func GetWCCategories(providerCategories []feed.ProviderCategory, categoryMap map
 				continue
 			}
 			unique[key2] = struct{}{}
-			added++
+			wcCategories = append(wcCategories, key2)
 		}
 	}
-	if added == 0 {
+	if len(wcCategories) == 0 {
 		return wcCategories, fmt.Errorf("No categories created for %v", providerCategories)
 	}
 
-	var it int
 	if allowMultiCats {
-		wcCategories = make([]int32, len(unique))
-		for k := range unique {
-			wcCategories[it] = k
-			it++
-		}
 		return wcCategories, nil
 	}
 
-	var candidate int32
-	for k := range unique {
-		if it == 0 {
-			candidate = k
-			continue
-		}
-		if k != 2859 && k != 2854 {
-			candidate = k
-		}
-	}
-	return []int32{candidate}, nil
+	return wcCategories[:1], nil
 }
@@ -0,0 +1,76 @@
+package woocommerce
+
+import (
+	"time"
+)
+
+// lastSeenMetaKey is the product meta field holding the unix time the product was last in a feed
+const lastSeenMetaKey = "_last_seen"
+
+// lifecycle stages of a product that is missing from the feeds
+const (
+	stageOutOfStock = iota
+	stageHidden
+	stageDeleted
+)
+
+// lifecycleStage returns how far a product missing since lastSeen is phased out,
+// short feed gaps only set it out of stock so it keeps its page and ranking
+func lifecycleStage(guard DeletionGuard, lastSeen, now time.Time, missedRuns int) int {
+	missing := now.Sub(lastSeen)
+	switch {
+	case missing >= guard.DeleteAfter && missedRuns >= guard.SoftDeleteRuns:
+		return stageDeleted
+	case missing >= guard.HideAfter:
+		return stageHidden
+	}
+	return stageOutOfStock
+}
+
+// setLastSeen stores the LastSeen of the feed product in the meta data of the WC product
+func (wp *Product) setLastSeen(lastSeen int32) {
+	if lastSeen == 0 {
+		return
+	}
+	wp.MetaData = append(
+		wp.MetaData,
+		map[string]interface{}{
+			"key":   lastSeenMetaKey,
+			"value": lastSeen,
+		},
+	)
+}
+
+// getLastSeen reads the LastSeen from the meta data, 0 if it isn't set
+func (wp *Product) getLastSeen() int64 {
+	for i := range wp.MetaData {
+		if wp.MetaData[i]["key"] != lastSeenMetaKey {
+			continue
+		}
+		switch v := wp.MetaData[i]["value"].(type) {
+		case int32:
+			return int64(v)
+		case int64:
+			return v
+		case float64:
+			return int64(v)
+		}
+	}
+	return 0
+}
+
+// collectLastSeen returns the LastSeen of the products in the update by WC id
+func collectLastSeen(update map[uint64]*Product, now time.Time) map[int]int64 {
+	seen := make(map[int]int64, len(update))
+	for k := range update {
+		if update[k].ID == 0 {
+			continue
+		}
+		ts := update[k].getLastSeen()
+		if ts == 0 {
+			ts = now.Unix()
+		}
+		seen[int(update[k].ID)] = ts
+	}
+	return seen
+}
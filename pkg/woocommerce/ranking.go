@@ -0,0 +1,29 @@
+package woocommerce
+
+import (
+	"time"
+
+	"stillgrove.com/gofeedyourself/pkg/feedservice/feed"
+	"stillgrove.com/gofeedyourself/pkg/ranking"
+)
+
+// SetRanking replaces the default ranking model the menu order is set by
+func (w *WooConnection) SetRanking(model ranking.Model) {
+	w.ranking = &model
+}
+
+func (w *WooConnection) getRanking() ranking.Model {
+	if w.ranking == nil || w.ranking.IsZero() {
+		return ranking.Default()
+	}
+	return *w.ranking
+}
+
+// rank returns the menu order of the product, mappings without a model rank by the default one
+func (m *ProductMapping) rank(p *feed.Product) int32 {
+	model := m.ranking
+	if model.IsZero() {
+		model = ranking.Default()
+	}
+	return model.Score(p, time.Now())
+}
@@ -8,18 +8,18 @@ import (
 	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
 )
 
-func loadCFG() (ws cfg.TdWebsite, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap map[string][]*string, categoryMap map[string]map[string][]*int32, err error) {
+func loadCFG() (ws cfg.TdWebsite, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap map[string][]*string, err error) {
 
 	configPath := helpers.FindFolderDir("gofeedyourself") + "/config/config.se.dev.yaml"
 	cfg, err := config.New(configPath)
 
 	if err != nil {
-		return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, categoryMap, err
+		return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, err
 	}
 
 	_, ws, err = cfg.GetTD()
 	if err != nil {
-		return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, categoryMap, err
+		return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, err
 	}
 
 	var mapNames = [...]string{
@@ -33,14 +33,14 @@ func loadCFG() (ws cfg.TdWebsite, ColorMap, SizeMap, PatternMap, GenderMap, CatN
 	for i := range mapNames {
 		maps[i], err = cfg.GetMapping(mapNames[i])
 		if err != nil {
-			return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, categoryMap, fmt.Errorf("Fetching %s - %v", mapNames[i], err)
+			return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, fmt.Errorf("Fetching %s - %v", mapNames[i], err)
 		}
 	}
 
-	categoryMap, CatNameMap, err = cfg.GetCategoryMaps()
+	CatNameMap, err = cfg.GetCategoryNames()
 	if err != nil {
-		return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, categoryMap, err
+		return ws, ColorMap, SizeMap, PatternMap, GenderMap, CatNameMap, err
 	}
 
-	return ws, maps[0], maps[1], maps[2], maps[3], CatNameMap, categoryMap, nil
+	return ws, maps[0], maps[1], maps[2], maps[3], CatNameMap, nil
 }
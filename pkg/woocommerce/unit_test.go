@@ -6,8 +6,16 @@ package woocommerce
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"stillgrove.com/gofeedyourself/pkg/feedservice/helpers"
@@ -228,6 +236,204 @@ func TestCategoriesUnit(t *testing.T) {
 	}
 }
 
+func TestCategoryTreeUnit(t *testing.T) {
+	pm, _, err := getExamples()
+	if err != nil {
+		t.Fatalf("Get examples - %v", err)
+	}
+
+	categories := extractCategories(pm)
+	if _, exists := categories["w"]["jeans"]; !exists {
+		t.Fatalf("Failed to extract categories - %v", categories)
+	}
+
+	if slug := categorySlug("w", ""); slug != "women" {
+		t.Fatalf("Wrong parent slug - %s", slug)
+	}
+	if slug := categorySlug("m", "T-Shirts & Tops"); slug != "men-t-shirts-tops" {
+		t.Fatalf("Wrong category slug - %s", slug)
+	}
+}
+
+func TestResolveTermsUnit(t *testing.T) {
+	mappings := ProductMapping{
+		termMap: map[string]map[string]*gwc.AttributeTerm{
+			"Brand": map[string]*gwc.AttributeTerm{
+				termKey("H&amp;M"): &gwc.AttributeTerm{
+					ID:   12,
+					Name: "H&amp;M",
+				},
+			},
+		},
+	}
+
+	resolved := mappings.resolveTerms("Brand", []string{" h&m", "Acne"})
+	if len(resolved) != 2 || resolved[0] != "H&M" || resolved[1] != "Acne" {
+		t.Fatalf("Failed to resolve terms - %v", resolved)
+	}
+
+	// WC looks the options up by name, an id would be registered as a new term
+	b, err := json.Marshal(gwc.Attribute{ID: 3, Name: "Brand", Option: resolved[0], Options: resolved})
+	if err != nil {
+		t.Fatalf("Failed to marshal attribute - %v", err)
+	}
+	var sent struct {
+		Options []string `json:"options"`
+	}
+	err = json.Unmarshal(b, &sent)
+	if err != nil || len(sent.Options) != 2 || sent.Options[0] != "H&M" || sent.Options[1] != "Acne" {
+		t.Fatalf("Expected the term names as options - %s", b)
+	}
+}
+
+func TestUnusedTermsUnit(t *testing.T) {
+	orphans := map[int32][]*gwc.AttributeTerm{
+		5: []*gwc.AttributeTerm{
+			&gwc.AttributeTerm{ID: 51, Name: "Linen"},
+			&gwc.AttributeTerm{ID: 52, Name: "Silk"},
+			&gwc.AttributeTerm{ID: 53, Name: "Wool"},
+		},
+	}
+	old := map[uint64]*productSnapshot{
+		// hidden products aren't counted by WC, but keep their terms
+		1: &productSnapshot{ID: 11, Terms: map[int32][]string{5: []string{termKey("linen")}}},
+		2: &productSnapshot{ID: 12, Terms: map[int32][]string{5: []string{termKey("Silk")}}},
+	}
+
+	unused := unusedTerms(orphans, old, []int{12})
+	if len(unused) != 1 || len(unused[5]) != 2 || unused[5][0] != 52 || unused[5][1] != 53 {
+		t.Fatalf("Expected the terms of the deleted and of no product - %v", unused)
+	}
+
+	snapshot := snapshotFromProduct(&gwc.Product{
+		ID:         13,
+		Attributes: []gwc.Attribute{{ID: 5, Options: []string{"Linen &amp; Silk"}}, {Name: "Custom", Options: []string{"x"}}},
+	})
+	if len(snapshot.Terms) != 1 || snapshot.Terms[5][0] != "linen & silk" {
+		t.Fatalf("Expected the term keys of the global attributes - %v", snapshot.Terms)
+	}
+}
+
+func TestDiffReportUnit(t *testing.T) {
+	old := map[uint64]*productSnapshot{
+		1: &productSnapshot{ID: 11, SKU: "A", Name: "Jeans", RegularPrice: "499", StockStatus: "instock", Categories: []int32{3, 2}},
+		2: &productSnapshot{ID: 12, SKU: "B", Name: "Shirt", RegularPrice: "199", StockStatus: "instock"},
+		3: &productSnapshot{ID: 13, SKU: "C", Name: "Shoes", RegularPrice: "899"},
+	}
+
+	jeans := &Product{}
+	jeans.RegularPrice = "399"
+	jeans.StockStatus = "instock"
+	jeans.Categories = []gwc.Category{{ID: 2}, {ID: 3}}
+
+	shirt := &Product{}
+	shirt.RegularPrice = "199"
+
+	dress := &Product{}
+	dress.SKU = "D"
+	dress.Name = "Dress"
+
+	d := NewDiffReport(
+		map[uint64]*Product{4: dress},
+		map[uint64]*Product{1: jeans, 2: shirt},
+		DeletionPlan{Deleted: []int{13}, HeldBack: []int{12}},
+		old,
+	)
+
+	if len(d.Create) != 1 || len(d.Update) != 1 || len(d.Delete) != 1 || len(d.HeldBack) != 1 || d.Unchanged != 1 {
+		t.Fatalf("Wrong groups - %s", d.Summary(10))
+	}
+	if len(d.Update[0].Changes) != 1 || d.Update[0].Changes[0].After != "399" {
+		t.Fatalf("Wrong changes - %v", d.Update[0].Changes)
+	}
+	if d.Delete[0].SKU != "C" {
+		t.Fatalf("Wrong product deleted - %v", d.Delete)
+	}
+	if d.HeldBack[0].SKU != "B" || len(d.Hide) != 0 || len(d.OutOfStock) != 0 {
+		t.Fatalf("Expected the held back product apart from the deletions - %s", d.Summary(10))
+	}
+
+	f, err := ioutil.TempFile("", "diff*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	err = d.WriteHTML(f.Name())
+	if err != nil {
+		t.Fatalf("Render diff report - %v", err)
+	}
+}
+
+func TestDeletionGuardUnit(t *testing.T) {
+	guard := DefaultDeletionGuard
+
+	if err := checkDeletions(guard, 10, 100, 90, 100); err != nil {
+		t.Fatalf("Normal churn was held back - %v", err)
+	}
+	if err := checkDeletions(guard, 50, 100, 50, 100); err == nil {
+		t.Fatal("Mass deletion wasn't held back")
+	}
+	if err := checkDeletions(guard, 0, 100, 50, 100); err == nil {
+		t.Fatal("Drop in product count wasn't held back")
+	}
+
+	now := time.Now()
+	day := int64(24 * 60 * 60)
+	state := deletionState{
+		Missed: map[int]int{
+			1: 2,
+			2: 1,
+			3: 1,
+			5: 5,
+		},
+		LastSeen: map[int]int64{
+			1: now.Unix() - 30*day,
+			2: now.Unix() - 5*day,
+			3: now.Unix() - 1*day,
+			5: now.Unix() - 1*day,
+		},
+	}
+	seen := map[int]int64{
+		3: now.Unix(),
+	}
+	outOfStock, hidden, hard := splitDeletions(&state, guard, []int{1, 2, 4, 5}, seen, now)
+	if len(hard) != 1 || hard[0] != 1 {
+		t.Fatalf("Wrong deletions - %v", hard)
+	}
+	if len(hidden) != 1 || hidden[0] != 2 {
+		t.Fatalf("Wrong hidden products - %v", hidden)
+	}
+	// 4 is missing for the first time, 5 missed enough runs but is within the grace period
+	if len(outOfStock) != 2 || outOfStock[0] != 4 || outOfStock[1] != 5 {
+		t.Fatalf("Wrong out of stock products - %v", outOfStock)
+	}
+	if _, exists := state.Missed[3]; exists || state.Missed[4] != 1 {
+		t.Fatalf("Missed runs not updated - %v", state.Missed)
+	}
+	if state.LastSeen[3] != now.Unix() || state.LastSeen[4] != now.Unix() {
+		t.Fatalf("Last seen not updated - %v", state.LastSeen)
+	}
+}
+
+func TestLastSeenUnit(t *testing.T) {
+	now := time.Now()
+
+	wp := &Product{}
+	wp.setLastSeen(int32(now.Unix() - 60))
+	update := map[uint64]*Product{
+		1: wp,
+		2: &Product{},
+	}
+	update[1].ID = 10
+	update[2].ID = 20
+
+	seen := collectLastSeen(update, now)
+	if seen[10] != now.Unix()-60 || seen[20] != now.Unix() {
+		t.Fatalf("Wrong last seen - %v", seen)
+	}
+}
+
 func TestHelpers(t *testing.T) {
 	var attributes = [...]string{
 		"Brand",
@@ -297,3 +503,113 @@ func TestGroups(t *testing.T) {
 		t.Fatalf("Incorrect grouping - create: %v, update: %v, delete %v", c, u, d)
 	}
 }
+
+func TestDryRunUnit(t *testing.T) {
+	// the logs and cache folders are found by the name of the repository
+	dir, err := ioutil.TempDir("", "dryrun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "gofeedyourself")
+	for _, sub := range []string{"logs", "cache"} {
+		err = os.MkdirAll(filepath.Join(root, sub), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(root)
+
+	products, err := feed.NewTestFeed("TestProducts").Get(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range products {
+		// products without a color group or a valid image are left out
+		products[i].ColorGroups = []string{"blue"}
+		products[i].ImageURL = fmt.Sprintf("https://www.images.com/%d.jpg", i)
+	}
+	pMap, err := feed.PMFromSlice(products)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the shop already has the categories of the products, a dry run can't create them
+	var categories []gwc.Category
+	for gender, names := range extractCategories(pMap) {
+		parent := int32(len(categories) + 1)
+		categories = append(categories, gwc.Category{ID: parent, Name: GenderCategories[gender], Slug: categorySlug(gender, "")})
+		for name := range names {
+			categories = append(categories, gwc.Category{ID: int32(len(categories) + 1), Name: name, Slug: categorySlug(gender, name), Parent: parent})
+		}
+	}
+
+	names, err := extractAttributeMap(pMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attributes []gwc.Attribute
+	for name := range names {
+		attributes = append(attributes, gwc.Attribute{ID: int32(len(attributes) + 100), Name: name})
+	}
+	pages := map[string][]interface{}{
+		"products/categories": {},
+		"products/attributes": {},
+	}
+	for i := range categories {
+		pages["products/categories"] = append(pages["products/categories"], categories[i])
+	}
+	for i := range attributes {
+		pages["products/attributes"] = append(pages["products/attributes"], attributes[i])
+	}
+
+	var (
+		mux    sync.Mutex
+		writes []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			mux.Lock()
+			writes = append(writes, r.Method+" "+r.URL.Path)
+			mux.Unlock()
+		}
+		page := []interface{}{}
+		for suffix := range pages {
+			if strings.HasSuffix(r.URL.Path, suffix) {
+				page = pages[suffix]
+			}
+		}
+		body, _ := json.Marshal(page)
+		rw.Header().Set("X-WP-Total", strconv.Itoa(len(page)))
+		rw.Header().Set("X-WP-TotalPages", "1")
+		rw.Write(body)
+	}))
+	defer server.Close()
+
+	c, err := NewWooConnection(server.URL, "key", "secret", "sv_se")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// what a dry run of the FeedService does
+	err = c.PrepareUpdate(pMap, false, false)
+	if err != nil {
+		t.Fatalf("Prepare update - %v", err)
+	}
+	for _, queue := range []string{"delete", "createupdate"} {
+		err = c.ApplyUpdate(queue, "json")
+		if err != nil {
+			t.Fatalf("Write %s queue - %v", queue, err)
+		}
+	}
+
+	if len(writes) > 0 {
+		t.Fatalf("Dry run changed the shop - %v", writes)
+	}
+	reports, _ := filepath.Glob(filepath.Join(root, "logs", "diff_*.html"))
+	if len(reports) != 1 {
+		t.Fatal("Expected the diff report of the dry run")
+	}
+}
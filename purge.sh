@@ -7,14 +7,14 @@ make purge
 case $1 in
     all)
         echo "Purging all products from WC backend"
-        ./wc-purge --mode=all
+        ./feedctl purge products
         ;;
     ftp-only)
         echo "Removing image assets from FTP server"
 
-        ./wc-purge --mode=ftp-only
+        ./feedctl purge images
         ;;
     *)
-        echo "Please specify dev or production"; exit
+        echo "Please specify all or ftp-only"; exit
         ;;
 esac
\ No newline at end of file
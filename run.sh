@@ -17,13 +17,13 @@ case $1 in
             -v "$(pwd)"/cache:/cache \
             -v "$(pwd)"/logs:/logs \
             {{repo}}.dkr.ecr.eu-central-1.amazonaws.com/{{reponame}} \
-            --mode=production env &
+            -config ./config/config.se.prod.yaml run -production &
         ;;
     dev)
         echo "Starting wc in dev mode"
 
         docker build -t wc-feedservice -f ./docker/wc/Dockerfile .
-        docker run --env-file private-env.list wc-feedservice --mode=dev env &
+        docker run --env-file private-env.list wc-feedservice run &
         ;;
     vsf-production)
         echo "Starting vsf in production mode"
@@ -37,7 +37,7 @@ case $1 in
             -v "$(pwd)"/cache:/cache \
             -v "$(pwd)"/logs:/logs \
             {{repo}}.dkr.ecr.eu-central-1.amazonaws.com/{{reponame}} \
-            --mode=production env &
+            -config ./config/config.se.prod.yaml run -production -backend vsf-dump &
         ;;
     vsf-dev)
         echo "Starting vsf in dev mode"
@@ -50,7 +50,7 @@ case $1 in
             -v "$(pwd)"/cache:/cache \
             -v "$(pwd)"/logs:/logs \
             vsf-feedservice \
-            --mode=dev env
+            run -backend vsf-dump
         ;;
     *)
         echo "Error: Please specify dev, production, vsf-production or vsf-dev"; exit